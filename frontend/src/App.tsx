@@ -11,6 +11,12 @@ import { RecurringPage } from './pages/RecurringPage';
 import { SettingsPage } from './pages/SettingsPage';
 import { BalancePage } from './pages/BalancePage';
 import { BulkExpensePage } from './pages/BulkExpensePage';
+import { SettlementPage } from './pages/SettlementPage';
+import { BusinessReportPage } from './pages/BusinessReportPage';
+import { MedicalReportPage } from './pages/MedicalReportPage';
+import { WeeklySummaryPage } from './pages/WeeklySummaryPage';
+import { MemberSummaryPage } from './pages/MemberSummaryPage';
+import { AnnualReportPage } from './pages/AnnualReportPage';
 import { config } from './config';
 import './App.css';
 
@@ -81,6 +87,12 @@ function AppRoutes() {
             <Route path="/settings" element={<AdminRoute><SettingsPage /></AdminRoute>} />
             <Route path="/balance" element={<AdminRoute><BalancePage /></AdminRoute>} />
             <Route path="/bulk" element={<AdminRoute><BulkExpensePage /></AdminRoute>} />
+            <Route path="/settlement" element={<SettlementPage />} />
+            <Route path="/business" element={<BusinessReportPage />} />
+            <Route path="/medical" element={<MedicalReportPage />} />
+            <Route path="/weekly" element={<WeeklySummaryPage />} />
+            <Route path="/members" element={<MemberSummaryPage />} />
+            <Route path="/annual" element={<AnnualReportPage />} />
             <Route path="*" element={<Navigate to="/" replace />} />
           </Routes>
         </main>
@@ -0,0 +1,179 @@
+import { useState, useEffect, useCallback } from 'react';
+import { summaryApi } from '../services/api';
+import type { AnnualReport, PeriodComparison, MonthComparison } from '../types';
+
+/** 増減の表示（増加は赤、減少は緑） */
+function DiffLabel({ diff, percent }: { diff: number; percent?: number }) {
+  if (diff === 0) return <span style={{ color: '#6b7280' }}>±0</span>;
+  return (
+    <span style={{ color: diff > 0 ? '#ef4444' : '#10b981' }}>
+      {diff > 0 ? '+' : '-'}&yen;{Math.abs(diff).toLocaleString()}
+      {percent ? ` (${percent > 0 ? '+' : ''}${percent.toFixed(1)}%)` : ''}
+    </span>
+  );
+}
+
+/** 比較対象の合計とカテゴリ別の増減 */
+function ComparisonList({ label, comparison }: { label: string; comparison: MonthComparison | null }) {
+  if (!comparison) return null;
+  return (
+    <div className="summary-category-list">
+      <div className="summary-category-item">
+        <span className="summary-category-name" style={{ fontWeight: 600 }}>{label} &yen;{comparison.total.toLocaleString()}</span>
+        <span className="summary-category-amount"><DiffLabel diff={comparison.diff} percent={comparison.diffPercent} /></span>
+      </div>
+      {(comparison.byCategory || []).map((c) => (
+        <div key={c.categoryId} className="summary-category-item">
+          <span className="summary-category-name">{c.category}</span>
+          <span className="summary-category-amount">&yen;{c.amount.toLocaleString()}</span>
+          <span className="summary-category-percent"><DiffLabel diff={c.diff} /></span>
+        </div>
+      ))}
+    </div>
+  );
+}
+
+function formatMonth(month: string): string {
+  return `${Number(month.slice(5, 7))}月`;
+}
+
+const cellStyle = { padding: '4px 6px', textAlign: 'right' as const, whiteSpace: 'nowrap' as const };
+
+// 年間レポート（暦年・年度の月別×カテゴリ別と前年比較）と期間比較
+export function AnnualReportPage() {
+  const [year, setYear] = useState(String(new Date().getFullYear()));
+  const [fiscal, setFiscal] = useState(false);
+  const [report, setReport] = useState<AnnualReport | null>(null);
+  const [loading, setLoading] = useState(true);
+
+  const [from, setFrom] = useState(`${new Date().getFullYear()}-01`);
+  const [to, setTo] = useState(`${new Date().getFullYear()}-12`);
+  const [compareFrom, setCompareFrom] = useState('');
+  const [compareTo, setCompareTo] = useState('');
+  const [periods, setPeriods] = useState<PeriodComparison | null>(null);
+  const [periodError, setPeriodError] = useState<string | null>(null);
+
+  const loadData = useCallback(async () => {
+    setLoading(true);
+    try {
+      setReport(await summaryApi.getAnnual(year, fiscal));
+    } catch (e) {
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  }, [year, fiscal]);
+
+  useEffect(() => {
+    loadData();
+  }, [loadData]);
+
+  const handleCompare = async () => {
+    setPeriodError(null);
+    try {
+      const result = await summaryApi.comparePeriods(from, to, compareFrom || undefined, compareTo || undefined);
+      setPeriods(result);
+      setCompareFrom(result.compareFrom);
+      setCompareTo(result.compareTo);
+    } catch (e) {
+      setPeriods(null);
+      setPeriodError(e instanceof Error ? e.message : '比較に失敗しました');
+    }
+  };
+
+  const title = report && report.startMonth !== 1 ? `${year}年度` : `${year}年`;
+
+  return (
+    <>
+      <div className="recurring-header">
+        <button className="modal-close-btn" onClick={() => setYear(String(Number(year) - 1))}>&lsaquo;</button>
+        <h2>{title}</h2>
+        <button className="modal-close-btn" onClick={() => setYear(String(Number(year) + 1))}>&rsaquo;</button>
+      </div>
+
+      <div className="payer-filter">
+        <button className={`payer-filter-btn ${!fiscal ? 'active' : ''}`} onClick={() => setFiscal(false)}>暦年</button>
+        <button className={`payer-filter-btn ${fiscal ? 'active' : ''}`} onClick={() => setFiscal(true)}>年度</button>
+      </div>
+
+      {loading || !report ? (
+        <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : (
+        <>
+          <div className="summary-totals">
+            <div className="summary-total-amount">&yen;{report.total.toLocaleString()}</div>
+            {report.elapsedMonths > 0 && (
+              <div className="summary-category-name">月平均 &yen;{report.average.toLocaleString()}（{report.elapsedMonths}ヶ月）</div>
+            )}
+          </div>
+
+          {/* 月別×カテゴリ別 */}
+          <div className="summary-category-list" style={{ overflowX: 'auto' }}>
+            <table style={{ borderCollapse: 'collapse', fontSize: '0.75rem' }}>
+              <thead>
+                <tr>
+                  <th style={{ ...cellStyle, textAlign: 'left' }}>カテゴリ</th>
+                  {report.months.map((m) => <th key={m} style={cellStyle}>{formatMonth(m)}</th>)}
+                  <th style={cellStyle}>合計</th>
+                  <th style={cellStyle}>平均</th>
+                </tr>
+              </thead>
+              <tbody>
+                {report.rows.map((r) => (
+                  <tr key={r.categoryId}>
+                    <td style={{ ...cellStyle, textAlign: 'left' }}>
+                      <span style={{ display: 'inline-block', width: 8, height: 8, borderRadius: 4, background: r.color, marginRight: 4 }} />
+                      {r.category}
+                    </td>
+                    {r.months.map((amount, i) => <td key={report.months[i]} style={cellStyle}>{amount ? amount.toLocaleString() : ''}</td>)}
+                    <td style={{ ...cellStyle, fontWeight: 600 }}>{r.total.toLocaleString()}</td>
+                    <td style={cellStyle}>{r.average.toLocaleString()}</td>
+                  </tr>
+                ))}
+                <tr style={{ borderTop: '1px solid #e5e7eb', fontWeight: 600 }}>
+                  <td style={{ ...cellStyle, textAlign: 'left' }}>合計</td>
+                  {report.monthTotals.map((total, i) => <td key={report.months[i]} style={cellStyle}>{total ? total.toLocaleString() : ''}</td>)}
+                  <td style={cellStyle}>{report.total.toLocaleString()}</td>
+                  <td style={cellStyle}>{report.average.toLocaleString()}</td>
+                </tr>
+              </tbody>
+            </table>
+          </div>
+
+          <ComparisonList label={`前年（${report.elapsedMonths}ヶ月）`} comparison={report.previousYear} />
+        </>
+      )}
+
+      {/* 期間比較 */}
+      <div className="summary-category-list">
+        <div className="summary-breakdown-tabs">
+          <button className="summary-breakdown-tab active">期間比較</button>
+        </div>
+        <div className="modal-field" style={{ padding: '0 16px' }}>
+          <label>対象期間</label>
+          <div style={{ display: 'flex', gap: 8 }}>
+            <input type="month" value={from} onChange={(e) => setFrom(e.target.value)} />
+            <input type="month" value={to} onChange={(e) => setTo(e.target.value)} />
+          </div>
+        </div>
+        <div className="modal-field" style={{ padding: '0 16px' }}>
+          <label>比較期間（空欄で直前の同じ月数）</label>
+          <div style={{ display: 'flex', gap: 8 }}>
+            <input type="month" value={compareFrom} onChange={(e) => setCompareFrom(e.target.value)} />
+            <input type="month" value={compareTo} onChange={(e) => setCompareTo(e.target.value)} />
+            <button className="recurring-add-btn" onClick={handleCompare}>比較</button>
+          </div>
+        </div>
+        {periodError && <div className="empty-state"><p>{periodError}</p></div>}
+      </div>
+      {periods && (
+        <>
+          <div className="summary-totals">
+            <div className="summary-total-amount">&yen;{periods.total.toLocaleString()}</div>
+          </div>
+          <ComparisonList label={`${periods.compareFrom}〜${periods.compareTo}`} comparison={periods.comparison} />
+        </>
+      )}
+    </>
+  );
+}
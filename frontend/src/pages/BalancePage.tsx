@@ -60,6 +60,8 @@ export function BalancePage() {
     const expMap = new Map<string, number>();
 
     for (const e of expenses) {
+      // 振替・残高調整・精算は収支に含めない
+      if (e.type && e.type !== 'expense') continue;
       if (expenseCategorySet.has(e.category)) {
         expMap.set(e.category, (expMap.get(e.category) || 0) + e.amount);
       } else {
@@ -0,0 +1,113 @@
+import { useState, useEffect, useCallback } from 'react';
+import { businessApi } from '../services/api';
+import type { BusinessReport } from '../types';
+
+// CSV をファイルとしてダウンロード
+function downloadCsv(fileName: string, content: string) {
+  const url = URL.createObjectURL(new Blob([content], { type: 'text/csv;charset=utf-8' }));
+  const a = document.createElement('a');
+  a.href = url;
+  a.download = fileName;
+  a.click();
+  URL.revokeObjectURL(url);
+}
+
+// 確定申告用の事業按分レポート（本人の負担分 × 事業割合を勘定科目別に集計）
+export function BusinessReportPage() {
+  // 確定申告は前年分が対象
+  const [year, setYear] = useState(String(new Date().getFullYear() - 1));
+  const [report, setReport] = useState<BusinessReport | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [expanded, setExpanded] = useState<string | null>(null);
+  const [toast, setToast] = useState<string | null>(null);
+
+  const loadData = useCallback(async () => {
+    setLoading(true);
+    try {
+      setReport(await businessApi.get(year));
+    } catch (e) {
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  }, [year]);
+
+  useEffect(() => {
+    loadData();
+  }, [loadData]);
+
+  useEffect(() => {
+    if (toast) {
+      const timer = setTimeout(() => setToast(null), 2000);
+      return () => clearTimeout(timer);
+    }
+  }, [toast]);
+
+  const handleExport = async () => {
+    try {
+      const csv = await businessApi.exportCsv(year);
+      downloadCsv(csv.fileName, csv.content);
+    } catch (e) {
+      console.error(e);
+      setToast('CSV の出力に失敗しました');
+    }
+  };
+
+  return (
+    <>
+      <div className="recurring-header">
+        <button className="modal-close-btn" onClick={() => setYear(String(Number(year) - 1))}>&lsaquo;</button>
+        <h2>{year}年 事業按分</h2>
+        <button className="modal-close-btn" onClick={() => setYear(String(Number(year) + 1))}>&rsaquo;</button>
+      </div>
+
+      {loading || !report ? (
+        <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : (
+        <>
+          <div className="summary-totals">
+            <div className="summary-total-amount">&yen;{report.total.toLocaleString()}</div>
+            <button className="recurring-add-btn" onClick={handleExport} disabled={report.lines.length === 0}>
+              CSVダウンロード
+            </button>
+          </div>
+
+          <div className="summary-category-list">
+            <div className="summary-breakdown-tabs">
+              <button className="summary-breakdown-tab active">勘定科目別</button>
+            </div>
+            {report.byAccount.length === 0 && (
+              <div className="empty-state"><p>事業割合を設定した支出はありません</p></div>
+            )}
+            {report.byAccount.map((a) => (
+              <div key={a.account}>
+                <div
+                  className="summary-category-item"
+                  style={{ cursor: 'pointer', ...(expanded === a.account ? { background: '#f3f4f6' } : {}) }}
+                  onClick={() => setExpanded(expanded === a.account ? null : a.account)}
+                >
+                  <span className="summary-category-name">{a.account}</span>
+                  <span className="summary-category-amount">&yen;{a.businessAmount.toLocaleString()}</span>
+                  <span className="summary-category-percent">{a.count}件</span>
+                </div>
+                {expanded === a.account && report.lines.filter((l) => l.account === a.account).map((l, i) => (
+                  <div key={`${l.expenseId}-${i}`} className="summary-category-item" style={{ paddingLeft: 16, background: '#f9fafb' }}>
+                    <span className="summary-category-name">
+                      {l.date} {[l.category, l.place, l.memo].filter(Boolean).join(' / ')}
+                    </span>
+                    <span className="summary-category-amount">
+                      &yen;{l.amount.toLocaleString()} × {l.ratio}%
+                    </span>
+                    <span className="summary-category-percent">&yen;{l.businessAmount.toLocaleString()}</span>
+                  </div>
+                ))}
+              </div>
+            ))}
+          </div>
+        </>
+      )}
+
+      {toast && <div className="toast">{toast}</div>}
+    </>
+  );
+}
@@ -1,7 +1,7 @@
 import { useState, useEffect, useCallback } from 'react';
 import { MonthPicker } from '../components/MonthPicker';
-import { expensesApi, categoriesApi, placesApi, payersApi } from '../services/api';
-import type { Expense, Category, Place, Payer, Visibility } from '../types';
+import { expensesApi, categoriesApi, placesApi, payersApi, summaryApi } from '../services/api';
+import type { Expense, Category, Place, Payer, Visibility, DailyTotals } from '../types';
 import { useAuth } from '../contexts/AuthContext';
 
 function todayString(): string {
@@ -26,15 +26,9 @@ function buildCalendarDays(year: number, month: number): (number | null)[] {
   return days;
 }
 
-/** 日別合計を集計（isExpense=true のカテゴリのみ） */
-function aggregateByDay(expenses: Expense[], expenseCategories: Set<string>): Map<number, number> {
-  const map = new Map<number, number>();
-  for (const e of expenses) {
-    if (!expenseCategories.has(e.category)) continue;
-    const day = parseInt(e.date.slice(8, 10), 10);
-    map.set(day, (map.get(day) || 0) + e.amount);
-  }
-  return map;
+/** 日別合計（サーバー集計）を日付→合計の Map に変換 */
+function totalsByDate(daily: DailyTotals | null): Map<string, number> {
+  return new Map((daily?.days || []).map((d) => [d.date, d.total]));
 }
 
 /** 95パーセンタイル値を算出（外れ値に強い基準値） */
@@ -53,6 +47,52 @@ function intensityColor(amount: number, refAmount: number): string {
   return `rgba(239, 68, 68, ${alpha})`;
 }
 
+/** 年間ヒートマップのセル（列=週、行=曜日、null = 年の範囲外） */
+function buildYearCells(year: number): (string | null)[] {
+  const first = new Date(year, 0, 1);
+  const cells: (string | null)[] = [];
+  for (let i = 0; i < first.getDay(); i++) cells.push(null);
+  for (let d = first; d.getFullYear() === year; d = new Date(d.getFullYear(), d.getMonth(), d.getDate() + 1)) {
+    cells.push(`${year}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`);
+  }
+  return cells;
+}
+
+interface YearHeatmapProps {
+  year: number;
+  daily: DailyTotals;
+  onSelect: (date: string) => void;
+}
+
+/** 1 年分の日別支出ヒートマップ（日付クリックでその月のカレンダーへ） */
+function YearHeatmap({ year, daily, onSelect }: YearHeatmapProps) {
+  const totals = totalsByDate(daily);
+  const topCategories = new Map(daily.days.map((d) => [d.date, d.topCategory]));
+  const refAmount = percentile95([...totals.values()].filter((v) => v > 0));
+
+  return (
+    <>
+      <div className="calendar-total">{year}年 合計: &yen;{daily.total.toLocaleString()}</div>
+      <div className="calendar-heatmap">
+        {buildYearCells(year).map((d, i) => {
+          if (d === null) return <div key={`empty-${i}`} className="calendar-heatmap-cell empty" />;
+          const total = totals.get(d) || 0;
+          const top = topCategories.get(d);
+          return (
+            <div
+              key={d}
+              className="calendar-heatmap-cell"
+              style={total > 0 ? { backgroundColor: intensityColor(total, refAmount) } : undefined}
+              title={`${formatDateShort(d)} ¥${total.toLocaleString()}${top ? ` (${top})` : ''}`}
+              onClick={() => onSelect(d)}
+            />
+          );
+        })}
+      </div>
+    </>
+  );
+}
+
 function formatDateShort(dateStr: string): string {
   const d = new Date(dateStr + 'T00:00:00');
   return `${d.getMonth() + 1}/${d.getDate()} (${WEEKDAYS[d.getDay()]})`;
@@ -173,6 +213,9 @@ export function CalendarPage() {
   const [editTarget, setEditTarget] = useState<Expense | null>(null);
   const [toast, setToast] = useState<string | null>(null);
   const [tab, setTab] = useState<'shared' | 'personal'>('shared');
+  // 月のカレンダー / 年間ヒートマップ（日別合計はサーバー側で集計）
+  const [view, setView] = useState<'month' | 'year'>('month');
+  const [daily, setDaily] = useState<DailyTotals | null>(null);
 
   const month = getMonth(date);
   const [yearStr, monthStr] = month.split('-');
@@ -182,13 +225,19 @@ export function CalendarPage() {
   const loadData = useCallback(async () => {
     setLoading(true);
     try {
-      const [exp, cats, plcs, pays] = await Promise.all([
+      if (view === 'year') {
+        setDaily(await summaryApi.getDaily(yearStr));
+        return;
+      }
+      const [exp, days, cats, plcs, pays] = await Promise.all([
         expensesApi.getByMonth(month),
+        summaryApi.getDaily(month),
         categoriesApi.getAll(),
         placesApi.getAll(),
         payersApi.getAll(),
       ]);
       setExpenses(exp);
+      setDaily(days);
       setCategories(cats);
       setPlaces(plcs);
       setPayers(pays);
@@ -197,7 +246,7 @@ export function CalendarPage() {
     } finally {
       setLoading(false);
     }
-  }, [month]);
+  }, [month, yearStr, view]);
 
   useEffect(() => {
     loadData();
@@ -240,7 +289,7 @@ export function CalendarPage() {
 
   // カレンダー用
   const days = buildCalendarDays(year, monthNum);
-  const dailyTotals = aggregateByDay(expenses, expenseCategories);
+  const dailyTotals = totalsByDate(daily);
   const nonZeroTotals = [...dailyTotals.values()].filter(v => v > 0);
   const maxAmount = nonZeroTotals.length > 0 ? percentile95(nonZeroTotals) : 0;
 
@@ -268,9 +317,17 @@ export function CalendarPage() {
   return (
     <>
       <MonthPicker value={date} onChange={setDate} mode="month" />
+      <div className="summary-breakdown-tabs">
+        <button className={`summary-breakdown-tab ${view === 'month' ? 'active' : ''}`}
+          onClick={() => setView('month')}>月</button>
+        <button className={`summary-breakdown-tab ${view === 'year' ? 'active' : ''}`}
+          onClick={() => setView('year')}>年間</button>
+      </div>
 
       {loading ? (
         <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : view === 'year' ? (
+        daily && <YearHeatmap year={year} daily={daily} onSelect={(d) => { setDate(d); setView('month'); }} />
       ) : (
         <>
           <div className="calendar-grid">
@@ -283,7 +340,7 @@ export function CalendarPage() {
               if (day === null) {
                 return <div key={`empty-${i}`} className="calendar-cell empty" />;
               }
-              const total = dailyTotals.get(day) || 0;
+              const total = dailyTotals.get(`${yearStr}-${monthStr}-${String(day).padStart(2, '0')}`) || 0;
               const isToday = day === todayDay;
               const dow = i % 7;
 
@@ -1,13 +1,21 @@
 import { useState, useEffect } from 'react';
 import { MonthPicker } from '../components/MonthPicker';
-import { categoriesApi, expensesApi, placesApi, payersApi } from '../services/api';
-import type { Category, Place, Payer, Visibility } from '../types';
+import { categoriesApi, expensesApi, placesApi, payersApi, exchangeRatesApi, settlementApi } from '../services/api';
+import type { Category, Place, Payer, Visibility, Split, SplitMethod, LineItem, TaxLine, MedicalKind } from '../types';
 
 function todayString(): string {
   const d = new Date();
   return `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
 }
 
+// "学校, 立替" のようなカンマ・空白区切りの入力をタグ配列に変換
+function parseTags(text: string): string[] {
+  return text.split(/[,、\s]+/).map((t) => t.replace(/^#/, '')).filter(Boolean);
+}
+
+// 消費税率（標準税率・軽減税率・非課税）
+const TAX_RATES = [10, 8, 0];
+
 export function ExpenseInputPage() {
   const [date, setDate] = useState(todayString());
   const [categories, setCategories] = useState<Category[]>([]);
@@ -18,8 +26,23 @@ export function ExpenseInputPage() {
   const [selectedPlace, setSelectedPlace] = useState('');
   const [customPlace, setCustomPlace] = useState('');
   const [amount, setAmount] = useState('');
+  // 明細（空=カテゴリ・金額で 1 件登録）
+  const [items, setItems] = useState<{ category: string; amount: string; memo: string }[]>([]);
+  // 消費税の内訳（空=内訳なし、included=税込、excluded=税抜で入力）
+  const [taxMode, setTaxMode] = useState<'' | 'included' | 'excluded'>('');
+  const [taxAmounts, setTaxAmounts] = useState<Record<number, string>>({});
+  const [currencies, setCurrencies] = useState<string[]>([]);
+  const [currency, setCurrency] = useState('');
   const [memo, setMemo] = useState('');
+  const [tagText, setTagText] = useState('');
+  const [patient, setPatient] = useState('');
+  const [provider, setProvider] = useState('');
+  const [medicalKind, setMedicalKind] = useState<MedicalKind>('treatment');
+  const [reimbursedAmount, setReimbursedAmount] = useState('');
   const [visibility, setVisibility] = useState<Visibility>('public');
+  const [members, setMembers] = useState<string[]>([]);
+  const [splitMethod, setSplitMethod] = useState<SplitMethod | ''>('');
+  const [splitRatios, setSplitRatios] = useState<Record<string, string>>({});
   const [loading, setLoading] = useState(false);
   const [toast, setToast] = useState<string | null>(null);
   const [loadError, setLoadError] = useState<string | null>(null);
@@ -29,6 +52,8 @@ export function ExpenseInputPage() {
       categoriesApi.getAll().then(setCategories),
       placesApi.getAll().then(setPlaces),
       payersApi.getAll().then(setPayers),
+      exchangeRatesApi.getAll().then((rates) => setCurrencies([...new Set((rates || []).map((r) => r.currency))])),
+      settlementApi.getMembers().then((m) => setMembers(m || [])),
     ]).catch((e) => {
       console.error(e);
       setLoadError(String(e));
@@ -47,26 +72,82 @@ export function ExpenseInputPage() {
   const selectedCat = categories.find((c) => c.id === selectedCategory);
   const isPersonalCategory = !!selectedCat?.ownerEmail;
 
-  const canSubmit = selectedCategory && selectedPayer && Number(amount) > 0;
+  const isItemized = items.length > 0;
+  const itemsTotal = items.reduce((sum, it) => sum + Number(it.amount || 0), 0);
+  // 医療費控除の対象カテゴリ（明細のいずれかを含む）の場合は医療費の項目を入力する
+  const isMedical = (isItemized ? items.map((it) => it.category) : [selectedCategory])
+    .some((id) => categories.find((c) => c.id === id)?.isMedical);
+  const taxLines: TaxLine[] = taxMode && !currency
+    ? TAX_RATES.filter((rate) => Number(taxAmounts[rate]) > 0).map((rate) => ({ rate, amount: Number(taxAmounts[rate]), tax: 0 }))
+    : [];
+  const hasTaxLines = taxLines.length > 0;
+  const canSubmit = isItemized
+    ? selectedPayer && items.every((it) => it.category && Number(it.amount) > 0)
+    : selectedCategory && selectedPayer && (Number(amount) > 0 || hasTaxLines);
+
+  const updateItem = (index: number, patch: Partial<{ category: string; amount: string; memo: string }>) => {
+    setItems(items.map((it, i) => (i === index ? { ...it, ...patch } : it)));
+  };
+
+  // 明細に分ける（現在のカテゴリ・金額を 1 行目にする）
+  const startItemize = () => {
+    setCurrency('');
+    setItems([
+      { category: selectedCategory, amount, memo: '' },
+      { category: '', amount: '', memo: '' },
+    ]);
+  };
+
+  const buildItems = (): LineItem[] | undefined => {
+    if (!isItemized) return undefined;
+    return items.map((it) => ({ category: it.category, amount: Number(it.amount), memo: it.memo || undefined }));
+  };
+
+  // 負担割合（空=精算対象外）
+  const buildSplit = (): Split | undefined => {
+    if (splitMethod === 'equal') return { method: 'equal', shares: [] };
+    if (splitMethod === 'ratio') {
+      return {
+        method: 'ratio',
+        shares: members
+          .map((email) => ({ email, value: Number(splitRatios[email] || 0) }))
+          .filter((s) => s.value > 0),
+      };
+    }
+    return undefined;
+  };
 
   const handleSubmit = async () => {
     if (!canSubmit) return;
 
     setLoading(true);
     try {
+      // 外貨建ての場合は登録日のレートでサーバー側が円換算する
+      // 明細がある場合はサーバー側で合計額・代表カテゴリを決める（消費税の内訳のみの場合は内訳の税込合計）
       const numAmount = Number(amount);
-      await expensesApi.create({
+      const created = await expensesApi.create({
         date,
         payer: selectedPayer,
-        category: selectedCategory,
-        amount: numAmount,
+        category: isItemized ? '' : selectedCategory,
+        amount: currency || isItemized || hasTaxLines ? 0 : numAmount,
+        items: buildItems(),
+        taxLines: hasTaxLines ? taxLines : undefined,
+        taxExcluded: hasTaxLines && taxMode === 'excluded' ? true : undefined,
+        currency: currency || undefined,
+        originalAmount: currency ? numAmount : undefined,
         memo,
         place: selectedPlace === '__other__' ? customPlace : selectedPlace,
         visibility,
+        split: buildSplit(),
+        tags: parseTags(tagText),
+        ...(isMedical ? { patient, provider, medicalKind, reimbursedAmount: Number(reimbursedAmount) } : {}),
       });
-      setToast(`${catNameMap.get(selectedCategory) || selectedCategory} \u00a5${numAmount.toLocaleString()} を登録しました`);
+      setToast(`${catNameMap.get(created.category) || created.category} \u00a5${created.amount.toLocaleString()} を登録しました`);
       setAmount('');
+      setItems([]);
+      setTaxAmounts({});
       setMemo('');
+      setReimbursedAmount('');
     } catch (e) {
       console.error(e);
       setToast('登録に失敗しました');
@@ -93,29 +174,110 @@ export function ExpenseInputPage() {
             ))}
           </select>
         </div>
-        <div className="input-field">
-          <label>カテゴリ</label>
-          <select value={selectedCategory} onChange={(e) => {
-            setSelectedCategory(e.target.value);
-            const cat = categories.find((c) => c.id === e.target.value);
-            if (cat?.ownerEmail) setVisibility('private');
-          }}>
-            <option value="">選択してください</option>
-            {categories.map((c) => (
-              <option key={c.id} value={c.id}>{c.name}</option>
+        {!isItemized && (
+          <div className="input-field">
+            <label>カテゴリ</label>
+            <select value={selectedCategory} onChange={(e) => {
+              setSelectedCategory(e.target.value);
+              const cat = categories.find((c) => c.id === e.target.value);
+              if (cat?.ownerEmail) setVisibility('private');
+            }}>
+              <option value="">選択してください</option>
+              {categories.map((c) => (
+                <option key={c.id} value={c.id}>{c.name}</option>
+              ))}
+            </select>
+          </div>
+        )}
+        {isItemized ? (
+          <div className="input-field">
+            <label>明細（合計 &yen;{itemsTotal.toLocaleString()}）</label>
+            {items.map((it, i) => (
+              <div key={i} style={{ display: 'flex', gap: '6px', marginTop: i > 0 ? '6px' : undefined }}>
+                <select value={it.category} onChange={(e) => updateItem(i, { category: e.target.value })} style={{ flex: 1 }}>
+                  <option value="">カテゴリ</option>
+                  {categories.map((c) => (
+                    <option key={c.id} value={c.id}>{c.name}</option>
+                  ))}
+                </select>
+                <input
+                  type="number"
+                  inputMode="numeric"
+                  placeholder="0"
+                  value={it.amount}
+                  onChange={(e) => updateItem(i, { amount: e.target.value })}
+                  style={{ width: '90px' }}
+                />
+                <input
+                  type="text"
+                  placeholder="メモ"
+                  value={it.memo}
+                  onChange={(e) => updateItem(i, { memo: e.target.value })}
+                  style={{ width: '80px' }}
+                />
+                <button className="modal-close-btn" onClick={() => setItems(items.filter((_, j) => j !== i))}>&times;</button>
+              </div>
             ))}
-          </select>
-        </div>
-        <div className="input-field">
-          <label>金額</label>
-          <input
-            type="number"
-            inputMode="numeric"
-            placeholder="0"
-            value={amount}
-            onChange={(e) => setAmount(e.target.value)}
-          />
-        </div>
+            <button
+              className="recurring-add-btn"
+              onClick={() => setItems([...items, { category: '', amount: '', memo: '' }])}
+              style={{ marginTop: '6px' }}
+            >
+              + 明細を追加
+            </button>
+          </div>
+        ) : (
+          <div className="input-field">
+            <label>金額</label>
+            <div style={{ display: 'flex', gap: '6px' }}>
+              {currencies.length > 0 && (
+                <select value={currency} onChange={(e) => setCurrency(e.target.value)} style={{ flex: '0 0 auto', width: 'auto' }}>
+                  <option value="">JPY</option>
+                  {currencies.map((c) => (
+                    <option key={c} value={c}>{c}</option>
+                  ))}
+                </select>
+              )}
+              <input
+                type="number"
+                inputMode={currency ? 'decimal' : 'numeric'}
+                step={currency ? '0.01' : undefined}
+                placeholder={hasTaxLines ? '消費税の内訳の合計' : '0'}
+                value={amount}
+                onChange={(e) => setAmount(e.target.value)}
+                disabled={hasTaxLines}
+              />
+            </div>
+            {!currency && (
+              <button className="recurring-add-btn" onClick={startItemize} style={{ marginTop: '6px' }}>
+                明細に分ける
+              </button>
+            )}
+          </div>
+        )}
+        {!currency && (
+          <div className="input-field">
+            <label>消費税の内訳</label>
+            <select value={taxMode} onChange={(e) => setTaxMode(e.target.value as '' | 'included' | 'excluded')}>
+              <option value="">なし</option>
+              <option value="included">税込金額で入力</option>
+              <option value="excluded">税抜金額で入力</option>
+            </select>
+            {taxMode && TAX_RATES.map((rate) => (
+              <div key={rate} style={{ display: 'flex', alignItems: 'center', gap: '6px', marginTop: '6px' }}>
+                <span style={{ flex: 1, fontSize: '0.8rem' }}>{rate}%{rate === 8 ? '（軽減税率）' : rate === 0 ? '（非課税）' : ''}</span>
+                <input
+                  type="number"
+                  inputMode="numeric"
+                  placeholder="0"
+                  value={taxAmounts[rate] || ''}
+                  onChange={(e) => setTaxAmounts({ ...taxAmounts, [rate]: e.target.value })}
+                  style={{ width: '120px' }}
+                />
+              </div>
+            ))}
+          </div>
+        )}
         <div className="input-field">
           <label>場所</label>
           <select value={selectedPlace} onChange={(e) => { setSelectedPlace(e.target.value); if (e.target.value !== '__other__') setCustomPlace(''); }}>
@@ -144,6 +306,69 @@ export function ExpenseInputPage() {
             onChange={(e) => setMemo(e.target.value)}
           />
         </div>
+        <div className="input-field">
+          <label>タグ</label>
+          <input
+            type="text"
+            placeholder="任意（カンマ区切り）"
+            value={tagText}
+            onChange={(e) => setTagText(e.target.value)}
+          />
+        </div>
+        {isMedical && (
+          <>
+            <div className="input-field">
+              <label>医療を受けた方</label>
+              <input type="text" placeholder="任意" value={patient} onChange={(e) => setPatient(e.target.value)} />
+            </div>
+            <div className="input-field">
+              <label>支払先（病院・薬局など）</label>
+              <input type="text" placeholder="空欄=場所" value={provider} onChange={(e) => setProvider(e.target.value)} />
+            </div>
+            <div className="input-field">
+              <label>医療費の区分</label>
+              <select value={medicalKind} onChange={(e) => setMedicalKind(e.target.value as MedicalKind)}>
+                <option value="treatment">診療・治療</option>
+                <option value="care">介護保険サービス</option>
+                <option value="medicine">医薬品購入</option>
+                <option value="other">その他の医療費</option>
+              </select>
+            </div>
+            <div className="input-field">
+              <label>保険金などで補填される金額</label>
+              <input
+                type="number"
+                inputMode="numeric"
+                placeholder="任意"
+                value={reimbursedAmount}
+                onChange={(e) => setReimbursedAmount(e.target.value)}
+              />
+            </div>
+          </>
+        )}
+        {members.length > 1 && (
+          <div className="input-field">
+            <label>負担</label>
+            <select value={splitMethod} onChange={(e) => setSplitMethod(e.target.value as SplitMethod | '')}>
+              <option value="">自分のみ</option>
+              <option value="equal">均等に分ける</option>
+              <option value="ratio">比率で分ける</option>
+            </select>
+            {splitMethod === 'ratio' && members.map((email) => (
+              <div key={email} style={{ display: 'flex', alignItems: 'center', gap: '6px', marginTop: '6px' }}>
+                <span style={{ flex: 1, fontSize: '0.8rem' }}>{email.split('@')[0]}</span>
+                <input
+                  type="number"
+                  inputMode="numeric"
+                  placeholder="0"
+                  value={splitRatios[email] || ''}
+                  onChange={(e) => setSplitRatios({ ...splitRatios, [email]: e.target.value })}
+                  style={{ width: '80px' }}
+                />
+              </div>
+            ))}
+          </div>
+        )}
         <div className="input-field">
           <label>公開設定</label>
           <select value={isPersonalCategory ? 'private' : visibility} onChange={(e) => setVisibility(e.target.value as Visibility)} disabled={isPersonalCategory}>
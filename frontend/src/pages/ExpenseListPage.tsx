@@ -1,8 +1,8 @@
 import { useState, useEffect, useCallback, useRef } from 'react';
 import { useSearchParams } from 'react-router-dom';
 import { MonthPicker } from '../components/MonthPicker';
-import { expensesApi, categoriesApi, placesApi, payersApi } from '../services/api';
-import type { Expense, Category, Place, Payer, Visibility } from '../types';
+import { expensesApi, categoriesApi, placesApi, payersApi, attachmentsApi } from '../services/api';
+import type { Expense, ExpenseInput, Category, Place, Payer, Visibility, Attachment, MedicalKind } from '../types';
 import { useAuth } from '../contexts/AuthContext';
 
 function todayString(): string {
@@ -21,6 +21,17 @@ function formatDateShort(dateStr: string): string {
   return `${d.getMonth() + 1}/${d.getDate()} (${WEEKDAYS[d.getDay()]})`;
 }
 
+// "学校, 立替" のようなカンマ・空白区切りの入力をタグ配列に変換
+function parseTags(text: string): string[] {
+  return text.split(/[,、\s]+/).map((t) => t.replace(/^#/, '')).filter(Boolean);
+}
+
+// 明細ごとのカテゴリ・金額（明細なしはカテゴリに全額、返金はカテゴリから差し引く）
+function expenseLines(e: Expense): { category: string; amount: number }[] {
+  if (e.type === 'refund') return [{ category: e.category, amount: -e.amount }];
+  return e.items?.length ? e.items : [{ category: e.category, amount: e.amount }];
+}
+
 // 日付ごとにグルーピング
 function groupByDate(expenses: Expense[]): Map<string, Expense[]> {
   const map = new Map<string, Expense[]>();
@@ -35,23 +46,172 @@ function groupByDate(expenses: Expense[]): Map<string, Expense[]> {
   return map;
 }
 
+const ATTACHMENT_ACCEPT = 'image/jpeg,image/png,image/webp,image/heic,application/pdf';
+
+// 添付ファイル（レシート・請求書）の一覧・追加・削除
+function AttachmentField({ expenseId, initial }: { expenseId: string; initial: Attachment[] }) {
+  const [attachments, setAttachments] = useState(initial);
+  const [busy, setBusy] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const handleUpload = async (file: File | undefined) => {
+    if (!file) return;
+    setBusy(true);
+    setError(null);
+    try {
+      const added = await attachmentsApi.upload(expenseId, file);
+      setAttachments([...attachments, added]);
+    } catch (e) {
+      console.error(e);
+      setError(e instanceof Error ? e.message : 'アップロードに失敗しました');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const handleOpen = async (a: Attachment) => {
+    try {
+      window.open(await attachmentsApi.getUrl(expenseId, a.id), '_blank');
+    } catch (e) {
+      console.error(e);
+      setError('添付ファイルを開けませんでした');
+    }
+  };
+
+  const handleDelete = async (a: Attachment) => {
+    if (!confirm(`${a.fileName} を削除しますか？`)) return;
+    try {
+      await attachmentsApi.delete(expenseId, a.id);
+      setAttachments(attachments.filter((x) => x.id !== a.id));
+    } catch (e) {
+      console.error(e);
+      setError('削除に失敗しました');
+    }
+  };
+
+  return (
+    <div className="modal-field">
+      <label>添付ファイル</label>
+      {attachments.map((a) => (
+        <div key={a.id} style={{ display: 'flex', alignItems: 'center', gap: '6px', fontSize: '0.85rem' }}>
+          <span style={{ flex: 1, cursor: 'pointer', textDecoration: 'underline' }} onClick={() => handleOpen(a)}>
+            {a.fileName}
+          </span>
+          <button className="modal-close-btn" onClick={() => handleDelete(a)}>&times;</button>
+        </div>
+      ))}
+      <input type="file" accept={ATTACHMENT_ACCEPT} disabled={busy} onChange={(e) => handleUpload(e.target.files?.[0])} />
+      {busy && <span style={{ fontSize: '0.75rem', color: '#6b7280' }}>アップロード中...</span>}
+      {error && <span style={{ fontSize: '0.75rem', color: '#dc2626' }}>{error}</span>}
+    </div>
+  );
+}
+
+// 返品・一部返金の登録（返金元のカテゴリから差し引かれる）
+function RefundField({ expense, categories, onRefunded }: { expense: Expense; categories: Category[]; onRefunded: () => void }) {
+  const remaining = expense.amount - (expense.refundedAmount || 0);
+  const [open, setOpen] = useState(false);
+  const [date, setDate] = useState(todayString());
+  const [amount, setAmount] = useState(String(remaining));
+  const [category, setCategory] = useState(expense.items?.[0]?.category || expense.category);
+  const [busy, setBusy] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  const handleSubmit = async () => {
+    setBusy(true);
+    setError(null);
+    try {
+      await expensesApi.refund({
+        expenseId: expense.id, date, amount: Number(amount),
+        category: expense.items?.length ? category : undefined,
+      });
+      onRefunded();
+    } catch (e) {
+      console.error(e);
+      setError(e instanceof Error ? e.message : '返金の登録に失敗しました');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  return (
+    <div className="modal-field">
+      <label>返金{expense.refundedAmount ? `（返金済み ¥${expense.refundedAmount.toLocaleString()}）` : ''}</label>
+      {!open ? (
+        remaining > 0 && <button className="modal-btn" onClick={() => setOpen(true)}>返金を登録</button>
+      ) : (
+        <>
+          <input type="date" value={date} min={expense.date} onChange={(e) => setDate(e.target.value)} />
+          {!!expense.items?.length && (
+            <select value={category} onChange={(e) => setCategory(e.target.value)}>
+              {expense.items.map((it, i) => (
+                <option key={i} value={it.category}>{categories.find((c) => c.id === it.category)?.name || it.category}</option>
+              ))}
+            </select>
+          )}
+          <input type="number" value={amount} max={remaining} onChange={(e) => setAmount(e.target.value)} />
+          <button className="modal-btn modal-btn-primary" disabled={busy} onClick={handleSubmit}>返金を登録</button>
+        </>
+      )}
+      {error && <span style={{ fontSize: '0.75rem', color: '#dc2626' }}>{error}</span>}
+    </div>
+  );
+}
+
 interface EditModalProps {
   expense: Expense;
   categories: Category[];
   places: Place[];
   payers: Payer[];
-  onSave: (id: string, data: { date: string; payer: string; category: string; amount: number; memo: string; place: string; visibility?: Visibility }) => void;
+  onSave: (id: string, data: ExpenseInput) => void;
   onDelete: (id: string) => void;
+  onRefunded: () => void;
   onClose: () => void;
 }
 
-function EditModal({ expense, categories, places, payers, onSave, onDelete, onClose }: EditModalProps) {
+// 返金の記録は編集できないため、内容の表示と削除のみ
+function RefundModal({ expense, categories, onDelete, onClose }: Pick<EditModalProps, 'expense' | 'categories' | 'onDelete' | 'onClose'>) {
+  return (
+    <div className="modal-overlay" onClick={onClose}>
+      <div className="modal-content" onClick={(e) => e.stopPropagation()}>
+        <div className="modal-header">
+          <h3>返金</h3>
+          <button className="modal-close-btn" onClick={onClose}>&times;</button>
+        </div>
+        <div className="modal-field">
+          <label>{expense.date} {expense.payer}</label>
+          <span>
+            {categories.find((c) => c.id === expense.category)?.name || expense.category} -&yen;{expense.amount.toLocaleString()}
+          </span>
+        </div>
+        <div className="modal-actions">
+          <button
+            className="modal-btn modal-btn-danger"
+            onClick={() => { if (confirm('返金を削除しますか？')) onDelete(expense.id); }}
+          >
+            削除
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
+
+function EditModal({ expense, categories, places, payers, onSave, onDelete, onRefunded, onClose }: EditModalProps) {
   const [date, setDate] = useState(expense.date);
   const [payer, setPayer] = useState(expense.payer);
   const [category, setCategory] = useState(expense.category);
   const [amount, setAmount] = useState(String(expense.amount));
+  const [originalAmount, setOriginalAmount] = useState(String(expense.originalAmount ?? ''));
   const [place, setPlace] = useState(expense.place);
   const [memo, setMemo] = useState(expense.memo);
+  const [tagText, setTagText] = useState((expense.tags || []).join(', '));
+  const [businessRatio, setBusinessRatio] = useState(expense.businessRatio != null ? String(expense.businessRatio) : '');
+  const [patient, setPatient] = useState(expense.patient || '');
+  const [provider, setProvider] = useState(expense.provider || '');
+  const [medicalKind, setMedicalKind] = useState<MedicalKind>(expense.medicalKind || 'treatment');
+  const [reimbursedAmount, setReimbursedAmount] = useState(expense.reimbursedAmount ? String(expense.reimbursedAmount) : '');
+  const isMedical = [category, ...(expense.items || []).map((it) => it.category)].some((id) => categories.find((c) => c.id === id)?.isMedical);
   const [visibility, setVisibility] = useState<Visibility>((expense.visibility || 'public') as Visibility);
 
   return (
@@ -74,17 +234,34 @@ function EditModal({ expense, categories, places, payers, onSave, onDelete, onCl
             ))}
           </select>
         </div>
-        <div className="modal-field">
-          <label>カテゴリ</label>
-          <select value={category} onChange={(e) => setCategory(e.target.value)}>
-            {categories.map((c) => (
-              <option key={c.id} value={c.id}>{c.name}</option>
+        {expense.items?.length ? (
+          <div className="modal-field">
+            <label>明細</label>
+            {expense.items.map((it, i) => (
+              <div key={i} style={{ fontSize: '0.85rem' }}>
+                {categories.find((c) => c.id === it.category)?.name || it.category} &yen;{it.amount.toLocaleString()}{it.memo ? ` (${it.memo})` : ''}
+              </div>
             ))}
-          </select>
-        </div>
+          </div>
+        ) : (
+          <div className="modal-field">
+            <label>カテゴリ</label>
+            <select value={category} onChange={(e) => setCategory(e.target.value)}>
+              {categories.map((c) => (
+                <option key={c.id} value={c.id}>{c.name}</option>
+              ))}
+            </select>
+          </div>
+        )}
+        {expense.currency && (
+          <div className="modal-field">
+            <label>金額（{expense.currency}）</label>
+            <input type="number" step="0.01" value={originalAmount} onChange={(e) => setOriginalAmount(e.target.value)} />
+          </div>
+        )}
         <div className="modal-field">
-          <label>金額</label>
-          <input type="number" value={amount} onChange={(e) => setAmount(e.target.value)} />
+          <label>{expense.currency ? '円換算額（空欄=登録済みレートで換算）' : '金額'}</label>
+          <input type="number" value={amount} onChange={(e) => setAmount(e.target.value)} disabled={!!expense.items?.length || !!expense.taxLines?.length} />
         </div>
         <div className="modal-field">
           <label>場所</label>
@@ -99,6 +276,39 @@ function EditModal({ expense, categories, places, payers, onSave, onDelete, onCl
           <label>メモ</label>
           <input type="text" value={memo} onChange={(e) => setMemo(e.target.value)} />
         </div>
+        <div className="modal-field">
+          <label>タグ</label>
+          <input type="text" placeholder="カンマ区切り" value={tagText} onChange={(e) => setTagText(e.target.value)} />
+        </div>
+        <div className="modal-field">
+          <label>事業割合（%）</label>
+          <input type="number" min={0} max={100} placeholder="空欄=カテゴリの設定" value={businessRatio} onChange={(e) => setBusinessRatio(e.target.value)} />
+        </div>
+        {isMedical && (
+          <>
+            <div className="modal-field">
+              <label>医療を受けた方</label>
+              <input type="text" value={patient} onChange={(e) => setPatient(e.target.value)} />
+            </div>
+            <div className="modal-field">
+              <label>支払先（病院・薬局など）</label>
+              <input type="text" placeholder="空欄=場所" value={provider} onChange={(e) => setProvider(e.target.value)} />
+            </div>
+            <div className="modal-field">
+              <label>医療費の区分</label>
+              <select value={medicalKind} onChange={(e) => setMedicalKind(e.target.value as MedicalKind)}>
+                <option value="treatment">診療・治療</option>
+                <option value="care">介護保険サービス</option>
+                <option value="medicine">医薬品購入</option>
+                <option value="other">その他の医療費</option>
+              </select>
+            </div>
+            <div className="modal-field">
+              <label>保険金などで補填される金額</label>
+              <input type="number" min={0} value={reimbursedAmount} onChange={(e) => setReimbursedAmount(e.target.value)} />
+            </div>
+          </>
+        )}
         <div className="modal-field">
           <label>公開設定</label>
           <select value={visibility} onChange={(e) => setVisibility(e.target.value as Visibility)}>
@@ -107,6 +317,10 @@ function EditModal({ expense, categories, places, payers, onSave, onDelete, onCl
             <option value="private">自分のみ</option>
           </select>
         </div>
+        <AttachmentField expenseId={expense.id} initial={expense.attachments || []} />
+        {(!expense.type || expense.type === 'expense') && (
+          <RefundField expense={expense} categories={categories} onRefunded={onRefunded} />
+        )}
         <div className="modal-actions">
           <button
             className="modal-btn modal-btn-danger"
@@ -116,7 +330,14 @@ function EditModal({ expense, categories, places, payers, onSave, onDelete, onCl
           </button>
           <button
             className="modal-btn modal-btn-primary"
-            onClick={() => onSave(expense.id, { date, payer, category, amount: Number(amount), memo, place, visibility })}
+            onClick={() => onSave(expense.id, {
+              type: expense.type, date, payer, toPayer: expense.toPayer, category, amount: Number(amount), items: expense.items,
+              currency: expense.currency, originalAmount: expense.currency ? Number(originalAmount) : undefined,
+              paidBy: expense.paidBy, split: expense.split, tags: parseTags(tagText),
+              taxLines: expense.taxLines, businessRatio: businessRatio === '' ? null : Number(businessRatio),
+              ...(isMedical ? { patient, provider, medicalKind, reimbursedAmount: Number(reimbursedAmount) } : {}),
+              memo, place, visibility,
+            })}
           >
             保存
           </button>
@@ -140,6 +361,7 @@ export function ExpenseListPage() {
   const [editTarget, setEditTarget] = useState<Expense | null>(null);
   const [toast, setToast] = useState<string | null>(null);
   const [tab, setTab] = useState<'shared' | 'personal'>('shared');
+  const [tagFilter, setTagFilter] = useState<string | null>(null);
 
   const month = getMonth(date);
 
@@ -150,7 +372,7 @@ export function ExpenseListPage() {
     setLoading(true);
     try {
       const [exp, cats, plcs, pays] = await Promise.all([
-        expensesApi.getByMonth(month),
+        expensesApi.getByMonth(month, tagFilter ? [tagFilter] : undefined),
         categoriesApi.getAll(),
         placesApi.getAll(),
         payersApi.getAll(),
@@ -164,7 +386,7 @@ export function ExpenseListPage() {
     } finally {
       setLoading(false);
     }
-  }, [month]);
+  }, [month, tagFilter]);
 
   useEffect(() => {
     loadData();
@@ -191,7 +413,7 @@ export function ExpenseListPage() {
     }
   }, [toast]);
 
-  const handleSave = async (id: string, data: { date: string; payer: string; category: string; amount: number; memo: string; place: string; visibility?: Visibility }) => {
+  const handleSave = async (id: string, data: ExpenseInput) => {
     try {
       await expensesApi.update(id, data);
       setEditTarget(null);
@@ -203,6 +425,12 @@ export function ExpenseListPage() {
     }
   };
 
+  const handleRefunded = async () => {
+    setEditTarget(null);
+    setToast('返金を登録しました');
+    await loadData();
+  };
+
   const handleDelete = async (id: string) => {
     try {
       await expensesApi.delete(id);
@@ -222,7 +450,10 @@ export function ExpenseListPage() {
         e.createdBy === user?.email &&
         (e.visibility === 'summary' || e.visibility === 'private')
       );
-  const total = filtered.filter((e) => expenseCategories.has(e.category)).reduce((sum, e) => sum + e.amount, 0);
+  const total = filtered
+    .flatMap((e) => expenseLines(e))
+    .filter((line) => expenseCategories.has(line.category))
+    .reduce((sum, line) => sum + line.amount, 0);
   const grouped = groupByDate(filtered);
 
   return (
@@ -234,6 +465,12 @@ export function ExpenseListPage() {
         <button className={`summary-breakdown-tab ${tab === 'personal' ? 'active' : ''}`}
           onClick={() => setTab('personal')}>個人</button>
       </div>
+      {tagFilter && (
+        <div className="expense-list-total">
+          #{tagFilter} で絞り込み中{' '}
+          <button className="modal-close-btn" onClick={() => setTagFilter(null)}>&times;</button>
+        </div>
+      )}
       <div className="expense-list-total">
         合計: &yen;{total.toLocaleString()}
       </div>
@@ -249,6 +486,10 @@ export function ExpenseListPage() {
               <div className="expense-date-header">{formatDateShort(dateKey)}</div>
               {items.map((item) => {
                 const isMasked = tab === 'shared' && item.visibility === 'summary' && item.createdBy !== user?.email;
+                const isTransfer = item.type === 'transfer';
+                const isAdjustment = item.type === 'adjustment';
+                const isSettlement = item.type === 'settlement';
+                const isRefund = item.type === 'refund';
                 return (
                   <div
                     key={item.id}
@@ -262,16 +503,42 @@ export function ExpenseListPage() {
                     />
                     <div className="expense-item-body">
                       <div className="expense-item-top">
-                        <span className="expense-item-category">{isMasked ? '個人出費' : (catNameMap.get(item.category) || item.category)}</span>
-                        <span className="expense-item-amount">&yen;{item.amount.toLocaleString()}</span>
+                        <span className="expense-item-category">{isTransfer ? '振替' : isAdjustment ? '残高調整' : isSettlement ? '精算' : isMasked ? '個人出費' : isRefund ? `返金（${catNameMap.get(item.category) || item.category}）` : (catNameMap.get(item.category) || item.category)}</span>
+                        <span className="expense-item-amount">{isRefund ? '-' : ''}&yen;{item.amount.toLocaleString()}</span>
                       </div>
                       <div className="expense-item-meta">
-                        {item.payer && <span className="expense-item-payer">{item.payer}</span>}
+                        {isTransfer ? (
+                          <span className="expense-item-payer">{item.payer} → {item.toPayer}</span>
+                        ) : isSettlement ? (
+                          <span className="expense-item-payer">{item.paidBy?.split('@')[0]} → {item.paidTo?.split('@')[0]}</span>
+                        ) : (
+                          item.payer && <span className="expense-item-payer">{item.payer}</span>
+                        )}
+                        {!isMasked && item.split && <span className="expense-item-payer">割り勘</span>}
+                        {!isMasked && !!item.attachments?.length && <span className="expense-item-payer">添付{item.attachments.length}</span>}
+                        {!isMasked && !!item.items?.length && (
+                          <span className="expense-item-memo">
+                            {item.items.map((it) => `${catNameMap.get(it.category) || it.category} ¥${it.amount.toLocaleString()}`).join(' / ')}
+                          </span>
+                        )}
+                        {!isMasked && item.currency && (
+                          <span className="expense-item-memo">{item.currency} {item.originalAmount?.toLocaleString()}</span>
+                        )}
                         {!isMasked && (item.place || item.memo) && (
                           <span className="expense-item-memo">
                             {[item.place, item.memo].filter(Boolean).join(' / ')}
                           </span>
                         )}
+                        {!isMasked && item.tags?.map((t) => (
+                          <span
+                            key={t}
+                            className="expense-item-memo"
+                            style={{ cursor: 'pointer' }}
+                            onClick={(ev) => { ev.stopPropagation(); setTagFilter(t); }}
+                          >
+                            #{t}
+                          </span>
+                        ))}
                       </div>
                     </div>
                   </div>
@@ -282,7 +549,14 @@ export function ExpenseListPage() {
         </div>
       )}
 
-      {editTarget && (
+      {editTarget?.type === 'refund' ? (
+        <RefundModal
+          expense={editTarget}
+          categories={categories}
+          onDelete={handleDelete}
+          onClose={() => setEditTarget(null)}
+        />
+      ) : editTarget && (
         <EditModal
           expense={editTarget}
           categories={categories}
@@ -290,7 +564,8 @@ export function ExpenseListPage() {
           payers={payers}
           onSave={handleSave}
           onDelete={handleDelete}
-          onClose={() => setEditTarget(null)}
+          onRefunded={handleRefunded}
+          onClose={() => { setEditTarget(null); loadData(); }}
         />
       )}
       {toast && <div className="toast">{toast}</div>}
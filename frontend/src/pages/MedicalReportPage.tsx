@@ -0,0 +1,136 @@
+import { useState, useEffect, useCallback } from 'react';
+import { medicalApi } from '../services/api';
+import type { MedicalReport, MedicalKind } from '../types';
+
+const KIND_LABELS: Record<MedicalKind, string> = {
+  treatment: '診療・治療',
+  care: '介護保険サービス',
+  medicine: '医薬品購入',
+  other: 'その他の医療費',
+};
+
+// CSV をファイルとしてダウンロード
+function downloadCsv(fileName: string, content: string) {
+  const url = URL.createObjectURL(new Blob([content], { type: 'text/csv;charset=utf-8' }));
+  const a = document.createElement('a');
+  a.href = url;
+  a.download = fileName;
+  a.click();
+  URL.revokeObjectURL(url);
+}
+
+// 確定申告用の医療費控除の明細書（医療を受けた方・支払先ごとの医療費と控除額）
+export function MedicalReportPage() {
+  // 確定申告は前年分が対象
+  const [year, setYear] = useState(String(new Date().getFullYear() - 1));
+  // 所得金額等の合計額（空=未指定、足切り額は 10 万円）
+  const [incomeText, setIncomeText] = useState('');
+  const [report, setReport] = useState<MedicalReport | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [toast, setToast] = useState<string | null>(null);
+  const income = Math.max(Number(incomeText) || 0, 0);
+
+  const loadData = useCallback(async () => {
+    setLoading(true);
+    try {
+      setReport(await medicalApi.get(year, income));
+    } catch (e) {
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  }, [year, income]);
+
+  useEffect(() => {
+    loadData();
+  }, [loadData]);
+
+  useEffect(() => {
+    if (toast) {
+      const timer = setTimeout(() => setToast(null), 2000);
+      return () => clearTimeout(timer);
+    }
+  }, [toast]);
+
+  const handleExport = async () => {
+    try {
+      const csv = await medicalApi.exportCsv(year, income);
+      downloadCsv(csv.fileName, csv.content);
+    } catch (e) {
+      console.error(e);
+      setToast('CSV の出力に失敗しました');
+    }
+  };
+
+  return (
+    <>
+      <div className="recurring-header">
+        <button className="modal-close-btn" onClick={() => setYear(String(Number(year) - 1))}>&lsaquo;</button>
+        <h2>{year}年 医療費控除</h2>
+        <button className="modal-close-btn" onClick={() => setYear(String(Number(year) + 1))}>&rsaquo;</button>
+      </div>
+
+      <div className="modal-field" style={{ padding: '0 16px' }}>
+        <label>所得金額等の合計額（空欄=足切り額 10 万円）</label>
+        <input type="number" inputMode="numeric" min={0} value={incomeText} onChange={(e) => setIncomeText(e.target.value)} />
+      </div>
+
+      {loading || !report ? (
+        <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : (
+        <>
+          <div className="summary-totals">
+            <div className="summary-total-amount">&yen;{report.deduction.toLocaleString()}</div>
+            <button className="recurring-add-btn" onClick={handleExport} disabled={report.rows.length === 0}>
+              CSVダウンロード
+            </button>
+          </div>
+
+          <div className="summary-category-list">
+            <div className="summary-category-item">
+              <span className="summary-category-name">医療費の合計 (A)</span>
+              <span className="summary-category-amount">&yen;{report.total.toLocaleString()}</span>
+            </div>
+            <div className="summary-category-item">
+              <span className="summary-category-name">補填される金額 (B)</span>
+              <span className="summary-category-amount">&yen;{report.reimbursed.toLocaleString()}</span>
+            </div>
+            <div className="summary-category-item">
+              <span className="summary-category-name">差引金額 (C)</span>
+              <span className="summary-category-amount">&yen;{report.net.toLocaleString()}</span>
+            </div>
+            <div className="summary-category-item">
+              <span className="summary-category-name">足切り額 (F)</span>
+              <span className="summary-category-amount">&yen;{report.threshold.toLocaleString()}</span>
+            </div>
+          </div>
+
+          <div className="summary-category-list">
+            <div className="summary-breakdown-tabs">
+              <button className="summary-breakdown-tab active">医療費の明細</button>
+            </div>
+            {report.rows.length === 0 && (
+              <div className="empty-state"><p>医療費控除の対象カテゴリの支出はありません</p></div>
+            )}
+            {report.rows.map((r) => (
+              <div key={`${r.patient}-${r.provider}`} className="summary-category-item">
+                <span className="summary-category-name">
+                  {[r.patient || '（未入力）', r.provider].filter(Boolean).join(' / ')}
+                  <span style={{ display: 'block', fontSize: '0.75rem', color: '#6b7280' }}>
+                    {r.kinds.map((k) => KIND_LABELS[k]).join('・')}
+                  </span>
+                </span>
+                <span className="summary-category-amount">&yen;{r.amount.toLocaleString()}</span>
+                <span className="summary-category-percent">
+                  {r.reimbursed > 0 ? `補填 ¥${r.reimbursed.toLocaleString()}` : `${r.count}件`}
+                </span>
+              </div>
+            ))}
+          </div>
+        </>
+      )}
+
+      {toast && <div className="toast">{toast}</div>}
+    </>
+  );
+}
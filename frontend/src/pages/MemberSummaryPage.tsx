@@ -0,0 +1,164 @@
+import { useState, useEffect, useCallback } from 'react';
+import { MonthPicker } from '../components/MonthPicker';
+import { useAuth } from '../contexts/AuthContext';
+import { summaryApi, usersApi } from '../services/api';
+import type { MemberSummary, MemberTotal } from '../types';
+
+function todayString(): string {
+  const d = new Date();
+  return `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
+}
+
+function formatMonth(month: string): string {
+  const [y, m] = month.split('-');
+  return `${y}年${Number(m)}月`;
+}
+
+type Period = 'month' | 'window';
+
+/** メンバーごとの合計とカテゴリ別内訳 */
+function MemberList({ members, grandTotal }: { members: MemberTotal[]; grandTotal: number }) {
+  if (members.length === 0) {
+    return <div className="empty-state"><p>この期間の支出はありません</p></div>;
+  }
+  return (
+    <>
+      {members.map((m) => (
+        <div key={m.email} className="summary-category-list">
+          <div className="summary-category-item">
+            <span className="summary-category-name" style={{ fontWeight: 600 }}>{m.name}</span>
+            <span className="summary-category-amount">&yen;{m.total.toLocaleString()}</span>
+            <span className="summary-category-percent">
+              {grandTotal > 0 ? `${(m.total / grandTotal * 100).toFixed(1)}%` : ''}
+            </span>
+          </div>
+          {m.byCategory.map((c) => (
+            <div key={c.categoryId || '__masked'} className="summary-category-item">
+              <div className="summary-category-color" style={{ background: c.color }} />
+              <span className="summary-category-name">{c.category}</span>
+              <span className="summary-category-amount">&yen;{c.amount.toLocaleString()}</span>
+              <span className="summary-category-percent">
+                {m.total > 0 ? `${(c.amount / m.total * 100).toFixed(1)}%` : ''}
+              </span>
+            </div>
+          ))}
+        </div>
+      ))}
+    </>
+  );
+}
+
+// メンバー別集計（登録者ごとの当月・直近 13 ヶ月の合計とカテゴリ別内訳）
+export function MemberSummaryPage() {
+  const { user } = useAuth();
+  const [date, setDate] = useState(todayString());
+  const [period, setPeriod] = useState<Period>('month');
+  const [summary, setSummary] = useState<MemberSummary | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [displayName, setDisplayName] = useState('');
+  const [toast, setToast] = useState<string | null>(null);
+
+  const month = date.slice(0, 7);
+
+  const loadData = useCallback(async () => {
+    setLoading(true);
+    try {
+      setSummary(await summaryApi.getMembers(month));
+    } catch (e) {
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  }, [month]);
+
+  useEffect(() => {
+    loadData();
+  }, [loadData]);
+
+  useEffect(() => {
+    const me = summary?.members.find((m) => m.email === user?.email) || summary?.window.find((m) => m.email === user?.email);
+    if (me && !displayName) setDisplayName(me.name);
+  }, [summary, user, displayName]);
+
+  const showToast = (msg: string) => {
+    setToast(msg);
+    setTimeout(() => setToast(null), 2000);
+  };
+
+  const handleSaveName = async () => {
+    try {
+      setDisplayName(await usersApi.updateDisplayName(displayName));
+      showToast('表示名を保存しました');
+      loadData();
+    } catch (e) {
+      showToast(e instanceof Error ? e.message : '保存に失敗しました');
+    }
+  };
+
+  const members = (period === 'month' ? summary?.members : summary?.window) || [];
+  const grandTotal = members.reduce((sum, m) => sum + m.total, 0);
+
+  return (
+    <>
+      <div className="recurring-header">
+        <h2>メンバー別</h2>
+      </div>
+
+      <div className="payer-filter">
+        <button className={`payer-filter-btn ${period === 'month' ? 'active' : ''}`} onClick={() => setPeriod('month')}>
+          {formatMonth(month)}
+        </button>
+        <button className={`payer-filter-btn ${period === 'window' ? 'active' : ''}`} onClick={() => setPeriod('window')}>
+          直近13ヶ月
+        </button>
+      </div>
+
+      {loading || !summary ? (
+        <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : (
+        <>
+          {period === 'window' && (
+            <div className="summary-totals">
+              <div className="summary-category-name">{formatMonth(summary.from)}〜{formatMonth(summary.month)}</div>
+            </div>
+          )}
+          <div className="summary-totals">
+            <div className="summary-total-amount">&yen;{grandTotal.toLocaleString()}</div>
+          </div>
+
+          <MemberList members={members} grandTotal={grandTotal} />
+
+          {/* 月別の推移（古い順） */}
+          {period === 'window' && (
+            <div className="summary-category-list">
+              <div className="summary-breakdown-tabs">
+                <button className="summary-breakdown-tab active">月の推移</button>
+              </div>
+              {summary.months.map((m) => (
+                <div key={m.month} className="summary-category-item" onClick={() => { setDate(`${m.month}-01`); setPeriod('month'); }} style={{ cursor: 'pointer' }}>
+                  <span className="summary-category-name">{formatMonth(m.month)}</span>
+                  <span className="summary-category-amount">
+                    {summary.window.map((w) => `${w.name} ¥${(m.totals[w.email] || 0).toLocaleString()}`).join(' / ')}
+                  </span>
+                </div>
+              ))}
+            </div>
+          )}
+        </>
+      )}
+
+      {/* 自分の表示名 */}
+      <div className="modal-field" style={{ padding: '0 16px' }}>
+        <label>自分の表示名（20文字まで、空欄でメールアドレスから表示）</label>
+        <div style={{ display: 'flex', gap: 8 }}>
+          <input type="text" value={displayName} maxLength={20} onChange={(e) => setDisplayName(e.target.value)} style={{ flex: 1 }} />
+          <button className="recurring-add-btn" onClick={handleSaveName}>保存</button>
+        </div>
+      </div>
+
+      <MonthPicker value={date} onChange={setDate} mode="month" />
+
+      {toast && <div className="toast">{toast}</div>}
+    </>
+  );
+}
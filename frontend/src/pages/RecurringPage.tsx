@@ -30,10 +30,12 @@ interface EditModalProps {
 
 function RecurringModal({ initial, categories, places, payers, onSave, onClose }: EditModalProps) {
   const [category, setCategory] = useState(initial?.category || (categories[0]?.id ?? ''));
-  const [amount, setAmount] = useState(initial ? String(initial.amount) : '');
+  const [currency, setCurrency] = useState(initial?.currency || '');
+  const [amount, setAmount] = useState(initial ? String(initial.currency ? initial.originalAmount : initial.amount) : '');
   const [payer, setPayer] = useState(initial?.payer || (payers[0]?.name ?? ''));
   const [place, setPlace] = useState(initial?.place || '');
   const [memo, setMemo] = useState(initial?.memo || '');
+  const [tagText, setTagText] = useState((initial?.tags || []).join(', '));
   const [visibility, setVisibility] = useState<Visibility>((initial?.visibility || 'public') as Visibility);
   const [frequency, setFrequency] = useState<'monthly' | 'bimonthly' | 'yearly'>(initial?.frequency || 'monthly');
   const [dayOfMonth, setDayOfMonth] = useState(initial ? String(initial.dayOfMonth) : '1');
@@ -43,13 +45,19 @@ function RecurringModal({ initial, categories, places, payers, onSave, onClose }
   const [isActive, setIsActive] = useState(initial?.isActive ?? true);
 
   const handleSubmit = () => {
+    // 外貨建ては登録時のレートで円換算する
     onSave({
       category,
-      amount: Number(amount),
+      amount: currency ? 0 : Number(amount),
+      currency: currency || undefined,
+      originalAmount: currency ? Number(amount) : undefined,
       payer,
       place,
       memo,
       visibility,
+      paidBy: initial?.paidBy,
+      split: initial?.split,
+      tags: tagText.split(/[,、\s]+/).map((t) => t.replace(/^#/, '')).filter(Boolean),
       frequency,
       dayOfMonth: Number(dayOfMonth),
       repeatMonth: frequency === 'yearly' ? Number(repeatMonth) : 0,
@@ -108,10 +116,22 @@ function RecurringModal({ initial, categories, places, payers, onSave, onClose }
         </div>
 
         <div className="modal-field">
-          <label>金額</label>
+          <label>通貨（空欄=円）</label>
+          <input
+            type="text"
+            value={currency}
+            onChange={(e) => setCurrency(e.target.value.toUpperCase())}
+            placeholder="USD"
+            maxLength={3}
+          />
+        </div>
+
+        <div className="modal-field">
+          <label>金額{currency && `（${currency}）`}</label>
           <input
             type="number"
-            inputMode="numeric"
+            inputMode={currency ? 'decimal' : 'numeric'}
+            step={currency ? '0.01' : undefined}
             value={amount}
             onChange={(e) => setAmount(e.target.value)}
             placeholder="0"
@@ -148,6 +168,16 @@ function RecurringModal({ initial, categories, places, payers, onSave, onClose }
           />
         </div>
 
+        <div className="modal-field">
+          <label>タグ</label>
+          <input
+            type="text"
+            value={tagText}
+            onChange={(e) => setTagText(e.target.value)}
+            placeholder="カンマ区切り（任意）"
+          />
+        </div>
+
         <div className="modal-field">
           <label>公開設定</label>
           <select value={visibility} onChange={(e) => setVisibility(e.target.value as Visibility)}>
@@ -261,16 +291,21 @@ export function RecurringPage() {
 
   const handleRegister = async (item: RecurringExpense) => {
     try {
-      await expensesApi.create({
+      const created = await expensesApi.create({
         date: todayString(),
         payer: item.payer,
         category: item.category,
         amount: item.amount,
+        currency: item.currency,
+        originalAmount: item.originalAmount,
         memo: item.memo,
         place: item.place,
         visibility: (item.visibility || 'public') as Visibility,
+        paidBy: item.paidBy,
+        split: item.split,
+        tags: item.tags,
       });
-      setToast(`${catNameMap.get(item.category) || item.category} ¥${item.amount.toLocaleString()} を登録しました`);
+      setToast(`${catNameMap.get(item.category) || item.category} ¥${created.amount.toLocaleString()} を登録しました`);
     } catch (e) {
       console.error(e);
       setToast('登録に失敗しました');
@@ -303,7 +338,9 @@ export function RecurringPage() {
               <div className="recurring-item-body">
                 <div className="recurring-item-top">
                   <span className="recurring-item-category">{catNameMap.get(item.category) || item.category}</span>
-                  <span className="recurring-item-amount">&yen;{item.amount.toLocaleString()}</span>
+                  <span className="recurring-item-amount">
+                    {item.currency ? `${item.currency} ${item.originalAmount?.toLocaleString()}` : <>&yen;{item.amount.toLocaleString()}</>}
+                  </span>
                 </div>
                 <div className="recurring-item-meta">
                   <span className="recurring-item-freq">{frequencyLabel(item)}</span>
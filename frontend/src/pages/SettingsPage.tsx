@@ -1,10 +1,10 @@
 import { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
-import { categoriesApi, placesApi, payersApi } from '../services/api';
+import { categoriesApi, placesApi, payersApi, exchangeRatesApi, settingsApi } from '../services/api';
 import { useAuth } from '../contexts/AuthContext';
-import type { Category, Place, Payer, CategoryInput, PlaceInput, PayerInput } from '../types';
+import type { Category, Place, Payer, CategoryInput, PlaceInput, PayerInput, ExchangeRate, ExchangeRateInput, WeekStart, SummaryCacheReport } from '../types';
 
-type Tab = 'categories' | 'places' | 'payers';
+type Tab = 'categories' | 'places' | 'payers' | 'rates';
 
 function hslToHex(h: number, s: number, l: number): string {
   s /= 100;
@@ -53,6 +53,9 @@ function CategoryModal({
   const [isExpense, setIsExpense] = useState(initial?.isExpense ?? true);
   const [excludeFromBreakdown, setExcludeFromBreakdown] = useState(initial?.excludeFromBreakdown ?? false);
   const [excludeFromSummary, setExcludeFromSummary] = useState(initial?.excludeFromSummary ?? false);
+  const [businessRatio, setBusinessRatio] = useState(String(initial?.businessRatio ?? 0));
+  const [expenseAccount, setExpenseAccount] = useState(initial?.expenseAccount || '');
+  const [isMedical, setIsMedical] = useState(initial?.isMedical ?? false);
 
   return (
     <div className="modal-overlay" onClick={onClose}>
@@ -108,6 +111,23 @@ function CategoryModal({
           </label>
         </div>
 
+        <div className="modal-field">
+          <label>事業割合（%、確定申告の按分用）</label>
+          <input type="number" min={0} max={100} value={businessRatio} onChange={(e) => setBusinessRatio(e.target.value)} />
+        </div>
+
+        <div className="modal-field">
+          <label>勘定科目</label>
+          <input type="text" value={expenseAccount} onChange={(e) => setExpenseAccount(e.target.value)} placeholder="空欄=カテゴリ名（例: 地代家賃、通信費）" />
+        </div>
+
+        <div className="modal-field">
+          <label className="recurring-active-label">
+            <input type="checkbox" checked={isMedical} onChange={(e) => setIsMedical(e.target.checked)} />
+            医療費控除の対象
+          </label>
+        </div>
+
         <div className="modal-field">
           <label className="recurring-active-label">
             <input type="checkbox" checked={isActive} onChange={(e) => setIsActive(e.target.checked)} />
@@ -118,7 +138,7 @@ function CategoryModal({
         <div className="modal-actions">
           <button
             className="modal-btn modal-btn-primary"
-            onClick={() => onSave({ name, sortOrder: Number(sortOrder), color, isActive, isExpense, excludeFromBreakdown, excludeFromSummary, ownerEmail: ownerEmail || initial?.ownerEmail })}
+            onClick={() => onSave({ name, sortOrder: Number(sortOrder), color, isActive, isExpense, excludeFromBreakdown, excludeFromSummary, ownerEmail: ownerEmail || initial?.ownerEmail, businessRatio: Number(businessRatio), expenseAccount, isMedical })}
             disabled={!name.trim()}
           >
             保存
@@ -206,6 +226,12 @@ function PayerModal({
   const [sortOrder, setSortOrder] = useState(String(initial?.sortOrder ?? 0));
   const [isActive, setIsActive] = useState(initial?.isActive ?? true);
   const [trackBalance, setTrackBalance] = useState(initial?.trackBalance ?? false);
+  const [openingBalance, setOpeningBalance] = useState(String(initial?.openingBalance ?? 0));
+  const [openingDate, setOpeningDate] = useState(initial?.openingDate || '');
+  const [closingDay, setClosingDay] = useState(String(initial?.closingDay ?? 0));
+  const [paymentDay, setPaymentDay] = useState(String(initial?.paymentDay ?? 0));
+  const [paymentMonthOffset, setPaymentMonthOffset] = useState(String(initial?.paymentMonthOffset || 1));
+  const [paymentSource, setPaymentSource] = useState(initial?.paymentSource || '');
 
   return (
     <div className="modal-overlay" onClick={onClose}>
@@ -232,6 +258,45 @@ function PayerModal({
           </label>
         </div>
 
+        {trackBalance && (
+          <>
+            <div className="modal-field">
+              <label>残高管理開始日（空欄=全期間）</label>
+              <input type="date" value={openingDate} onChange={(e) => setOpeningDate(e.target.value)} />
+            </div>
+            <div className="modal-field">
+              <label>開始時点の残高</label>
+              <input type="number" value={openingBalance} onChange={(e) => setOpeningBalance(e.target.value)} />
+            </div>
+          </>
+        )}
+
+        <div className="modal-field">
+          <label>締め日（0=カード以外、31=月末）</label>
+          <input type="number" min={0} max={31} value={closingDay} onChange={(e) => setClosingDay(e.target.value)} />
+        </div>
+
+        {Number(closingDay) > 0 && (
+          <>
+            <div className="modal-field">
+              <label>支払日</label>
+              <input type="number" min={1} max={31} value={paymentDay} onChange={(e) => setPaymentDay(e.target.value)} />
+            </div>
+            <div className="modal-field">
+              <label>支払月</label>
+              <select value={paymentMonthOffset} onChange={(e) => setPaymentMonthOffset(e.target.value)}>
+                <option value="1">翌月</option>
+                <option value="2">翌々月</option>
+                <option value="3">3ヶ月後</option>
+              </select>
+            </div>
+            <div className="modal-field">
+              <label>引き落とし元</label>
+              <input type="text" value={paymentSource} onChange={(e) => setPaymentSource(e.target.value)} placeholder="支払元名" />
+            </div>
+          </>
+        )}
+
         <div className="modal-field">
           <label className="recurring-active-label">
             <input type="checkbox" checked={isActive} onChange={(e) => setIsActive(e.target.checked)} />
@@ -242,7 +307,10 @@ function PayerModal({
         <div className="modal-actions">
           <button
             className="modal-btn modal-btn-primary"
-            onClick={() => onSave({ name, sortOrder: Number(sortOrder), isActive, trackBalance })}
+            onClick={() => onSave({
+              name, sortOrder: Number(sortOrder), isActive, trackBalance, openingBalance: Number(openingBalance), openingDate,
+              closingDay: Number(closingDay), paymentDay: Number(paymentDay), paymentMonthOffset: Number(paymentMonthOffset), paymentSource,
+            })}
             disabled={!name.trim()}
           >
             保存
@@ -256,6 +324,71 @@ function PayerModal({
   );
 }
 
+// --- 為替レートモーダル ---
+function ExchangeRateModal({
+  initial,
+  onSave,
+  onDelete,
+  onClose,
+}: {
+  initial?: ExchangeRate;
+  onSave: (input: ExchangeRateInput) => void;
+  onDelete?: () => void;
+  onClose: () => void;
+}) {
+  const [currency, setCurrency] = useState(initial?.currency || '');
+  const [date, setDate] = useState(initial?.date || '');
+  const [rate, setRate] = useState(initial ? String(initial.rate) : '');
+
+  return (
+    <div className="modal-overlay" onClick={onClose}>
+      <div className="modal-content" onClick={(e) => e.stopPropagation()}>
+        <div className="modal-header">
+          <h3>{initial ? '為替レートを編集' : '為替レートを追加'}</h3>
+          <button className="modal-close-btn" onClick={onClose}>&times;</button>
+        </div>
+
+        <div className="modal-field">
+          <label>通貨</label>
+          <input type="text" value={currency} onChange={(e) => setCurrency(e.target.value.toUpperCase())} placeholder="USD" maxLength={3} disabled={!!initial} />
+        </div>
+
+        <div className="modal-field">
+          <label>適用開始日</label>
+          <input type="date" value={date} onChange={(e) => setDate(e.target.value)} disabled={!!initial} />
+        </div>
+
+        <div className="modal-field">
+          <label>レート（1通貨あたりの円）</label>
+          <input type="number" step="0.0001" value={rate} onChange={(e) => setRate(e.target.value)} />
+        </div>
+
+        <div className="modal-actions">
+          <button
+            className="modal-btn modal-btn-primary"
+            onClick={() => onSave({ currency, date, rate: Number(rate) })}
+            disabled={currency.length !== 3 || !date || Number(rate) <= 0}
+          >
+            保存
+          </button>
+          {initial && onDelete && (
+            <button className="modal-btn modal-btn-danger" onClick={onDelete}>削除</button>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+}
+
+// parseExchangeRateCsv は「通貨,適用開始日,レート」形式の行を解析する（ヘッダー行・空行は無視）
+function parseExchangeRateCsv(text: string): ExchangeRateInput[] {
+  return text
+    .split(/\r?\n/)
+    .map((line) => line.split(',').map((v) => v.trim()))
+    .filter((cols) => cols.length >= 3 && /^\d{4}-\d{2}-\d{2}$/.test(cols[1]))
+    .map((cols) => ({ currency: cols[0].toUpperCase(), date: cols[1], rate: Number(cols[2]) }));
+}
+
 // --- メインページ ---
 export function SettingsPage() {
   const { user } = useAuth();
@@ -264,25 +397,40 @@ export function SettingsPage() {
   const [categories, setCategories] = useState<Category[]>([]);
   const [places, setPlaces] = useState<Place[]>([]);
   const [payers, setPayers] = useState<Payer[]>([]);
+  const [rates, setRates] = useState<ExchangeRate[]>([]);
+  const [monthStartDay, setMonthStartDay] = useState('1');
+  const [weekStart, setWeekStart] = useState<WeekStart>('monday');
+  const [fiscalYearStartMonth, setFiscalYearStartMonth] = useState('1');
   const [loading, setLoading] = useState(true);
   const [toast, setToast] = useState<string | null>(null);
+  const [cacheFrom, setCacheFrom] = useState(`${new Date().getFullYear()}-01`);
+  const [cacheTo, setCacheTo] = useState(`${new Date().getFullYear()}-${String(new Date().getMonth() + 1).padStart(2, '0')}`);
+  const [cacheReport, setCacheReport] = useState<SummaryCacheReport | null>(null);
 
   // モーダル状態
   const [editCategory, setEditCategory] = useState<Category | null | 'new' | 'new-personal'>(null);
   const [editPlace, setEditPlace] = useState<Place | null | 'new'>(null);
   const [editPayer, setEditPayer] = useState<Payer | null | 'new'>(null);
+  const [editRate, setEditRate] = useState<ExchangeRate | null | 'new'>(null);
+  const [rateCsv, setRateCsv] = useState('');
 
   const loadData = async () => {
     setLoading(true);
     try {
-      const [c, p, pay] = await Promise.all([
+      const [c, p, pay, r, s] = await Promise.all([
         categoriesApi.getAllIncludingInactive(),
         placesApi.getAllIncludingInactive(),
         payersApi.getAllIncludingInactive(),
+        exchangeRatesApi.getAll(),
+        settingsApi.get(),
       ]);
       setCategories(c || []);
       setPlaces(p || []);
       setPayers(pay || []);
+      setRates(r || []);
+      setMonthStartDay(String(s.monthStartDay || 1));
+      setWeekStart(s.weekStart || 'monday');
+      setFiscalYearStartMonth(String(s.fiscalYearStartMonth || 1));
     } catch (e) {
       console.error(e);
     } finally {
@@ -301,6 +449,34 @@ export function SettingsPage() {
     }
   }, [toast]);
 
+  // --- 集計キャッシュ（管理者のみ） ---
+  const handleRebuildSummaryCache = async (verify: boolean) => {
+    try {
+      const report = await settingsApi.rebuildSummaryCache(cacheFrom, cacheTo, verify);
+      setCacheReport(report);
+      setToast(verify
+        ? `照合しました（食い違い ${report.mismatches} ヶ月）`
+        : `${report.rebuilt} ヶ月分を再構築しました`);
+    } catch (e) {
+      console.error(e);
+      setToast(e instanceof Error ? e.message : '集計キャッシュの処理に失敗しました');
+    }
+  };
+
+  // --- 世帯設定 ---
+  const handleSaveSettings = async () => {
+    try {
+      const s = await settingsApi.update({ monthStartDay: Number(monthStartDay), weekStart, fiscalYearStartMonth: Number(fiscalYearStartMonth) });
+      setMonthStartDay(String(s.monthStartDay));
+      setWeekStart(s.weekStart || 'monday');
+      setFiscalYearStartMonth(String(s.fiscalYearStartMonth || 1));
+      setToast('月・週・年度の区切りを保存しました');
+    } catch (e) {
+      console.error(e);
+      setToast('保存に失敗しました');
+    }
+  };
+
   // --- カテゴリ CRUD ---
   const handleSaveCategory = async (input: CategoryInput) => {
     try {
@@ -394,6 +570,49 @@ export function SettingsPage() {
     }
   };
 
+  // --- 為替レート ---
+  const handleSaveRate = async (input: ExchangeRateInput) => {
+    try {
+      await exchangeRatesApi.put(input);
+      setToast('為替レートを保存しました');
+      setEditRate(null);
+      setRates(await exchangeRatesApi.getAll() || []);
+    } catch (e) {
+      console.error(e);
+      setToast('保存に失敗しました');
+    }
+  };
+
+  const handleDeleteRate = async (id: string) => {
+    if (!confirm('この為替レートを削除しますか？登録済みの支出の円換算額には影響しません。')) return;
+    try {
+      await exchangeRatesApi.delete(id);
+      setEditRate(null);
+      setToast('為替レートを削除しました');
+      setRates(await exchangeRatesApi.getAll() || []);
+    } catch (e) {
+      console.error(e);
+      setToast('削除に失敗しました');
+    }
+  };
+
+  const handleImportRates = async () => {
+    const inputs = parseExchangeRateCsv(rateCsv);
+    if (inputs.length === 0) {
+      setToast('取り込めるレートがありません');
+      return;
+    }
+    try {
+      const { imported } = await exchangeRatesApi.import(inputs);
+      setRateCsv('');
+      setToast(`${imported}件のレートを取り込みました`);
+      setRates(await exchangeRatesApi.getAll() || []);
+    } catch (e) {
+      console.error(e);
+      setToast('取り込みに失敗しました');
+    }
+  };
+
   if (loading) {
     return <div className="loading-spinner"><div className="spinner"></div></div>;
   }
@@ -411,8 +630,64 @@ export function SettingsPage() {
         <button className="recurring-link-btn" onClick={() => navigate('/bulk')} style={{ marginTop: 8 }}>
           一括登録
         </button>
+        <button className="recurring-link-btn" onClick={() => navigate('/settlement')} style={{ marginTop: 8 }}>
+          精算
+        </button>
+        <button className="recurring-link-btn" onClick={() => navigate('/business')} style={{ marginTop: 8 }}>
+          確定申告（事業按分）
+        </button>
+        <button className="recurring-link-btn" onClick={() => navigate('/medical')} style={{ marginTop: 8 }}>
+          医療費控除
+        </button>
+      </div>
+
+      {/* 月の開始日（給料日などに合わせて集計・残額の月を区切る）・年度の開始月・週の開始曜日 */}
+      <div className="modal-field" style={{ padding: '0 16px' }}>
+        <label>月の開始日（集計・残額の月の区切り）</label>
+        <select value={monthStartDay} onChange={(e) => setMonthStartDay(e.target.value)}>
+          {Array.from({ length: 28 }, (_, i) => i + 1).map((d) => (
+            <option key={d} value={String(d)}>{d === 1 ? '1日（カレンダー月）' : `${d}日`}</option>
+          ))}
+        </select>
+      </div>
+      <div className="modal-field" style={{ padding: '0 16px' }}>
+        <label>年度の開始月（年間レポートの年度）</label>
+        <select value={fiscalYearStartMonth} onChange={(e) => setFiscalYearStartMonth(e.target.value)}>
+          {Array.from({ length: 12 }, (_, i) => i + 1).map((m) => (
+            <option key={m} value={String(m)}>{m === 1 ? '1月（暦年）' : `${m}月`}</option>
+          ))}
+        </select>
+      </div>
+      <div className="modal-field" style={{ padding: '0 16px' }}>
+        <label>週の開始曜日（週別集計の区切り）</label>
+        <div style={{ display: 'flex', gap: 8 }}>
+          <select value={weekStart} onChange={(e) => setWeekStart(e.target.value as WeekStart)} style={{ flex: 1 }}>
+            <option value="monday">月曜日</option>
+            <option value="sunday">日曜日</option>
+          </select>
+          <button className="recurring-add-btn" onClick={handleSaveSettings}>保存</button>
+        </div>
       </div>
 
+      {/* 集計キャッシュの照合・再構築（インポートや DB の直接操作の後に使う） */}
+      {user?.role === 'admin' && (
+        <div className="modal-field" style={{ padding: '0 16px' }}>
+          <label>集計キャッシュ（管理者）</label>
+          <div style={{ display: 'flex', gap: 8 }}>
+            <input type="month" value={cacheFrom} onChange={(e) => setCacheFrom(e.target.value)} />
+            <input type="month" value={cacheTo} onChange={(e) => setCacheTo(e.target.value)} />
+            <button className="recurring-add-btn" onClick={() => handleRebuildSummaryCache(true)}>照合</button>
+            <button className="recurring-add-btn" onClick={() => handleRebuildSummaryCache(false)}>再構築</button>
+          </div>
+          {cacheReport && cacheReport.months.filter((m) => m.status === 'mismatch').map((m) => (
+            <div key={`${m.month}#${m.startDay}`} className="summary-category-name">
+              {m.month}（{m.startDay}日始まり）: {(m.diffs || []).map((d) => `${d.payer}/${d.categoryId} ${d.cached.toLocaleString()}→${d.actual.toLocaleString()}`).join('、')}
+              {m.privateMismatches ? ` 非公開 ${m.privateMismatches} 人` : ''}
+            </div>
+          ))}
+        </div>
+      )}
+
       {/* タブ */}
       <div className="settings-tabs">
         <button className={`settings-tab ${tab === 'categories' ? 'active' : ''}`} onClick={() => setTab('categories')}>
@@ -424,6 +699,9 @@ export function SettingsPage() {
         <button className={`settings-tab ${tab === 'payers' ? 'active' : ''}`} onClick={() => setTab('payers')}>
           支払元
         </button>
+        <button className={`settings-tab ${tab === 'rates' ? 'active' : ''}`} onClick={() => setTab('rates')}>
+          為替
+        </button>
       </div>
 
       {/* カテゴリタブ */}
@@ -448,6 +726,9 @@ export function SettingsPage() {
                       excludeFromBreakdown: sorted[i].excludeFromBreakdown,
                       excludeFromSummary: sorted[i].excludeFromSummary,
                       ownerEmail: sorted[i].ownerEmail,
+                      businessRatio: sorted[i].businessRatio,
+                      expenseAccount: sorted[i].expenseAccount,
+                      isMedical: sorted[i].isMedical,
                     });
                   }
                   setCategories(await categoriesApi.getAllIncludingInactive() || []);
@@ -594,6 +875,43 @@ export function SettingsPage() {
         </>
       )}
 
+      {/* 為替レートタブ */}
+      {tab === 'rates' && (
+        <>
+          <div className="settings-add-row">
+            <button className="recurring-add-btn" onClick={() => setEditRate('new')}>+ 追加</button>
+          </div>
+          <div className="settings-list">
+            {rates.map((r) => (
+              <div key={r.id} className="settings-item" onClick={() => setEditRate(r)}>
+                <div className="settings-item-body">
+                  <span className="settings-item-name">{r.currency} {r.rate.toLocaleString()}円</span>
+                  <span className="settings-item-meta">
+                    <span className="settings-item-order">{r.date}〜</span>
+                  </span>
+                </div>
+              </div>
+            ))}
+            {rates.length === 0 && <div className="empty-state"><p>為替レートがありません</p></div>}
+          </div>
+          <div className="modal-field" style={{ padding: '12px 16px' }}>
+            <label>CSV取り込み（通貨,適用開始日,レート）</label>
+            <textarea rows={4} value={rateCsv} onChange={(e) => setRateCsv(e.target.value)} placeholder={'USD,2025-01-01,150.25'} />
+            <button className="recurring-add-btn" style={{ marginTop: 8 }} onClick={handleImportRates} disabled={!rateCsv.trim()}>
+              取り込む
+            </button>
+          </div>
+          {editRate && (
+            <ExchangeRateModal
+              initial={editRate === 'new' ? undefined : editRate}
+              onSave={handleSaveRate}
+              onDelete={editRate !== 'new' ? () => handleDeleteRate(editRate.id) : undefined}
+              onClose={() => setEditRate(null)}
+            />
+          )}
+        </>
+      )}
+
       {toast && <div className="toast">{toast}</div>}
     </>
   );
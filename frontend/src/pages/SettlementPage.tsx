@@ -0,0 +1,126 @@
+import { useState, useEffect, useCallback } from 'react';
+import { MonthPicker } from '../components/MonthPicker';
+import { settlementApi } from '../services/api';
+import type { Settlement } from '../types';
+
+function todayString(): string {
+  const d = new Date();
+  return `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
+}
+
+function memberLabel(email: string): string {
+  return email.split('@')[0];
+}
+
+export function SettlementPage() {
+  const [date, setDate] = useState(todayString());
+  const [settlement, setSettlement] = useState<Settlement | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [toast, setToast] = useState<string | null>(null);
+
+  const month = date.slice(0, 7);
+
+  const loadData = useCallback(async () => {
+    setLoading(true);
+    try {
+      setSettlement(await settlementApi.get(month, month));
+    } catch (e) {
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  }, [month]);
+
+  useEffect(() => {
+    loadData();
+  }, [loadData]);
+
+  useEffect(() => {
+    if (toast) {
+      const timer = setTimeout(() => setToast(null), 2000);
+      return () => clearTimeout(timer);
+    }
+  }, [toast]);
+
+  const handleRecord = async (from: string, to: string, amount: number) => {
+    if (!confirm(`${memberLabel(from)} → ${memberLabel(to)} ¥${amount.toLocaleString()} の精算を記録しますか？`)) return;
+    // 精算日は対象月内（当月なら今日、過去月なら月末）
+    const today = todayString();
+    const lastDay = new Date(Number(month.slice(0, 4)), Number(month.slice(5, 7)), 0).getDate();
+    const settleDate = today.startsWith(month) ? today : `${month}-${String(lastDay).padStart(2, '0')}`;
+    try {
+      await settlementApi.record({ date: settleDate, from, to, amount });
+      setToast('精算を記録しました');
+      await loadData();
+    } catch (e) {
+      console.error(e);
+      setToast('記録に失敗しました');
+    }
+  };
+
+  return (
+    <>
+      <MonthPicker value={date} onChange={setDate} mode="month" />
+
+      {loading || !settlement ? (
+        <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : (
+        <>
+          {/* 精算が必要な支払い */}
+          <div className="summary-totals">
+            {settlement.transfers.length === 0 ? (
+              <div className="summary-comparison">精算済みです</div>
+            ) : (
+              settlement.transfers.map((t) => (
+                <div key={`${t.from}-${t.to}`} className="summary-comparison">
+                  <div className="summary-comparison-item">
+                    <span>{memberLabel(t.from)} → {memberLabel(t.to)}: </span>
+                    <span style={{ color: '#dc2626' }}>&yen;{t.amount.toLocaleString()}</span>
+                  </div>
+                  <button className="recurring-add-btn" onClick={() => handleRecord(t.from, t.to, t.amount)}>
+                    精算を記録
+                  </button>
+                </div>
+              ))
+            )}
+          </div>
+
+          {/* メンバー別の立替・負担 */}
+          <div className="summary-category-list">
+            <div className="summary-breakdown-tabs">
+              <button className="summary-breakdown-tab active">立替・負担</button>
+            </div>
+            {settlement.members.map((m) => (
+              <div key={m.email} className="summary-category-item">
+                <span className="summary-category-name">{memberLabel(m.email)}</span>
+                <span className="summary-category-amount">
+                  立替 &yen;{m.paid.toLocaleString()} / 負担 &yen;{m.share.toLocaleString()}
+                </span>
+                <span className="summary-category-percent" style={{ color: m.net >= 0 ? '#059669' : '#dc2626' }}>
+                  {m.net >= 0 ? '+' : ''}{m.net.toLocaleString()}
+                </span>
+              </div>
+            ))}
+          </div>
+
+          {/* 記録済みの精算 */}
+          {settlement.payments.length > 0 && (
+            <div className="summary-category-list">
+              <div className="summary-breakdown-tabs">
+                <button className="summary-breakdown-tab active">精算履歴</button>
+              </div>
+              {settlement.payments.map((p) => (
+                <div key={p.id} className="summary-category-item">
+                  <span className="summary-category-name">{p.date} {memberLabel(p.paidBy || '')} → {memberLabel(p.paidTo || '')}</span>
+                  <span className="summary-category-amount">&yen;{p.amount.toLocaleString()}</span>
+                </div>
+              ))}
+            </div>
+          )}
+        </>
+      )}
+
+      {toast && <div className="toast">{toast}</div>}
+    </>
+  );
+}
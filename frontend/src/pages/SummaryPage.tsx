@@ -2,9 +2,10 @@ import { useState, useEffect, useCallback, useMemo, useRef } from 'react';
 import { Chart as ChartJS, ArcElement, Tooltip, Legend, CategoryScale, LinearScale, BarElement, Title } from 'chart.js';
 import type { ChartOptions } from 'chart.js';
 import { Doughnut, Bar } from 'react-chartjs-2';
+import { useNavigate } from 'react-router-dom';
 import { MonthPicker } from '../components/MonthPicker';
-import { summaryApi, payersApi, expensesApi, categoriesApi } from '../services/api';
-import type { MonthlySummary, YearlySummary, Payer, PayerBalance, Expense, Category } from '../types';
+import { summaryApi, payersApi, expensesApi, categoriesApi, settingsApi } from '../services/api';
+import type { MonthlySummary, YearlySummary, Payer, PayerBalance, Expense, Category, TagSummary, TaxSummary, PivotSummary, Insights, InsightFlag } from '../types';
 
 ChartJS.register(ArcElement, Tooltip, Legend, CategoryScale, LinearScale, BarElement, Title);
 
@@ -17,6 +18,33 @@ function getMonth(dateStr: string): string {
   return dateStr.slice(0, 7);
 }
 
+// 月の開始日に応じた月の期間（初日・末日）を返す（開始日 25 なら "2025-03" は 2025-03-25〜2025-04-24）
+function monthPeriod(month: string, startDay: number): { from: string; to: string } {
+  const [y, m] = month.split('-').map(Number);
+  const fmt = (d: Date) => `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
+  return { from: fmt(new Date(y, m - 1, startDay)), to: fmt(new Date(y, m, startDay - 1)) };
+}
+
+function formatPeriod(period: { from: string; to: string }): string {
+  const short = (d: string) => `${Number(d.slice(5, 7))}/${Number(d.slice(8, 10))}`;
+  return `${short(period.from)}〜${short(period.to)}`;
+}
+
+// 異常検知の表示名（月初から・直近 30 日はカテゴリ別、高額はその支出）
+function insightLabel(f: InsightFlag): string {
+  switch (f.kind) {
+    case 'monthToDate': return `${f.category}（今月これまで）`;
+    case 'last30Days': return `${f.category}（直近30日）`;
+    default: return `${f.category}の高額な支出`;
+  }
+}
+
+// 明細ごとのカテゴリ・金額（明細なしはカテゴリに全額、返金はカテゴリから差し引く）
+function expenseLines(e: Expense): { category: string; amount: number }[] {
+  if (e.type === 'refund') return [{ category: e.category, amount: -e.amount }];
+  return e.items?.length ? e.items : [{ category: e.category, amount: e.amount }];
+}
+
 function formatDiff(diff: number, percent: number): string {
   const sign = diff >= 0 ? '+' : '';
   return `${sign}\u00a5${Math.abs(diff).toLocaleString()} (${sign}${percent.toFixed(1)}%)`;
@@ -164,37 +192,56 @@ export function SummaryPage() {
   const [selectedPayer, setSelectedPayer] = useState('');
   const [payerBalance, setPayerBalance] = useState<PayerBalance | null>(null);
   const [expenses, setExpenses] = useState<Expense[]>([]);
+  const [tagSummary, setTagSummary] = useState<TagSummary | null>(null);
+  const [taxSummary, setTaxSummary] = useState<TaxSummary | null>(null);
+  const [pivot, setPivot] = useState<PivotSummary | null>(null);
+  const [insights, setInsights] = useState<Insights | null>(null);
   const [categories, setCategories] = useState<Category[]>([]);
   const [loading, setLoading] = useState(true);
   const [expandedChart, setExpandedChart] = useState<'doughnut' | 'bar' | null>(null);
   const [filterCount, setFilterCount] = useState(0);
-  const [breakdownTab, setBreakdownTab] = useState<'category' | 'place'>('category');
+  const [breakdownTab, setBreakdownTab] = useState<'category' | 'place' | 'tag' | 'tax' | 'pivot'>('category');
   const [expandedCategory, setExpandedCategory] = useState<string | null>(null);
+  // 月の開始日（世帯設定）と、設定によらずカレンダー月で表示するかどうか
+  const [monthStartDay, setMonthStartDay] = useState(1);
+  const [calendarView, setCalendarView] = useState(false);
+  const navigate = useNavigate();
   const selectedRef = useRef(new Set<number>());
   const barScrollRef = useRef<HTMLDivElement>(null);
   const barChartRef = useRef<ChartJS<'bar'>>(null);
   const stackedBarOptions = useMemo(() => buildStackedBarOptions(selectedRef, setFilterCount), []);
 
   const month = getMonth(date);
+  const startDay = calendarView ? 1 : monthStartDay;
+  const period = monthPeriod(month, startDay);
 
-  // 支払元・カテゴリ一覧を取得
+  // 支払元・カテゴリ一覧・世帯設定を取得
   useEffect(() => {
     payersApi.getAll().then(setPayers).catch(console.error);
     categoriesApi.getAll().then(setCategories).catch(console.error);
+    settingsApi.get().then((s) => setMonthStartDay(s.monthStartDay || 1)).catch(console.error);
   }, []);
 
   const loadData = useCallback(async () => {
     setLoading(true);
     try {
       const payer = selectedPayer || undefined;
-      const [m, y, exp] = await Promise.all([
-        summaryApi.getMonthly(month, payer),
-        summaryApi.getYearly(month, payer),
+      const calendar = calendarView || undefined;
+      // 開始日が 1 以外の月は翌月分の支出も取得して期間内に絞り込む
+      const { from, to } = monthPeriod(month, startDay);
+      const [m, y, exp, nextExp, tags, tax] = await Promise.all([
+        summaryApi.getMonthly(month, payer, calendar),
+        summaryApi.getYearly(month, payer, calendar),
         expensesApi.getByMonth(month),
+        startDay > 1 ? expensesApi.getByMonth(to.slice(0, 7)) : Promise.resolve([]),
+        summaryApi.getByTag(month, month, calendar),
+        summaryApi.getTax(month, month, calendar),
       ]);
       setSummary(m);
       setYearly(y);
-      setExpenses(exp || []);
+      setExpenses([...(exp || []), ...(nextExp || [])].filter((e) => e.date >= from && e.date <= to));
+      setTagSummary(tags);
+      setTaxSummary(tax);
 
       // trackBalance=true の支払元が選択されている場合のみ残額を取得
       const selectedPayerObj = payers.find((p) => p.name === selectedPayer);
@@ -209,12 +256,32 @@ export function SummaryPage() {
     } finally {
       setLoading(false);
     }
-  }, [month, selectedPayer, payers]);
+  }, [month, selectedPayer, payers, calendarView, startDay]);
 
   useEffect(() => {
     loadData();
   }, [loadData]);
 
+  // 通常と異なる支出（当月は今日時点、過去の月は月末時点）
+  useEffect(() => {
+    const today = todayString();
+    const asOf = period.to < today ? period.to : today;
+    if (asOf < period.from) {
+      setInsights(null);
+      return;
+    }
+    summaryApi.getInsights(asOf).then(setInsights).catch(console.error);
+  }, [period.from, period.to]);
+
+  // カテゴリ×場所（タブを開いたときに取得、支払元フィルタはサーバー側で絞り込み）
+  useEffect(() => {
+    if (breakdownTab !== 'pivot') return;
+    const filters = selectedPayer ? { payer: [selectedPayer] } : undefined;
+    summaryApi.getPivot(month, month, ['category', 'place'], filters, calendarView || undefined)
+      .then(setPivot)
+      .catch(console.error);
+  }, [breakdownTab, month, selectedPayer, calendarView]);
+
   // カテゴリ別積み上げ棒グラフデータ（yearlyが変わった時だけ再生成＆選択解除）
   const stackedBarData = useMemo(() => {
     selectedRef.current.clear();
@@ -229,12 +296,16 @@ export function SummaryPage() {
   );
   const placeRanking = useMemo(() => {
     const filtered = expenses
-      .filter((e) => expenseCategories.has(e.category))
       .filter((e) => !selectedPayer || e.payer === selectedPayer);
     const map = new Map<string, number>();
     for (const e of filtered) {
+      // 明細がある場合は支出カテゴリの明細のみ計上（返金は差し引く）
+      const amount = expenseLines(e)
+        .filter((line) => expenseCategories.has(line.category))
+        .reduce((sum, line) => sum + line.amount, 0);
+      if (amount === 0) continue;
       const place = e.place || '未設定';
-      map.set(place, (map.get(place) || 0) + e.amount);
+      map.set(place, (map.get(place) || 0) + amount);
     }
     const total = Array.from(map.values()).reduce((a, b) => a + b, 0);
     return Array.from(map.entries())
@@ -287,6 +358,19 @@ export function SummaryPage() {
     <>
       <MonthPicker value={date} onChange={setDate} mode="month" />
 
+      {/* 月の開始日を設定している場合は期間と表示の切り替え */}
+      {monthStartDay > 1 && (
+        <div className="payer-filter">
+          <span style={{ fontSize: '0.8rem', color: '#6b7280', alignSelf: 'center' }}>{formatPeriod(period)}</span>
+          <button className={`payer-filter-btn ${!calendarView ? 'active' : ''}`} onClick={() => setCalendarView(false)}>
+            {monthStartDay}日始まり
+          </button>
+          <button className={`payer-filter-btn ${calendarView ? 'active' : ''}`} onClick={() => setCalendarView(true)}>
+            カレンダー月
+          </button>
+        </div>
+      )}
+
       {/* 支払元フィルタ */}
       <div className="payer-filter">
         <button
@@ -307,7 +391,7 @@ export function SummaryPage() {
       </div>
 
       {/* 残額表示（支払元選択時のみ） */}
-      {payerBalance && (payerBalance.carryover !== 0 || payerBalance.monthCharge > 0) && (
+      {payerBalance && !calendarView && (payerBalance.carryover !== 0 || payerBalance.monthCharge > 0) && (
         <div className="payer-balance">
           <span className="payer-balance-label">{payerBalance.payer} 残額</span>
           <span className={`payer-balance-amount ${payerBalance.balance < 0 ? 'negative' : ''}`}>
@@ -428,6 +512,28 @@ export function SummaryPage() {
             >
               場所別
             </button>
+            <button
+              className={`summary-breakdown-tab ${breakdownTab === 'pivot' ? 'active' : ''}`}
+              onClick={() => setBreakdownTab('pivot')}
+            >
+              カテゴリ×場所
+            </button>
+            {tagSummary && tagSummary.byTag.length > 0 && (
+              <button
+                className={`summary-breakdown-tab ${breakdownTab === 'tag' ? 'active' : ''}`}
+                onClick={() => setBreakdownTab('tag')}
+              >
+                タグ別
+              </button>
+            )}
+            {taxSummary && taxSummary.tax !== 0 && (
+              <button
+                className={`summary-breakdown-tab ${breakdownTab === 'tax' ? 'active' : ''}`}
+                onClick={() => setBreakdownTab('tax')}
+              >
+                消費税
+              </button>
+            )}
           </div>
 
           {breakdownTab === 'category' && categorySummaries.map((cat) => {
@@ -437,8 +543,14 @@ export function SummaryPage() {
             const isExpanded = expandedCategory === cat.categoryId;
             const catExpenses = isExpanded
               ? expenses
-                  .filter((e) => e.category === cat.categoryId)
                   .filter((e) => !selectedPayer || e.payer === selectedPayer)
+                  .flatMap((e) => {
+                    // 明細がある場合はこのカテゴリの明細分のみ表示（返金はマイナス）
+                    const amount = expenseLines(e)
+                      .filter((line) => line.category === cat.categoryId)
+                      .reduce((sum, line) => sum + line.amount, 0);
+                    return amount !== 0 ? [{ ...e, amount }] : [];
+                  })
                   .sort((a, b) => b.date.localeCompare(a.date))
               : [];
             return (
@@ -465,7 +577,7 @@ export function SummaryPage() {
                           <div className="expense-item-body">
                             <div className="expense-item-top">
                               <span className="expense-item-category">{dateLabel}</span>
-                              <span className="expense-item-amount">&yen;{e.amount.toLocaleString()}</span>
+                              <span className="expense-item-amount">{e.amount < 0 ? '-' : ''}&yen;{Math.abs(e.amount).toLocaleString()}</span>
                             </div>
                             <div className="expense-item-meta">
                               <span className="expense-item-payer">{e.payer}</span>
@@ -490,9 +602,93 @@ export function SummaryPage() {
               <span className="summary-category-percent">{item.percent.toFixed(1)}%</span>
             </div>
           ))}
+
+          {/* カテゴリ×場所（カテゴリごとに場所を金額の大きい順に表示） */}
+          {breakdownTab === 'pivot' && pivot?.rows.map((row) => (
+            <div key={row.keys[0]}>
+              <div className="summary-category-item" style={{ background: '#f3f4f6' }}>
+                <span className="summary-category-name">{row.labels[0]}</span>
+                <span className="summary-category-amount">&yen;{row.amount.toLocaleString()}</span>
+                <span className="summary-category-percent">{row.count}件</span>
+              </div>
+              {pivot.cells.filter((c) => c.keys[0] === row.keys[0]).map((c) => (
+                <div key={c.keys[1]} className="summary-category-item" style={{ paddingLeft: 24 }}>
+                  <span className="summary-category-name">{c.labels[1]}</span>
+                  <span className="summary-category-amount">&yen;{c.amount.toLocaleString()}</span>
+                  <span className="summary-category-percent">{c.count}件</span>
+                </div>
+              ))}
+            </div>
+          ))}
+
+          {/* タグ別（複数タグの支出は各タグに計上、支払元フィルタは対象外） */}
+          {breakdownTab === 'tag' && tagSummary?.byTag.map((item) => (
+            <div key={item.tag} className="summary-category-item">
+              <span className="summary-category-name">#{item.tag}</span>
+              <span className="summary-category-amount">&yen;{item.amount.toLocaleString()}</span>
+              <span className="summary-category-percent">{item.count}件</span>
+            </div>
+          ))}
+
+          {/* 消費税（税率別内訳を登録した支出のみ、支払元フィルタは対象外） */}
+          {breakdownTab === 'tax' && taxSummary && (
+            <>
+              {taxSummary.byRate.map((r) => (
+                <div key={r.rate} className="summary-category-item">
+                  <span className="summary-category-name">{r.rate}%{r.rate === 8 ? '（軽減）' : ''}</span>
+                  <span className="summary-category-amount">&yen;{r.tax.toLocaleString()}</span>
+                  <span className="summary-category-percent">税抜 &yen;{r.taxable.toLocaleString()}</span>
+                </div>
+              ))}
+              {taxSummary.months[0]?.byCategory.map((c) => (
+                <div key={c.categoryId} className="summary-category-item">
+                  <div className="summary-category-color" style={{ background: c.color }} />
+                  <span className="summary-category-name">{c.category}</span>
+                  <span className="summary-category-amount">&yen;{c.tax.toLocaleString()}</span>
+                  <span className="summary-category-percent">税抜 &yen;{c.taxable.toLocaleString()}</span>
+                </div>
+              ))}
+            </>
+          )}
         </div>
       )}
 
+      {/* 通常と異なる支出 */}
+      {insights && insights.flags.length > 0 && (
+        <div className="summary-category-list">
+          <div className="summary-breakdown-tabs">
+            <button className="summary-breakdown-tab active">気になる支出</button>
+          </div>
+          {insights.flags.map((f) => (
+            <div key={`${f.kind}-${f.categoryId}-${f.expenseId || ''}`} className="summary-category-item">
+              <span className="summary-category-name">
+                {insightLabel(f)}
+                <span style={{ display: 'block', fontSize: '0.75rem', color: '#6b7280' }}>
+                  {f.kind === 'largeExpense'
+                    ? `${f.date} ${[f.place, f.memo].filter(Boolean).join(' / ')}`
+                    : `いつもは ¥${f.median.toLocaleString()}`}
+                </span>
+              </span>
+              <span className="summary-category-amount">&yen;{f.amount.toLocaleString()}</span>
+              <span className="summary-category-percent">{f.ratio > 0 ? `${f.ratio.toFixed(1)}倍` : ''}</span>
+            </div>
+          ))}
+        </div>
+      )}
+
+      {/* 週の集計（前週比・直近の平均との比較） */}
+      <div className="summary-recurring-link">
+        <button className="recurring-link-btn" onClick={() => navigate('/weekly')}>
+          週の集計
+        </button>
+        <button className="recurring-link-btn" onClick={() => navigate('/members')} style={{ marginTop: 8 }}>
+          メンバー別
+        </button>
+        <button className="recurring-link-btn" onClick={() => navigate('/annual')} style={{ marginTop: 8 }}>
+          年間レポート・期間比較
+        </button>
+      </div>
+
       {/* 最下部の月移動 */}
       <MonthPicker value={date} onChange={setDate} mode="month" />
 
@@ -0,0 +1,160 @@
+import { useState, useEffect, useCallback } from 'react';
+import { summaryApi, payersApi } from '../services/api';
+import type { WeeklySummary, MonthComparison, Payer } from '../types';
+
+function todayString(): string {
+  const d = new Date();
+  return `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
+}
+
+/** 日付 "YYYY-MM-DD" に n 日を加える */
+function addDays(dateStr: string, n: number): string {
+  const d = new Date(dateStr + 'T00:00:00');
+  d.setDate(d.getDate() + n);
+  return `${d.getFullYear()}-${String(d.getMonth() + 1).padStart(2, '0')}-${String(d.getDate()).padStart(2, '0')}`;
+}
+
+function formatDay(dateStr: string): string {
+  const d = new Date(dateStr + 'T00:00:00');
+  return `${d.getMonth() + 1}/${d.getDate()}`;
+}
+
+/** 増減の表示（増加は赤、減少は緑） */
+function DiffLabel({ diff, percent }: { diff: number; percent?: number }) {
+  if (diff === 0) return <span style={{ color: '#6b7280' }}>±0</span>;
+  return (
+    <span style={{ color: diff > 0 ? '#ef4444' : '#10b981' }}>
+      {diff > 0 ? '+' : '-'}&yen;{Math.abs(diff).toLocaleString()}
+      {percent ? ` (${percent > 0 ? '+' : ''}${percent.toFixed(1)}%)` : ''}
+    </span>
+  );
+}
+
+function ComparisonItem({ label, comparison }: { label: string; comparison: MonthComparison | null }) {
+  if (!comparison) return null;
+  return (
+    <div className="summary-category-item">
+      <span className="summary-category-name">{label} &yen;{comparison.total.toLocaleString()}</span>
+      <span className="summary-category-amount"><DiffLabel diff={comparison.diff} percent={comparison.diffPercent} /></span>
+    </div>
+  );
+}
+
+const AVERAGE_WEEKS = [4, 8, 12];
+
+// 週別集計（前週比と直近 N 週の平均との比較）
+export function WeeklySummaryPage() {
+  const [date, setDate] = useState(todayString());
+  const [weeks, setWeeks] = useState(4);
+  const [payers, setPayers] = useState<Payer[]>([]);
+  const [selectedPayer, setSelectedPayer] = useState('');
+  const [summary, setSummary] = useState<WeeklySummary | null>(null);
+  const [loading, setLoading] = useState(true);
+
+  useEffect(() => {
+    payersApi.getAll().then(setPayers).catch(console.error);
+  }, []);
+
+  const loadData = useCallback(async () => {
+    setLoading(true);
+    try {
+      setSummary(await summaryApi.getWeekly(date, weeks, selectedPayer || undefined));
+    } catch (e) {
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  }, [date, weeks, selectedPayer]);
+
+  useEffect(() => {
+    loadData();
+  }, [loadData]);
+
+  const averages = new Map((summary?.recentAverage?.byCategory || []).map((c) => [c.categoryId, c]));
+  const maxWeekTotal = Math.max(...(summary?.weeks || []).map((w) => w.total), 1);
+
+  return (
+    <>
+      <div className="recurring-header">
+        <button className="modal-close-btn" onClick={() => setDate(addDays(summary?.week || date, -7))}>&lsaquo;</button>
+        <h2>{summary ? `${formatDay(summary.week)}〜${formatDay(summary.weekEnd)}` : '週の集計'}</h2>
+        <button className="modal-close-btn" onClick={() => setDate(addDays(summary?.week || date, 7))}>&rsaquo;</button>
+      </div>
+
+      {/* 支払元フィルタ */}
+      <div className="payer-filter">
+        <button className={`payer-filter-btn ${selectedPayer === '' ? 'active' : ''}`} onClick={() => setSelectedPayer('')}>
+          全体
+        </button>
+        {payers.map((p) => (
+          <button
+            key={p.id}
+            className={`payer-filter-btn ${selectedPayer === p.name ? 'active' : ''}`}
+            onClick={() => setSelectedPayer(p.name)}
+          >
+            {p.name}
+          </button>
+        ))}
+      </div>
+
+      {loading || !summary ? (
+        <div className="loading-spinner"><div className="spinner"></div></div>
+      ) : (
+        <>
+          <div className="summary-totals">
+            <div className="summary-total-amount">&yen;{summary.total.toLocaleString()}</div>
+          </div>
+
+          <div className="summary-category-list">
+            <ComparisonItem label="前週" comparison={summary.previousWeek} />
+            <ComparisonItem label={`直近${summary.averageWeeks}週の平均`} comparison={summary.recentAverage} />
+            <div className="payer-filter">
+              {AVERAGE_WEEKS.map((n) => (
+                <button key={n} className={`payer-filter-btn ${weeks === n ? 'active' : ''}`} onClick={() => setWeeks(n)}>
+                  {n}週平均
+                </button>
+              ))}
+            </div>
+          </div>
+
+          {/* カテゴリ別（平均との差） */}
+          <div className="summary-category-list">
+            <div className="summary-breakdown-tabs">
+              <button className="summary-breakdown-tab active">カテゴリ別</button>
+            </div>
+            {(summary.byCategory || []).length === 0 && (
+              <div className="empty-state"><p>この週の支出はありません</p></div>
+            )}
+            {(summary.byCategory || []).map((c) => {
+              const avg = averages.get(c.categoryId);
+              return (
+                <div key={c.categoryId} className="summary-category-item">
+                  <div className="summary-category-color" style={{ background: c.color }} />
+                  <span className="summary-category-name">{c.category}</span>
+                  <span className="summary-category-amount">&yen;{c.amount.toLocaleString()}</span>
+                  <span className="summary-category-percent">{avg && <DiffLabel diff={avg.diff} />}</span>
+                </div>
+              );
+            })}
+          </div>
+
+          {/* 週の推移（古い順） */}
+          <div className="summary-category-list">
+            <div className="summary-breakdown-tabs">
+              <button className="summary-breakdown-tab active">週の推移</button>
+            </div>
+            {summary.weeks.map((w) => (
+              <div key={w.week} className="summary-category-item" onClick={() => setDate(w.week)} style={{ cursor: 'pointer' }}>
+                <span className="summary-category-name">{formatDay(w.week)}〜</span>
+                <span style={{ flex: 2, height: 8, background: '#e5e7eb', borderRadius: 4 }}>
+                  <span style={{ display: 'block', height: 8, borderRadius: 4, background: w.week === summary.week ? '#3b82f6' : '#93c5fd', width: `${Math.max(w.total, 0) / maxWeekTotal * 100}%` }} />
+                </span>
+                <span className="summary-category-amount">&yen;{w.total.toLocaleString()}</span>
+              </div>
+            ))}
+          </div>
+        </>
+      )}
+    </>
+  );
+}
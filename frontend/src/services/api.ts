@@ -1,5 +1,5 @@
 import { config } from '../config';
-import type { Expense, ExpenseInput, Category, Place, Payer, PayerBalance, MonthlySummary, YearlySummary, ApiResponse, Role, RecurringExpense, RecurringExpenseInput, CategoryInput, PlaceInput, PayerInput } from '../types';
+import type { Expense, ExpenseInput, Category, Place, Payer, PayerBalance, PayerBalanceHistory, CardStatement, MonthlySummary, YearlySummary, ApiResponse, Role, RecurringExpense, RecurringExpenseInput, CategoryInput, PlaceInput, PayerInput, ReconcileInput, ReconcileResult, ExchangeRate, ExchangeRateInput, Settlement, SettlementInput, RefundInput, TagSummary, TaxSummary, PivotSummary, PivotDimension, DailyTotals, WeeklySummary, Insights, MemberSummary, AnnualReport, PeriodComparison, BusinessReport, MedicalReport, CSVExport, HouseholdSettings, SummaryCacheReport, Attachment, AttachmentUpload, AttachmentDownload } from '../types';
 
 // 認証トークン（グローバル）
 let authToken: string | null = null;
@@ -42,6 +42,7 @@ function invalidateExpenseCache(): void {
   cacheInvalidate('expenses:');
   cacheInvalidate('summary:');
   cacheInvalidate('payerBalance:');
+  cacheInvalidate('cardStatement:');
 }
 
 /**
@@ -104,9 +105,16 @@ export const usersApi = {
     const result = await callApi<{ role: Role }>('getMyRole');
     return result.role;
   },
+
+  // 自分の表示名を変更（メンバー別集計の表示に使う）
+  async updateDisplayName(name: string): Promise<string> {
+    const result = await callApi<{ name?: string }>('updateDisplayName', { name });
+    cacheInvalidate('summary:members:');
+    return result.name || '';
+  },
 };
 
-// カテゴリAPI（セッション中キャッシュ）
+// カテゴリAPI（セッション中キャッシュ。更新・削除時は集計のキャッシュも破棄）
 export const categoriesApi = {
   async getAll(): Promise<Category[]> {
     const cached = cacheGet<Category[]>('master:categories');
@@ -124,11 +132,13 @@ export const categoriesApi = {
   async update(id: string, input: CategoryInput): Promise<Category> {
     const result = await callApi<Category>('updateCategory', { id, category: input });
     cacheInvalidate('master:categories');
+    cacheInvalidate('summary:');
     return result;
   },
   async delete(id: string): Promise<void> {
     await callApi<void>('deleteCategory', { id });
     cacheInvalidate('master:categories');
+    cacheInvalidate('summary:');
   },
 };
 
@@ -174,6 +184,23 @@ export const payersApi = {
     if (cached) return cached;
     return cacheSet(key, await callApi<PayerBalance>('getPayerBalance', { payer, month }));
   },
+  async getBalanceHistory(payer: string, from: string, to: string): Promise<PayerBalanceHistory> {
+    const key = `payerBalance:history:${payer}:${from}:${to}`;
+    const cached = cacheGet<PayerBalanceHistory>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<PayerBalanceHistory>('getPayerBalanceHistory', { payer, from, to }));
+  },
+  async getCardStatement(payer: string, from: string, to: string): Promise<CardStatement[]> {
+    const key = `cardStatement:${payer}:${from}:${to}`;
+    const cached = cacheGet<CardStatement[]>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<CardStatement[]>('getCardStatement', { payer, from, to }));
+  },
+  async reconcile(input: ReconcileInput): Promise<ReconcileResult> {
+    const result = await callApi<ReconcileResult>('reconcilePayer', { reconcile: input });
+    invalidateExpenseCache();
+    return result;
+  },
   async create(input: PayerInput): Promise<Payer> {
     const result = await callApi<Payer>('createPayer', { payerData: input });
     cacheInvalidate('master:payers');
@@ -192,11 +219,16 @@ export const payersApi = {
 
 // 支出API（月別キャッシュ、変更時に破棄）
 export const expensesApi = {
-  async getByMonth(month: string): Promise<Expense[]> {
-    const key = `expenses:${month}`;
+  async getByMonth(month: string, tags?: string[]): Promise<Expense[]> {
+    const key = `expenses:${month}:${(tags || []).join(',')}`;
     const cached = cacheGet<Expense[]>(key);
     if (cached) return cached;
-    return cacheSet(key, await callApi<Expense[]>('getExpenses', { month }));
+    return cacheSet(key, await callApi<Expense[]>('getExpenses', { month, ...(tags?.length ? { tags } : {}) }));
+  },
+
+  // キーワード（メモ・場所・カテゴリ名）・タグで期間内を検索（キャッシュなし）
+  async search(from: string, to: string, query: string, tags?: string[]): Promise<Expense[]> {
+    return callApi<Expense[]>('searchExpenses', { from, to, query, ...(tags?.length ? { tags } : {}) });
   },
 
   async create(expense: ExpenseInput): Promise<Expense> {
@@ -211,6 +243,13 @@ export const expensesApi = {
     return result;
   },
 
+  // 返品・一部返金を登録（返金元のカテゴリから差し引かれる）
+  async refund(input: RefundInput): Promise<Expense> {
+    const result = await callApi<Expense>('createRefund', { refund: input });
+    invalidateExpenseCache();
+    return result;
+  },
+
   async delete(id: string): Promise<void> {
     const result = await callApi<void>('deleteExpense', { id });
     invalidateExpenseCache();
@@ -224,20 +263,169 @@ export const expensesApi = {
   },
 };
 
+// base64 への変換（直接アップロード用）
+function fileToBase64(file: File): Promise<string> {
+  return new Promise((resolve, reject) => {
+    const reader = new FileReader();
+    reader.onload = () => resolve(String(reader.result).split(',')[1] || '');
+    reader.onerror = () => reject(reader.error);
+    reader.readAsDataURL(file);
+  });
+}
+
+// 直接アップロードの上限（サーバー側と合わせる）
+const MAX_DIRECT_UPLOAD_SIZE = 4 * 1024 * 1024;
+
+// 添付ファイルAPI（署名付き URL を発行できないストアでは API 経由で直接アップロード）
+export const attachmentsApi = {
+  async getAll(expenseId: string): Promise<Attachment[]> {
+    return callApi<Attachment[]>('getAttachments', { expenseId });
+  },
+
+  async upload(expenseId: string, file: File): Promise<Attachment> {
+    const meta = { expenseId, fileName: file.name, contentType: file.type, size: file.size };
+    let result: AttachmentUpload;
+    try {
+      result = await callApi<AttachmentUpload>('uploadAttachment', { attachment: meta });
+    } catch (e) {
+      if (file.size > MAX_DIRECT_UPLOAD_SIZE) throw e;
+      result = await callApi<AttachmentUpload>('uploadAttachment', { attachment: { ...meta, data: await fileToBase64(file) } });
+    }
+    let attachment = result.attachment;
+    if (result.uploadUrl) {
+      // 署名付き URL にアップロードしてから、サーバーで本体を確認して支出に記録する
+      const res = await fetch(result.uploadUrl, { method: 'PUT', headers: { 'Content-Type': file.type }, body: file });
+      if (!res.ok) throw new Error(`アップロードに失敗しました: ${res.status}`);
+      attachment = await callApi<Attachment>('confirmAttachment', { id: result.attachment.id, attachment: meta });
+    }
+    invalidateExpenseCache();
+    return attachment;
+  },
+
+  // 表示用 URL（base64 で返るストアは Blob URL に変換）
+  async getUrl(expenseId: string, id: string): Promise<string> {
+    const result = await callApi<AttachmentDownload>('getAttachment', { expenseId, id });
+    if (result.url) return result.url;
+    const bytes = Uint8Array.from(atob(result.data || ''), (c) => c.charCodeAt(0));
+    return URL.createObjectURL(new Blob([bytes], { type: result.attachment.contentType }));
+  },
+
+  async delete(expenseId: string, id: string): Promise<void> {
+    await callApi<void>('deleteAttachment', { expenseId, id });
+    invalidateExpenseCache();
+  },
+};
+
 // 集計API（月/年+payer別キャッシュ、変更時に破棄）
+// calendar = true の場合は月の開始日の設定によらずカレンダー月で集計
 export const summaryApi = {
-  async getMonthly(month: string, payer?: string): Promise<MonthlySummary> {
-    const key = `summary:monthly:${month}:${payer || ''}`;
+  async getMonthly(month: string, payer?: string, calendar?: boolean): Promise<MonthlySummary> {
+    const key = `summary:monthly:${month}:${payer || ''}:${calendar ? 'cal' : ''}`;
     const cached = cacheGet<MonthlySummary>(key);
     if (cached) return cached;
-    return cacheSet(key, await callApi<MonthlySummary>('getMonthlySummary', { month, ...(payer ? { payer } : {}) }));
+    return cacheSet(key, await callApi<MonthlySummary>('getMonthlySummary', { month, ...(payer ? { payer } : {}), ...(calendar ? { calendar } : {}) }));
   },
 
-  async getYearly(month: string, payer?: string): Promise<YearlySummary> {
-    const key = `summary:yearly:${month}:${payer || ''}`;
+  async getYearly(month: string, payer?: string, calendar?: boolean): Promise<YearlySummary> {
+    const key = `summary:yearly:${month}:${payer || ''}:${calendar ? 'cal' : ''}`;
     const cached = cacheGet<YearlySummary>(key);
     if (cached) return cached;
-    return cacheSet(key, await callApi<YearlySummary>('getYearlySummary', { month, ...(payer ? { payer } : {}) }));
+    return cacheSet(key, await callApi<YearlySummary>('getYearlySummary', { month, ...(payer ? { payer } : {}), ...(calendar ? { calendar } : {}) }));
+  },
+
+  // 暦年（fiscal=true は年度）の月別・カテゴリ別集計と前年との比較
+  async getAnnual(year: string, fiscal?: boolean, payer?: string): Promise<AnnualReport> {
+    const key = `summary:annual:${year}:${fiscal ? 'fiscal' : ''}:${payer || ''}`;
+    const cached = cacheGet<AnnualReport>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<AnnualReport>('getAnnualReport', { year, ...(fiscal ? { fiscal } : {}), ...(payer ? { payer } : {}) }));
+  },
+
+  // 期間比較（compareFrom/compareTo 省略時は直前の同じ月数の期間）
+  async comparePeriods(from: string, to: string, compareFrom?: string, compareTo?: string, payer?: string): Promise<PeriodComparison> {
+    const key = `summary:periods:${from}:${to}:${compareFrom || ''}:${compareTo || ''}:${payer || ''}`;
+    const cached = cacheGet<PeriodComparison>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<PeriodComparison>('comparePeriods', { from, to, ...(compareFrom ? { compareFrom } : {}), ...(compareTo ? { compareTo } : {}), ...(payer ? { payer } : {}) }));
+  },
+
+  async getByTag(from: string, to: string, calendar?: boolean): Promise<TagSummary> {
+    const key = `summary:tags:${from}:${to}:${calendar ? 'cal' : ''}`;
+    const cached = cacheGet<TagSummary>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<TagSummary>('getTagSummary', { from, to, ...(calendar ? { calendar } : {}) }));
+  },
+
+  // 消費税（税率別・カテゴリ別）
+  async getTax(from: string, to: string, calendar?: boolean): Promise<TaxSummary> {
+    const key = `summary:tax:${from}:${to}:${calendar ? 'cal' : ''}`;
+    const cached = cacheGet<TaxSummary>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<TaxSummary>('getTaxSummary', { from, to, ...(calendar ? { calendar } : {}) }));
+  },
+
+  // ピボット集計（groupBy は 1〜2 次元、filters は次元ごとの値で絞り込み）
+  async getPivot(from: string, to: string, groupBy: PivotDimension[], filters?: Partial<Record<PivotDimension, string[]>>, calendar?: boolean): Promise<PivotSummary> {
+    const key = `summary:pivot:${from}:${to}:${groupBy.join(',')}:${JSON.stringify(filters || {})}:${calendar ? 'cal' : ''}`;
+    const cached = cacheGet<PivotSummary>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<PivotSummary>('getPivot', { from, to, groupBy, ...(filters ? { filters } : {}), ...(calendar ? { calendar } : {}) }));
+  },
+
+  // 週別集計（date を含む週、前週比と直近 weeks 週の平均との比較）
+  async getWeekly(date: string, weeks?: number, payer?: string): Promise<WeeklySummary> {
+    const key = `summary:weekly:${date}:${weeks || ''}:${payer || ''}`;
+    const cached = cacheGet<WeeklySummary>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<WeeklySummary>('getWeeklySummary', { date, ...(weeks ? { weeks } : {}), ...(payer ? { payer } : {}) }));
+  },
+
+  // 通常と異なる支出（date 時点の月初からの累計・直近 30 日・高額な支出）
+  async getInsights(date: string): Promise<Insights> {
+    const key = `summary:insights:${date}`;
+    const cached = cacheGet<Insights>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<Insights>('getInsights', { date }));
+  },
+
+  // メンバー別集計（month と直近 13 ヶ月の登録者ごとの合計・カテゴリ別）
+  async getMembers(month: string, calendar?: boolean): Promise<MemberSummary> {
+    const key = `summary:members:${month}:${calendar ? 'cal' : ''}`;
+    const cached = cacheGet<MemberSummary>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<MemberSummary>('getMemberSummary', { month, ...(calendar ? { calendar } : {}) }));
+  },
+
+  // 日別合計（period は月 "YYYY-MM" または年 "YYYY"）
+  async getDaily(period: string): Promise<DailyTotals> {
+    const key = `summary:daily:${period}`;
+    const cached = cacheGet<DailyTotals>(key);
+    if (cached) return cached;
+    const params = period.length === 4 ? { year: period } : { month: period };
+    return cacheSet(key, await callApi<DailyTotals>('getDailyTotals', params));
+  },
+};
+
+// 世帯設定API（月の開始日の変更時は集計・残額のキャッシュも破棄）
+export const settingsApi = {
+  async get(): Promise<HouseholdSettings> {
+    const cached = cacheGet<HouseholdSettings>('master:settings');
+    if (cached) return cached;
+    return cacheSet('master:settings', await callApi<HouseholdSettings>('getSettings'));
+  },
+
+  async update(settings: HouseholdSettings): Promise<HouseholdSettings> {
+    const result = await callApi<HouseholdSettings>('updateSettings', { settings });
+    cacheInvalidate('master:settings');
+    invalidateExpenseCache();
+    return result;
+  },
+
+  // 月別集計キャッシュを記録から再構築（verify=true は照合のみ。管理者のみ）
+  async rebuildSummaryCache(from: string, to: string, verify: boolean): Promise<SummaryCacheReport> {
+    const result = await callApi<SummaryCacheReport>('rebuildSummaryCache', { from, to, verify });
+    if (!verify) cacheInvalidate('summary:');
+    return result;
   },
 };
 
@@ -266,3 +454,79 @@ export const recurringApi = {
     cacheInvalidate('master:recurring');
   },
 };
+
+// 為替レートAPI（セッション中キャッシュ）
+export const exchangeRatesApi = {
+  async getAll(): Promise<ExchangeRate[]> {
+    const cached = cacheGet<ExchangeRate[]>('master:exchangeRates');
+    if (cached) return cached;
+    return cacheSet('master:exchangeRates', await callApi<ExchangeRate[]>('getExchangeRates'));
+  },
+
+  async put(input: ExchangeRateInput): Promise<ExchangeRate> {
+    const result = await callApi<ExchangeRate>('putExchangeRate', { exchangeRate: input });
+    cacheInvalidate('master:exchangeRates');
+    return result;
+  },
+
+  async import(inputs: ExchangeRateInput[]): Promise<{ imported: number }> {
+    const result = await callApi<{ imported: number }>('importExchangeRates', { exchangeRates: inputs });
+    cacheInvalidate('master:exchangeRates');
+    return result;
+  },
+
+  async delete(id: string): Promise<void> {
+    await callApi<void>('deleteExchangeRate', { id });
+    cacheInvalidate('master:exchangeRates');
+  },
+};
+
+// 事業按分レポートAPI（確定申告用）
+export const businessApi = {
+  async get(year: string): Promise<BusinessReport> {
+    const key = `expenses:business:${year}`;
+    const cached = cacheGet<BusinessReport>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<BusinessReport>('getBusinessReport', { year }));
+  },
+
+  async exportCsv(year: string): Promise<CSVExport> {
+    return callApi<CSVExport>('exportBusinessReport', { year });
+  },
+};
+
+// 医療費控除レポートAPI（income=所得金額等の合計額、0=未指定）
+export const medicalApi = {
+  async get(year: string, income: number): Promise<MedicalReport> {
+    const key = `expenses:medical:${year}:${income}`;
+    const cached = cacheGet<MedicalReport>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<MedicalReport>('getMedicalReport', { year, income }));
+  },
+
+  async exportCsv(year: string, income: number): Promise<CSVExport> {
+    return callApi<CSVExport>('exportMedicalReport', { year, income });
+  },
+};
+
+// 精算API
+export const settlementApi = {
+  async getMembers(): Promise<string[]> {
+    const cached = cacheGet<string[]>('master:members');
+    if (cached) return cached;
+    return cacheSet('master:members', await callApi<string[]>('getMembers'));
+  },
+
+  async get(from: string, to: string): Promise<Settlement> {
+    const key = `expenses:settlement:${from}:${to}`;
+    const cached = cacheGet<Settlement>(key);
+    if (cached) return cached;
+    return cacheSet(key, await callApi<Settlement>('getSettlement', { from, to }));
+  },
+
+  async record(input: SettlementInput): Promise<Expense> {
+    const result = await callApi<Expense>('recordSettlement', { settlement: input });
+    invalidateExpenseCache();
+    return result;
+  },
+};
@@ -1,16 +1,87 @@
 // 公開レベル型
 export type Visibility = 'public' | 'summary' | 'private';
 
+// 記録種別型（transfer = 支払元間の振替、adjustment = 残高照合による調整、settlement = メンバー間の精算、refund = 返金）
+export type ExpenseType = 'expense' | 'transfer' | 'adjustment' | 'settlement' | 'refund';
+
+// 負担割合型（equal = 均等、ratio = 比率、fixed = 金額指定）
+export type SplitMethod = 'equal' | 'ratio' | 'fixed';
+
+export interface SplitShare {
+  email: string;
+  value: number;
+}
+
+// 添付ファイル型（レシート・請求書）
+export interface Attachment {
+  id: string;
+  fileName: string;
+  contentType: string;
+  size: number;
+  uploadedBy: string;
+  uploadedAt: string;
+}
+
+export interface AttachmentUpload {
+  attachment: Attachment;
+  uploadUrl?: string;
+}
+
+export interface AttachmentDownload {
+  attachment: Attachment;
+  url?: string;
+  data?: string;
+}
+
+// 消費税の税率別内訳型（amount は税込金額、tax はうち消費税額）
+export interface TaxLine {
+  rate: number;
+  amount: number;
+  tax: number;
+}
+
+// 支出の明細型（1 枚のレシートを複数カテゴリに分ける）
+export interface LineItem {
+  category: string;
+  amount: number;
+  memo?: string;
+}
+
+export interface Split {
+  method: SplitMethod;
+  shares: SplitShare[];
+}
+
 // 支出データ型
 export interface Expense {
   id: string;
+  type?: ExpenseType;
   date: string;
   payer: string;
+  toPayer?: string;
   category: string;
   amount: number;
+  items?: LineItem[];
+  taxLines?: TaxLine[];
+  currency?: string;
+  originalAmount?: number;
+  exchangeRate?: number;
   memo: string;
   place: string;
   visibility: string;
+  paidBy?: string;
+  paidTo?: string;
+  split?: Split;
+  tags?: string[];
+  attachments?: Attachment[];
+  refundOf?: string;
+  refundedAmount?: number;
+  refundedByCategory?: Record<string, number>;
+  businessRatio?: number;
+  patient?: string;
+  provider?: string;
+  medicalKind?: MedicalKind;
+  reimbursedAmount?: number;
   createdBy: string;
   createdAt: string;
   updatedAt: string;
@@ -18,13 +89,31 @@ export interface Expense {
 
 // 支出入力型
 export interface ExpenseInput {
+  type?: ExpenseType;
   date: string;
   payer: string;
+  toPayer?: string;
   category: string;
   amount: number;
+  items?: LineItem[];
+  // taxExcluded = true の場合 taxLines の amount は税抜金額、tax 0 は税率から計算
+  taxLines?: TaxLine[];
+  taxExcluded?: boolean;
+  currency?: string;
+  originalAmount?: number;
   memo: string;
   place: string;
   visibility?: Visibility;
+  paidBy?: string;
+  split?: Split;
+  tags?: string[];
+  // 事業割合（%、null=カテゴリの事業割合）
+  businessRatio?: number | null;
+  // 医療費控除（医療費カテゴリの支出のみ）
+  patient?: string;
+  provider?: string;
+  medicalKind?: MedicalKind;
+  reimbursedAmount?: number;
 }
 
 // 支払元マスタ型
@@ -34,6 +123,12 @@ export interface Payer {
   sortOrder: number;
   isActive: boolean;
   trackBalance: boolean;
+  openingBalance: number;
+  openingDate: string;
+  closingDay: number;
+  paymentDay: number;
+  paymentMonthOffset: number;
+  paymentSource: string;
 }
 
 // 場所マスタ型
@@ -55,15 +150,257 @@ export interface Category {
   excludeFromBreakdown: boolean;
   excludeFromSummary: boolean;
   ownerEmail?: string;
+  businessRatio: number;
+  expenseAccount: string;
+  isMedical: boolean;
 }
 
 // 支払元残額（月別）
 export interface PayerBalance {
   payer: string;
+  month: string;
   carryover: number;
   monthCharge: number;
+  monthTransferIn: number;
+  monthTransferOut: number;
   monthSpent: number;
+  monthAdjustment: number;
+  balance: number;
+}
+
+// 支払元残額推移（月別、昇順）
+export interface PayerBalanceHistory {
+  payer: string;
+  months: PayerBalance[];
+}
+
+// クレジットカード請求（締め期間ごと）
+export interface CardStatement {
+  payer: string;
+  periodStart: string;
+  periodEnd: string;
+  paymentDate: string;
+  paymentSource: string;
+  amount: number;
+  count: number;
+  expenses: Expense[];
+}
+
+// 為替レート（適用開始日以降、次のレートまで有効）
+export interface ExchangeRate {
+  id: string;
+  currency: string;
+  date: string;
+  rate: number;
+  updatedAt: string;
+}
+
+// 為替レート入力型
+export interface ExchangeRateInput {
+  currency: string;
+  date: string;
+  rate: number;
+}
+
+// 精算入力型
+export interface SettlementInput {
+  date: string;
+  from: string;
+  to: string;
+  amount: number;
+  memo?: string;
+}
+
+// 返金入力型（category 省略時は返金元のカテゴリ、payer 省略時は返金元の支払元）
+export interface RefundInput {
+  expenseId: string;
+  date: string;
+  amount: number;
+  payer?: string;
+  category?: string;
+  memo?: string;
+}
+
+// メンバーごとの立替・負担
+export interface SettlementMember {
+  email: string;
+  paid: number;
+  share: number;
+  settledOut: number;
+  settledIn: number;
+  net: number;
+}
+
+// 精算結果
+export interface Settlement {
+  from: string;
+  to: string;
+  members: SettlementMember[];
+  transfers: { from: string; to: string; amount: number }[];
+  payments: Expense[];
+}
+
+// タグ別集計型
+export interface TagAmount {
+  tag: string;
+  amount: number;
+  count: number;
+}
+
+export interface TagSummary {
+  from: string;
+  to: string;
+  byTag: TagAmount[];
+}
+
+// ピボット集計型（groupBy の次元ごとのキーと表示名）
+export type PivotDimension = 'category' | 'payer' | 'place' | 'creator' | 'tag' | 'week' | 'month';
+
+export interface PivotCell {
+  keys: string[];
+  labels: string[];
+  amount: number;
+  count: number;
+}
+
+export interface PivotSummary {
+  from: string;
+  to: string;
+  groupBy: PivotDimension[];
+  total: number;
+  rows: PivotCell[];
+  cells: PivotCell[];
+}
+
+// 日別合計型（カレンダー・ヒートマップ用、支出のない日は含まない）
+export interface DailyTotal {
+  date: string;
+  total: number;
+  count: number;
+  topCategoryId?: string;
+  topCategory?: string;
+  topColor?: string;
+}
+
+export interface DailyTotals {
+  from: string;
+  to: string;
+  total: number;
+  days: DailyTotal[];
+}
+
+// 事業按分レポート型（確定申告用、金額は本人の負担分）
+export interface BusinessLine {
+  expenseId: string;
+  date: string;
+  categoryId: string;
+  category: string;
+  account: string;
+  place: string;
+  memo: string;
+  amount: number;
+  ratio: number;
+  businessAmount: number;
+}
+
+export interface BusinessAccount {
+  account: string;
+  amount: number;
+  businessAmount: number;
+  count: number;
+}
+
+export interface BusinessReport {
+  year: string;
+  total: number;
+  byAccount: BusinessAccount[];
+  lines: BusinessLine[];
+}
+
+// 医療費の区分（空=treatment）
+export type MedicalKind = 'treatment' | 'care' | 'medicine' | 'other';
+
+// 医療費控除の明細書型（金額は明細書の (A)〜(F) と控除額）
+export interface MedicalRow {
+  patient: string;
+  provider: string;
+  kinds: MedicalKind[];
+  amount: number;
+  reimbursed: number;
+  count: number;
+}
+
+export interface MedicalPatient {
+  patient: string;
+  amount: number;
+  reimbursed: number;
+}
+
+export interface MedicalReport {
+  year: string;
+  rows: MedicalRow[];
+  byPatient: MedicalPatient[];
+  total: number;
+  reimbursed: number;
+  net: number;
+  income: number;
+  threshold: number;
+  deduction: number;
+}
+
+export interface CSVExport {
+  fileName: string;
+  content: string;
+}
+
+// 消費税集計型（税率別内訳のある支出のみ）
+export interface TaxRateAmount {
+  rate: number;
+  taxable: number;
+  tax: number;
+}
+
+export interface TaxCategoryAmount {
+  categoryId: string;
+  category: string;
+  color: string;
+  taxable: number;
+  tax: number;
+}
+
+export interface TaxMonth {
+  month: string;
+  taxable: number;
+  tax: number;
+  byRate: TaxRateAmount[];
+  byCategory: TaxCategoryAmount[];
+}
+
+export interface TaxSummary {
+  from: string;
+  to: string;
+  taxable: number;
+  tax: number;
+  byRate: TaxRateAmount[];
+  months: TaxMonth[];
+}
+
+// 残高照合入力型
+export interface ReconcileInput {
+  payer: string;
+  date: string;
   balance: number;
+  memo?: string;
+}
+
+// 残高照合結果
+export interface ReconcileResult {
+  payer: string;
+  date: string;
+  expected: number;
+  actual: number;
+  difference: number;
+  adjustment: Expense | null;
 }
 
 // カテゴリ別集計
@@ -79,6 +416,80 @@ export interface MonthComparison {
   total: number;
   diff: number;
   diffPercent: number;
+  byCategory?: CategoryComparison[];
+}
+
+// カテゴリ別の比較（amount は比較対象の金額）
+export interface CategoryComparison {
+  categoryId: string;
+  category: string;
+  amount: number;
+  diff: number;
+  diffPercent: number;
+}
+
+// 支出の異常検知型（過去 12 ヶ月の中央値・ばらつきとの比較）
+export type InsightKind = 'monthToDate' | 'last30Days' | 'largeExpense';
+
+export interface InsightFlag {
+  kind: InsightKind;
+  categoryId: string;
+  category: string;
+  amount: number;
+  median: number;
+  spread: number;
+  ratio: number;
+  expenseId?: string;
+  date?: string;
+  place?: string;
+  memo?: string;
+}
+
+export interface Insights {
+  date: string;
+  month: string;
+  monthStart: string;
+  flags: InsightFlag[];
+}
+
+// 週別集計型
+export interface WeekData {
+  week: string;
+  total: number;
+  byCategory: CategorySummary[] | null;
+}
+
+export interface WeeklySummary {
+  week: string;
+  weekEnd: string;
+  weekStart: WeekStart;
+  total: number;
+  byCategory: CategorySummary[] | null;
+  previousWeek: MonthComparison | null;
+  averageWeeks: number;
+  recentAverage: MonthComparison | null;
+  weeks: WeekData[];
+}
+
+// メンバー別集計（登録者ごとの合計とカテゴリ別内訳）
+export interface MemberTotal {
+  email: string;
+  name: string;
+  total: number;
+  byCategory: CategorySummary[];
+}
+
+export interface MemberMonth {
+  month: string;
+  totals: Record<string, number>;
+}
+
+export interface MemberSummary {
+  month: string;
+  from: string;
+  members: MemberTotal[];
+  window: MemberTotal[];
+  months: MemberMonth[];
 }
 
 // 月別集計
@@ -103,15 +514,80 @@ export interface YearlySummary {
   months: MonthData[];
 }
 
+// 年間レポートのカテゴリ別の行
+export interface AnnualCategoryRow {
+  categoryId: string;
+  category: string;
+  color: string;
+  months: number[];
+  total: number;
+  average: number;
+}
+
+// 暦年・年度の年間レポート
+export interface AnnualReport {
+  year: string;
+  startMonth: number;
+  months: string[];
+  monthTotals: number[];
+  rows: AnnualCategoryRow[];
+  total: number;
+  average: number;
+  elapsedMonths: number;
+  previousYear: MonthComparison | null;
+}
+
+// 同じ月数の 2 つの期間の比較
+export interface PeriodComparison {
+  from: string;
+  to: string;
+  compareFrom: string;
+  compareTo: string;
+  total: number;
+  byCategory: CategorySummary[] | null;
+  comparison: MonthComparison;
+}
+
+// 集計キャッシュの照合・再構築の結果（管理者のみ）
+export interface SummaryCacheDiff {
+  payer: string;
+  categoryId: string;
+  cached: number;
+  actual: number;
+}
+
+export interface SummaryCacheMonth {
+  month: string;
+  startDay: number;
+  status: 'ok' | 'missing' | 'invalid' | 'mismatch';
+  diffs?: SummaryCacheDiff[];
+  privateMismatches?: number;
+  rebuilt: boolean;
+}
+
+export interface SummaryCacheReport {
+  from: string;
+  to: string;
+  verify: boolean;
+  months: SummaryCacheMonth[];
+  mismatches: number;
+  rebuilt: number;
+}
+
 // 定期支出テンプレート
 export interface RecurringExpense {
   id: string;
   category: string;
   amount: number;
+  currency?: string;
+  originalAmount?: number;
   payer: string;
   place: string;
   memo: string;
   visibility: string;
+  paidBy?: string;
+  split?: Split;
+  tags?: string[];
   frequency: 'monthly' | 'bimonthly' | 'yearly';
   dayOfMonth: number;
   repeatMonth: number;
@@ -127,10 +603,15 @@ export interface RecurringExpense {
 export interface RecurringExpenseInput {
   category: string;
   amount: number;
+  currency?: string;
+  originalAmount?: number;
   payer: string;
   place: string;
   memo: string;
   visibility?: Visibility;
+  paidBy?: string;
+  split?: Split;
+  tags?: string[];
   frequency: 'monthly' | 'bimonthly' | 'yearly';
   dayOfMonth: number;
   repeatMonth: number;
@@ -139,6 +620,19 @@ export interface RecurringExpenseInput {
   isActive: boolean;
 }
 
+// 世帯設定型
+export interface HouseholdSettings {
+  // 月の開始日（1〜28、1=カレンダー月。25 なら「3月」は 3/25〜4/24）
+  monthStartDay: number;
+  // 週の開始曜日（週別集計の区切り）
+  weekStart?: WeekStart;
+  // 年度の開始月（1〜12、1=暦年。4 なら 2025 年度は 2025-04〜2026-03）
+  fiscalYearStartMonth?: number;
+  updatedAt?: string;
+}
+
+export type WeekStart = 'monday' | 'sunday';
+
 // カテゴリ入力型
 export interface CategoryInput {
   name: string;
@@ -149,6 +643,9 @@ export interface CategoryInput {
   excludeFromBreakdown: boolean;
   excludeFromSummary: boolean;
   ownerEmail?: string;
+  businessRatio?: number;
+  expenseAccount?: string;
+  isMedical?: boolean;
 }
 
 // 場所入力型
@@ -164,6 +661,12 @@ export interface PayerInput {
   sortOrder: number;
   isActive: boolean;
   trackBalance: boolean;
+  openingBalance: number;
+  openingDate: string;
+  closingDay: number;
+  paymentDay: number;
+  paymentMonthOffset: number;
+  paymentSource: string;
 }
 
 // APIレスポンス型
@@ -20,6 +20,11 @@ func route(ctx context.Context, event json.RawMessage) (any, error) {
 	if err := json.Unmarshal(event, &httpEvent); err == nil && httpEvent.RequestContext.HTTP.Method != "" {
 		return handler.Handle(ctx, httpEvent)
 	}
+	// DynamoDB Streams（expenses テーブルの変更）
+	var streamEvent events.DynamoDBEvent
+	if err := json.Unmarshal(event, &streamEvent); err == nil && len(streamEvent.Records) > 0 && streamEvent.Records[0].EventSource == "aws:dynamodb" {
+		return handler.HandleExpenseStream(ctx, streamEvent)
+	}
 	// 非HTTPイベント: action で振り分け
 	var scheduled struct {
 		Action string `json:"action"`
@@ -0,0 +1,42 @@
+// 残高スナップショット再構築スクリプト
+//
+// 全支出から trackBalance=true の支払元の月別残高スナップショットを作り直す。
+// 移行・インポート後や、カテゴリ設定の変更後に実行する。
+//
+// 使い方:
+//   環境変数を設定してから実行:
+//     export DYNAMO_EXPENSE_TABLE=money-diary-expenses
+//     export DYNAMO_MASTER_TABLE=money-diary-master
+//     go run ./cmd/rebuild-snapshots
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"money-diary/internal/dynamo"
+	"money-diary/internal/service"
+)
+
+func main() {
+	ctx := context.Background()
+
+	if os.Getenv("DYNAMO_EXPENSE_TABLE") == "" || os.Getenv("DYNAMO_MASTER_TABLE") == "" {
+		log.Fatal("DYNAMO_EXPENSE_TABLE と DYNAMO_MASTER_TABLE を設定してください")
+	}
+
+	client, err := dynamo.NewClient(ctx)
+	if err != nil {
+		log.Fatalf("DynamoDB client error: %v", err)
+	}
+
+	result, err := service.RebuildBalanceSnapshots(ctx, client)
+	if err != nil {
+		log.Fatalf("再構築エラー: %v", err)
+	}
+	for payer, count := range result {
+		log.Printf("  %s: %d ヶ月", payer, count)
+	}
+	log.Printf("再構築完了: %d 支払元", len(result))
+}
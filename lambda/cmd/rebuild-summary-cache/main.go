@@ -0,0 +1,68 @@
+// 月別集計キャッシュ再構築スクリプト
+//
+// 指定した月範囲の月別集計キャッシュを支出記録から作り直す（カレンダー月と世帯設定の開始日の月）。
+// -verify を付けると保存せず、キャッシュと記録からの集計の差異だけを表示する（差異があれば終了コード 1）。
+// インポートや DynamoDB を直接操作した後に実行する。
+//
+// 使い方:
+//   環境変数を設定してから実行:
+//     export DYNAMO_EXPENSE_TABLE=money-diary-expenses
+//     export DYNAMO_MASTER_TABLE=money-diary-master
+//     go run ./cmd/rebuild-summary-cache -from 2025-01 -to 2025-12
+//     go run ./cmd/rebuild-summary-cache -from 2025-01 -to 2025-12 -verify
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"money-diary/internal/dynamo"
+	"money-diary/internal/service"
+)
+
+func main() {
+	from := flag.String("from", "", "開始月 (YYYY-MM)")
+	to := flag.String("to", "", "終了月 (YYYY-MM)")
+	verify := flag.Bool("verify", false, "保存せずに照合のみ行う")
+	flag.Parse()
+
+	if *from == "" || *to == "" {
+		log.Fatal("使い方: go run ./cmd/rebuild-summary-cache -from YYYY-MM -to YYYY-MM [-verify]")
+	}
+
+	ctx := context.Background()
+
+	if os.Getenv("DYNAMO_EXPENSE_TABLE") == "" || os.Getenv("DYNAMO_MASTER_TABLE") == "" {
+		log.Fatal("DYNAMO_EXPENSE_TABLE と DYNAMO_MASTER_TABLE を設定してください")
+	}
+
+	client, err := dynamo.NewClient(ctx)
+	if err != nil {
+		log.Fatalf("DynamoDB client error: %v", err)
+	}
+
+	report, err := service.RebuildSummaryCaches(ctx, client, *from, *to, *verify)
+	if err != nil {
+		log.Fatalf("再構築エラー: %v", err)
+	}
+	for _, m := range report.Months {
+		log.Printf("  %s (開始日 %d): %s", m.Month, m.StartDay, m.Status)
+		for _, d := range m.Diffs {
+			log.Printf("    %s / %s: キャッシュ %d, 記録 %d", d.Payer, d.CategoryID, d.Cached, d.Actual)
+		}
+		if m.PrivateMismatches > 0 {
+			log.Printf("    private の食い違い: %d 人", m.PrivateMismatches)
+		}
+	}
+
+	if *verify {
+		log.Printf("照合完了: %d ヶ月中 %d ヶ月で食い違い", len(report.Months), report.Mismatches)
+		if report.Mismatches > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	log.Printf("再構築完了: %d ヶ月（食い違い %d ヶ月）", report.Rebuilt, report.Mismatches)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"sort"
 	"strconv"
+	"strings"
 
 	"money-diary/internal/dynamo"
 	"money-diary/internal/model"
@@ -32,9 +33,65 @@ func expenseToRow(e *model.Expense, catNameMap map[string]string) []interface{}
 		e.CreatedAt,
 		e.UpdatedAt,
 		e.Visibility,
+		e.Type,
+		e.ToPayer,
+		e.Currency,
+		formatFloat(e.OriginalAmount),
+		formatFloat(e.ExchangeRate),
+		e.PaidBy,
+		e.PaidTo,
+		formatSplit(e.Split),
+		strings.Join(e.Tags, ","),
+		formatItems(e.Items, catNameMap),
+		formatTaxLines(e.TaxLines),
 	}
 }
 
+// formatItems は明細を "カテゴリ名=金額(メモ);..." 形式の文字列に変換する（明細なしは空欄）
+func formatItems(items []model.LineItem, catNameMap map[string]string) string {
+	parts := make([]string, len(items))
+	for i, li := range items {
+		name := catNameMap[li.Category]
+		if name == "" {
+			name = li.Category
+		}
+		parts[i] = name + "=" + strconv.Itoa(li.Amount)
+		if li.Memo != "" {
+			parts[i] += "(" + li.Memo + ")"
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// formatTaxLines は消費税の内訳を "税率%=税込金額(税額);..." 形式の文字列に変換する（内訳なしは空欄）
+func formatTaxLines(lines []model.TaxLine) string {
+	parts := make([]string, len(lines))
+	for i, t := range lines {
+		parts[i] = fmt.Sprintf("%d%%=%d(%d)", t.Rate, t.Amount, t.Tax)
+	}
+	return strings.Join(parts, ";")
+}
+
+// formatSplit は負担割合を "method:email=value;..." 形式の文字列に変換する（nil は空欄）
+func formatSplit(split *model.Split) string {
+	if split == nil {
+		return ""
+	}
+	shares := make([]string, len(split.Shares))
+	for i, sh := range split.Shares {
+		shares[i] = sh.Email + "=" + strconv.Itoa(sh.Value)
+	}
+	return split.Method + ":" + strings.Join(shares, ";")
+}
+
+// formatFloat は外貨金額・レートを文字列に変換する（0 は空欄）
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
 // SyncExpenses は DynamoDB の全支出を Google Sheets に全件洗い替えする
 func SyncExpenses(ctx context.Context, dynamoClient *dynamo.Client) error {
 	expenses, err := dynamoClient.ScanAllExpenses(ctx)
@@ -0,0 +1,75 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNotFound はキーに対応するオブジェクトが存在しない場合のエラー
+var ErrNotFound = errors.New("blob not found")
+
+// ErrPresignNotSupported は署名付き URL を発行できないストアの場合のエラー（直接アップロード・取得を使う）
+var ErrPresignNotSupported = errors.New("presigned URL is not supported")
+
+// Store は添付ファイルなどのバイナリを保存するストア
+type Store interface {
+	// Put はオブジェクトを保存する（同じキーは上書き）
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get はオブジェクトを取得する。呼び出し側で Close すること。
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete はオブジェクトを削除する（存在しない場合もエラーにしない）
+	Delete(ctx context.Context, key string) error
+	// Stat はオブジェクトのサイズと Content-Type を返す（存在しない場合は ErrNotFound）
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// PresignPut はブラウザから直接アップロードするための署名付き URL を返す（Content-Type とサイズを固定）
+	PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (string, error)
+	// PresignGet はブラウザから直接取得するための署名付き URL を返す
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// ObjectInfo は保存済みオブジェクトの情報
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+var (
+	instance Store
+	once     sync.Once
+	initErr  error
+)
+
+// NewStore は環境変数に応じたストアを生成する（sync.Once でシングルトン）。
+// BLOB_STORE=s3（ATTACHMENT_BUCKET 指定時の既定）は S3、BLOB_STORE=local は BLOB_DIR 配下のファイルに保存する。
+func NewStore(ctx context.Context) (Store, error) {
+	once.Do(func() {
+		kind := os.Getenv("BLOB_STORE")
+		if kind == "" {
+			kind = "local"
+			if os.Getenv("ATTACHMENT_BUCKET") != "" {
+				kind = "s3"
+			}
+		}
+		switch kind {
+		case "s3":
+			instance, initErr = NewS3Store(ctx, os.Getenv("ATTACHMENT_BUCKET"))
+		case "local":
+			dir := os.Getenv("BLOB_DIR")
+			if dir == "" {
+				dir = "data/blobs"
+			}
+			instance = NewLocalStore(dir)
+		default:
+			initErr = fmt.Errorf("BLOB_STORE の値が不正です: %s", kind)
+		}
+	})
+	if initErr != nil {
+		return nil, initErr
+	}
+	return instance, nil
+}
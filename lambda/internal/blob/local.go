@@ -0,0 +1,113 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStore はローカルファイルシステムに保存するストア（セルフホスト・開発用）
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore は dir 配下に保存するストアを生成する（ディレクトリは初回保存時に作成する）
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+// path はキーをファイルパスに変換する（ディレクトリ外を指すキーは拒否）
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("不正な blob キー: %s", key)
+	}
+	return filepath.Join(s.dir, cleaned), nil
+}
+
+// Put はファイルに書き込む。書き込み途中のファイルが残らないよう一時ファイルからリネームする。
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("blob ディレクトリの作成に失敗: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("blob の書き込みに失敗: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("blob の書き込みに失敗: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("blob の書き込みに失敗: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("blob の書き込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// Get はファイルを開く
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blob の読み込みに失敗: %w", err)
+	}
+	return f, nil
+}
+
+// Delete はファイルを削除する
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("blob の削除に失敗: %w", err)
+	}
+	return nil
+}
+
+// Stat はファイルのサイズを返す（Content-Type は保存しないため空）
+func (s *LocalStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blob の情報取得に失敗: %w", err)
+	}
+	return &ObjectInfo{Size: info.Size()}, nil
+}
+
+// PresignPut はローカルストアでは使えない
+func (s *LocalStore) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// PresignGet はローカルストアでは使えない
+func (s *LocalStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
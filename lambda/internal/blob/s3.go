@@ -0,0 +1,106 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Store は S3 バケットに保存するストア（Lambda デプロイ用）
+type S3Store struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+}
+
+// NewS3Store は bucket に保存するストアを生成する
+func NewS3Store(ctx context.Context, bucket string) (*S3Store, error) {
+	if bucket == "" {
+		return nil, errors.New("ATTACHMENT_BUCKET が設定されていません")
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("ap-northeast-1"))
+	if err != nil {
+		return nil, fmt.Errorf("AWS config の読み込みに失敗: %w", err)
+	}
+	client := s3.NewFromConfig(cfg)
+	return &S3Store{client: client, presign: s3.NewPresignClient(client), bucket: bucket}, nil
+}
+
+// Put はオブジェクトを保存する
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &s.bucket,
+		Key:         &key,
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("blob の保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// Get はオブジェクトを取得する
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: &s.bucket, Key: &key})
+	if err != nil {
+		var notFound *types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("blob の取得に失敗: %w", err)
+	}
+	return out.Body, nil
+}
+
+// Delete はオブジェクトを削除する（S3 は存在しないキーの削除もエラーにならない）
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &s.bucket, Key: &key}); err != nil {
+		return fmt.Errorf("blob の削除に失敗: %w", err)
+	}
+	return nil
+}
+
+// Stat は HeadObject でオブジェクトの情報を返す
+func (s *S3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &s.bucket, Key: &key})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("blob の情報取得に失敗: %w", err)
+	}
+	return &ObjectInfo{Size: aws.ToInt64(out.ContentLength), ContentType: aws.ToString(out.ContentType)}, nil
+}
+
+// PresignPut は Content-Type と Content-Length を固定した PUT 用の署名付き URL を返す
+// （署名と異なるサイズのアップロードは S3 が拒否する）
+func (s *S3Store) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (string, error) {
+	req, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &s.bucket,
+		Key:           &key,
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("署名付き URL の発行に失敗: %w", err)
+	}
+	return req.URL, nil
+}
+
+// PresignGet は GET 用の署名付き URL を返す
+func (s *S3Store) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: &s.bucket, Key: &key}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("署名付き URL の発行に失敗: %w", err)
+	}
+	return req.URL, nil
+}
@@ -2,12 +2,16 @@ package dynamo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -54,33 +58,73 @@ func NewClient(ctx context.Context) (*Client, error) {
 
 // expenseItem は DynamoDB expenses テーブルのアイテム
 type expenseItem struct {
-	ID         string `dynamodbav:"id"`
-	YearMonth  string `dynamodbav:"yearMonth"`
-	Date       string `dynamodbav:"date"`
-	Payer      string `dynamodbav:"payer"`
-	Category   string `dynamodbav:"category"`
-	Amount     int    `dynamodbav:"amount"`
-	Memo       string `dynamodbav:"memo"`
-	Place      string `dynamodbav:"place"`
-	Visibility string `dynamodbav:"visibility,omitempty"`
-	CreatedBy  string `dynamodbav:"createdBy"`
-	CreatedAt  string `dynamodbav:"createdAt"`
-	UpdatedAt  string `dynamodbav:"updatedAt"`
+	ID                 string           `dynamodbav:"id"`
+	Type               string           `dynamodbav:"type,omitempty"`
+	YearMonth          string           `dynamodbav:"yearMonth"`
+	Date               string           `dynamodbav:"date"`
+	Payer              string           `dynamodbav:"payer"`
+	ToPayer            string           `dynamodbav:"toPayer,omitempty"`
+	Category           string           `dynamodbav:"category"`
+	Amount             int              `dynamodbav:"amount"`
+	Items              []lineItem       `dynamodbav:"items,omitempty"`
+	TaxLines           []taxLine        `dynamodbav:"taxLines,omitempty"`
+	Currency           string           `dynamodbav:"currency,omitempty"`
+	OriginalAmount     float64          `dynamodbav:"originalAmount,omitempty"`
+	ExchangeRate       float64          `dynamodbav:"exchangeRate,omitempty"`
+	Memo               string           `dynamodbav:"memo"`
+	Place              string           `dynamodbav:"place"`
+	Visibility         string           `dynamodbav:"visibility,omitempty"`
+	PaidBy             string           `dynamodbav:"paidBy,omitempty"`
+	PaidTo             string           `dynamodbav:"paidTo,omitempty"`
+	Split              *splitItem       `dynamodbav:"split,omitempty"`
+	Tags               []string         `dynamodbav:"tags,stringset,omitempty"`
+	Attachments        []attachmentItem `dynamodbav:"attachments,omitempty"`
+	RefundOf           string           `dynamodbav:"refundOf,omitempty"`
+	RefundedAmount     int              `dynamodbav:"refundedAmount,omitempty"`
+	RefundedByCategory map[string]int   `dynamodbav:"refundedByCategory,omitempty"`
+	BusinessRatio      *int             `dynamodbav:"businessRatio,omitempty"`
+	Patient            string           `dynamodbav:"patient,omitempty"`
+	Provider           string           `dynamodbav:"provider,omitempty"`
+	MedicalKind        string           `dynamodbav:"medicalKind,omitempty"`
+	ReimbursedAmount   int              `dynamodbav:"reimbursedAmount,omitempty"`
+	CreatedBy          string           `dynamodbav:"createdBy"`
+	CreatedAt          string           `dynamodbav:"createdAt"`
+	UpdatedAt          string           `dynamodbav:"updatedAt"`
 }
 
 func (item *expenseItem) toModel() model.Expense {
 	return model.Expense{
-		ID:         item.ID,
-		Date:       item.Date,
-		Payer:      item.Payer,
-		Category:   item.Category,
-		Amount:     item.Amount,
-		Memo:       item.Memo,
-		Place:      item.Place,
-		Visibility: item.Visibility,
-		CreatedBy:  item.CreatedBy,
-		CreatedAt:  item.CreatedAt,
-		UpdatedAt:  item.UpdatedAt,
+		ID:                 item.ID,
+		Type:               item.Type,
+		Date:               item.Date,
+		Payer:              item.Payer,
+		ToPayer:            item.ToPayer,
+		Category:           item.Category,
+		Amount:             item.Amount,
+		Items:              lineItemsToModel(item.Items),
+		TaxLines:           taxLinesToModel(item.TaxLines),
+		Currency:           item.Currency,
+		OriginalAmount:     item.OriginalAmount,
+		ExchangeRate:       item.ExchangeRate,
+		Memo:               item.Memo,
+		Place:              item.Place,
+		Visibility:         item.Visibility,
+		PaidBy:             item.PaidBy,
+		PaidTo:             item.PaidTo,
+		Split:              item.Split.toModel(),
+		Tags:               sortedTags(item.Tags),
+		Attachments:        attachmentsToModel(item.Attachments),
+		RefundOf:           item.RefundOf,
+		RefundedAmount:     item.RefundedAmount,
+		RefundedByCategory: item.RefundedByCategory,
+		BusinessRatio:      item.BusinessRatio,
+		Patient:            item.Patient,
+		Provider:           item.Provider,
+		MedicalKind:        item.MedicalKind,
+		ReimbursedAmount:   item.ReimbursedAmount,
+		CreatedBy:          item.CreatedBy,
+		CreatedAt:          item.CreatedAt,
+		UpdatedAt:          item.UpdatedAt,
 	}
 }
 
@@ -90,19 +134,172 @@ func expenseFromModel(e *model.Expense) expenseItem {
 		ym = e.Date[:7]
 	}
 	return expenseItem{
-		ID:         e.ID,
-		YearMonth:  ym,
-		Date:       e.Date,
-		Payer:      e.Payer,
-		Category:   e.Category,
-		Amount:     e.Amount,
-		Memo:       e.Memo,
-		Place:      e.Place,
-		Visibility: e.Visibility,
-		CreatedBy:  e.CreatedBy,
-		CreatedAt:  e.CreatedAt,
-		UpdatedAt:  e.UpdatedAt,
+		ID:                 e.ID,
+		Type:               e.Type,
+		YearMonth:          ym,
+		Date:               e.Date,
+		Payer:              e.Payer,
+		ToPayer:            e.ToPayer,
+		Category:           e.Category,
+		Amount:             e.Amount,
+		Items:              lineItemsFromModel(e.Items),
+		TaxLines:           taxLinesFromModel(e.TaxLines),
+		Currency:           e.Currency,
+		OriginalAmount:     e.OriginalAmount,
+		ExchangeRate:       e.ExchangeRate,
+		Memo:               e.Memo,
+		Place:              e.Place,
+		Visibility:         e.Visibility,
+		PaidBy:             e.PaidBy,
+		PaidTo:             e.PaidTo,
+		Split:              splitFromModel(e.Split),
+		Tags:               e.Tags,
+		Attachments:        attachmentsFromModel(e.Attachments),
+		RefundOf:           e.RefundOf,
+		RefundedAmount:     e.RefundedAmount,
+		RefundedByCategory: e.RefundedByCategory,
+		BusinessRatio:      e.BusinessRatio,
+		Patient:            e.Patient,
+		Provider:           e.Provider,
+		MedicalKind:        e.MedicalKind,
+		ReimbursedAmount:   e.ReimbursedAmount,
+		CreatedBy:          e.CreatedBy,
+		CreatedAt:          e.CreatedAt,
+		UpdatedAt:          e.UpdatedAt,
+	}
+}
+
+// lineItem は支出の明細
+type lineItem struct {
+	Category string `dynamodbav:"category"`
+	Amount   int    `dynamodbav:"amount"`
+	Memo     string `dynamodbav:"memo,omitempty"`
+}
+
+func lineItemsToModel(items []lineItem) []model.LineItem {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make([]model.LineItem, len(items))
+	for i, li := range items {
+		result[i] = model.LineItem{Category: li.Category, Amount: li.Amount, Memo: li.Memo}
+	}
+	return result
+}
+
+func lineItemsFromModel(items []model.LineItem) []lineItem {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make([]lineItem, len(items))
+	for i, li := range items {
+		result[i] = lineItem{Category: li.Category, Amount: li.Amount, Memo: li.Memo}
+	}
+	return result
+}
+
+// taxLine は消費税の税率別内訳
+type taxLine struct {
+	Rate   int `dynamodbav:"rate"`
+	Amount int `dynamodbav:"amount"`
+	Tax    int `dynamodbav:"tax"`
+}
+
+func taxLinesToModel(lines []taxLine) []model.TaxLine {
+	if len(lines) == 0 {
+		return nil
+	}
+	result := make([]model.TaxLine, len(lines))
+	for i, t := range lines {
+		result[i] = model.TaxLine(t)
+	}
+	return result
+}
+
+func taxLinesFromModel(lines []model.TaxLine) []taxLine {
+	if len(lines) == 0 {
+		return nil
 	}
+	result := make([]taxLine, len(lines))
+	for i, t := range lines {
+		result[i] = taxLine(t)
+	}
+	return result
+}
+
+// attachmentItem は支出の添付ファイルのメタデータ
+type attachmentItem struct {
+	ID          string `dynamodbav:"id"`
+	FileName    string `dynamodbav:"fileName"`
+	ContentType string `dynamodbav:"contentType"`
+	Size        int64  `dynamodbav:"size"`
+	UploadedBy  string `dynamodbav:"uploadedBy"`
+	UploadedAt  string `dynamodbav:"uploadedAt"`
+}
+
+func attachmentsToModel(items []attachmentItem) []model.Attachment {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make([]model.Attachment, len(items))
+	for i, a := range items {
+		result[i] = model.Attachment(a)
+	}
+	return result
+}
+
+func attachmentsFromModel(attachments []model.Attachment) []attachmentItem {
+	if len(attachments) == 0 {
+		return nil
+	}
+	result := make([]attachmentItem, len(attachments))
+	for i, a := range attachments {
+		result[i] = attachmentItem(a)
+	}
+	return result
+}
+
+// splitItem は支出・定期支出テンプレートの負担割合
+type splitItem struct {
+	Method string           `dynamodbav:"method"`
+	Shares []splitShareItem `dynamodbav:"shares"`
+}
+
+type splitShareItem struct {
+	Email string `dynamodbav:"email"`
+	Value int    `dynamodbav:"value"`
+}
+
+func (item *splitItem) toModel() *model.Split {
+	if item == nil {
+		return nil
+	}
+	split := &model.Split{Method: item.Method, Shares: make([]model.SplitShare, len(item.Shares))}
+	for i, sh := range item.Shares {
+		split.Shares[i] = model.SplitShare{Email: sh.Email, Value: sh.Value}
+	}
+	return split
+}
+
+func splitFromModel(split *model.Split) *splitItem {
+	if split == nil {
+		return nil
+	}
+	item := &splitItem{Method: split.Method, Shares: make([]splitShareItem, len(split.Shares))}
+	for i, sh := range split.Shares {
+		item.Shares[i] = splitShareItem{Email: sh.Email, Value: sh.Value}
+	}
+	return item
+}
+
+// sortedTags は string set（順序なし）で保存したタグを昇順に並べて返す
+func sortedTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return sorted
 }
 
 // categoryItem は DynamoDB master テーブルのカテゴリアイテム
@@ -117,6 +314,9 @@ type categoryItem struct {
 	ExcludeFromBreakdown bool   `dynamodbav:"excludeFromBreakdown"`
 	ExcludeFromSummary   bool   `dynamodbav:"excludeFromSummary"`
 	OwnerEmail           string `dynamodbav:"ownerEmail,omitempty"`
+	BusinessRatio        int    `dynamodbav:"businessRatio,omitempty"`
+	ExpenseAccount       string `dynamodbav:"expenseAccount,omitempty"`
+	IsMedical            bool   `dynamodbav:"isMedical,omitempty"`
 }
 
 // placeItem は DynamoDB master テーブルの場所アイテム
@@ -130,22 +330,40 @@ type placeItem struct {
 
 // payerItem は DynamoDB master テーブルの支払元アイテム
 type payerItem struct {
-	Type         string `dynamodbav:"type"`
-	ID           string `dynamodbav:"id"`
-	Name         string `dynamodbav:"name"`
-	SortOrder    int    `dynamodbav:"sortOrder"`
-	IsActive     bool   `dynamodbav:"isActive"`
-	TrackBalance bool   `dynamodbav:"trackBalance"`
+	Type           string `dynamodbav:"type"`
+	ID             string `dynamodbav:"id"`
+	Name           string `dynamodbav:"name"`
+	SortOrder      int    `dynamodbav:"sortOrder"`
+	IsActive       bool   `dynamodbav:"isActive"`
+	TrackBalance   bool   `dynamodbav:"trackBalance"`
+	OpeningBalance int    `dynamodbav:"openingBalance,omitempty"`
+	OpeningDate    string `dynamodbav:"openingDate,omitempty"`
+
+	ClosingDay         int    `dynamodbav:"closingDay,omitempty"`
+	PaymentDay         int    `dynamodbav:"paymentDay,omitempty"`
+	PaymentMonthOffset int    `dynamodbav:"paymentMonthOffset,omitempty"`
+	PaymentSource      string `dynamodbav:"paymentSource,omitempty"`
 }
 
 // userItem は DynamoDB master テーブルのユーザーアイテム
 type userItem struct {
 	Type      string `dynamodbav:"type"`
 	ID        string `dynamodbav:"id"`
+	Name      string `dynamodbav:"name,omitempty"`
 	Role      string `dynamodbav:"role"`
 	CreatedAt string `dynamodbav:"createdAt"`
 }
 
+// settingsItem は DynamoDB master テーブルの世帯設定アイテム（type=settings, id=household）
+type settingsItem struct {
+	Type                 string `dynamodbav:"type"`
+	ID                   string `dynamodbav:"id"`
+	MonthStartDay        int    `dynamodbav:"monthStartDay,omitempty"`
+	WeekStart            string `dynamodbav:"weekStart,omitempty"`
+	FiscalYearStartMonth int    `dynamodbav:"fiscalYearStartMonth,omitempty"`
+	UpdatedAt            string `dynamodbav:"updatedAt,omitempty"`
+}
+
 // --- Expense 操作 ---
 
 // PutExpense は支出を DynamoDB に保存する（作成・更新兼用）
@@ -165,6 +383,98 @@ func (c *Client) PutExpense(ctx context.Context, e *model.Expense) error {
 	return nil
 }
 
+// PutRefund は返金の記録と返金元の返金済み額（合計とカテゴリ別の内訳 refundedByCategory）を 1 つのトランザクションで保存する。
+// 返金元の返金済み額が refundedBefore から変わっていた（同時に返金・更新された）場合は保存せず false を返す。
+func (c *Client) PutRefund(ctx context.Context, refund *model.Expense, refundedBefore int, refundedByCategory map[string]int) (bool, error) {
+	av, err := attributevalue.MarshalMap(expenseFromModel(refund))
+	if err != nil {
+		return false, fmt.Errorf("expense のマーシャルに失敗: %w", err)
+	}
+	byCategory, err := attributevalue.Marshal(refundedByCategory)
+	if err != nil {
+		return false, fmt.Errorf("refundedByCategory のマーシャルに失敗: %w", err)
+	}
+	// refundedAmount は 0 のとき保存しない（omitempty）
+	condition := "attribute_exists(id) AND refundedAmount = :before"
+	if refundedBefore == 0 {
+		condition = "attribute_exists(id) AND (attribute_not_exists(refundedAmount) OR refundedAmount = :before)"
+	}
+	_, err = c.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:           &c.expenseTable,
+				Item:                av,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			}},
+			{Update: &types.Update{
+				TableName: &c.expenseTable,
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: refund.RefundOf},
+				},
+				UpdateExpression:    aws.String("SET refundedAmount = :after, refundedByCategory = :byCategory, updatedAt = :now"),
+				ConditionExpression: aws.String(condition),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":before":     &types.AttributeValueMemberN{Value: strconv.Itoa(refundedBefore)},
+					":after":      &types.AttributeValueMemberN{Value: strconv.Itoa(refundedBefore + refund.Amount)},
+					":byCategory": byCategory,
+					":now":        &types.AttributeValueMemberS{Value: refund.CreatedAt},
+				},
+			}},
+		},
+	})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for _, reason := range canceled.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return false, nil
+			}
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("refund の保存に失敗: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseRefund は削除した返金の金額を返金元の返金済み額（合計とカテゴリ別の内訳）から差し引く（存在しない支出は何もしない）。
+// カテゴリ別の内訳が無い返金元（内訳の導入前の返金）は合計だけを差し引く。
+func (c *Client) ReleaseRefund(ctx context.Context, id string, category string, amount int, updatedAt string) error {
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+	values := map[string]types.AttributeValue{
+		":delta":  &types.AttributeValueMemberN{Value: strconv.Itoa(-amount)},
+		":amount": &types.AttributeValueMemberN{Value: strconv.Itoa(amount)},
+		":now":    &types.AttributeValueMemberS{Value: updatedAt},
+	}
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &c.expenseTable,
+		Key:                       key,
+		UpdateExpression:          aws.String("ADD refundedAmount :delta SET refundedByCategory.#c = refundedByCategory.#c - :amount, updatedAt = :now"),
+		ConditionExpression:       aws.String("attribute_exists(refundedByCategory.#c)"),
+		ExpressionAttributeNames:  map[string]string{"#c": category},
+		ExpressionAttributeValues: values,
+	})
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		delete(values, ":amount")
+		_, err = c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 &c.expenseTable,
+			Key:                       key,
+			UpdateExpression:          aws.String("ADD refundedAmount :delta SET updatedAt = :now"),
+			ConditionExpression:       aws.String("attribute_exists(id)"),
+			ExpressionAttributeValues: values,
+		})
+		if errors.As(err, &condErr) {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("refundedAmount の更新に失敗: %w", err)
+	}
+	return nil
+}
+
 // GetExpense は ID で支出を1件取得する
 func (c *Client) GetExpense(ctx context.Context, id string) (*model.Expense, error) {
 	out, err := c.db.GetItem(ctx, &dynamodb.GetItemInput{
@@ -204,6 +514,34 @@ func (c *Client) QueryExpensesByMonth(ctx context.Context, yearMonth string) ([]
 	return unmarshalExpenses(out.Items)
 }
 
+// QueryExpensesByMonthWithTags は指定月の支出のうち、指定タグをすべて含むものを取得する（新しい日付順）
+func (c *Client) QueryExpensesByMonthWithTags(ctx context.Context, yearMonth string, tags []string) ([]model.Expense, error) {
+	if len(tags) == 0 {
+		return c.QueryExpensesByMonth(ctx, yearMonth)
+	}
+	values := map[string]types.AttributeValue{
+		":ym": &types.AttributeValueMemberS{Value: yearMonth},
+	}
+	conditions := make([]string, len(tags))
+	for i, tag := range tags {
+		key := fmt.Sprintf(":tag%d", i)
+		values[key] = &types.AttributeValueMemberS{Value: tag}
+		conditions[i] = fmt.Sprintf("contains(tags, %s)", key)
+	}
+	out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 &c.expenseTable,
+		IndexName:                 aws.String("yearMonth-date-index"),
+		KeyConditionExpression:    aws.String("yearMonth = :ym"),
+		FilterExpression:          aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("expense のタグ別クエリに失敗: %w", err)
+	}
+	return unmarshalExpenses(out.Items)
+}
+
 // ScanAllExpenses は全支出データを取得する
 func (c *Client) ScanAllExpenses(ctx context.Context) ([]model.Expense, error) {
 	var allItems []map[string]types.AttributeValue
@@ -297,6 +635,9 @@ func (c *Client) GetCategories(ctx context.Context) ([]model.Category, error) {
 				ExcludeFromBreakdown: item.ExcludeFromBreakdown,
 				ExcludeFromSummary:   item.ExcludeFromSummary,
 				OwnerEmail:           item.OwnerEmail,
+				BusinessRatio:        item.BusinessRatio,
+				ExpenseAccount:       item.ExpenseAccount,
+				IsMedical:            item.IsMedical,
 			})
 		}
 	}
@@ -347,11 +688,18 @@ func (c *Client) GetPayers(ctx context.Context) ([]model.Payer, error) {
 	for _, item := range dbItems {
 		if item.IsActive {
 			payers = append(payers, model.Payer{
-				ID:           item.ID,
-				Name:         item.Name,
-				SortOrder:    item.SortOrder,
-				IsActive:     item.IsActive,
-				TrackBalance: item.TrackBalance,
+				ID:             item.ID,
+				Name:           item.Name,
+				SortOrder:      item.SortOrder,
+				IsActive:       item.IsActive,
+				TrackBalance:   item.TrackBalance,
+				OpeningBalance: item.OpeningBalance,
+				OpeningDate:    item.OpeningDate,
+
+				ClosingDay:         item.ClosingDay,
+				PaymentDay:         item.PaymentDay,
+				PaymentMonthOffset: item.PaymentMonthOffset,
+				PaymentSource:      item.PaymentSource,
 			})
 		}
 	}
@@ -379,6 +727,9 @@ func (c *Client) GetAllCategories(ctx context.Context) ([]model.Category, error)
 			ExcludeFromBreakdown: item.ExcludeFromBreakdown,
 			ExcludeFromSummary:   item.ExcludeFromSummary,
 			OwnerEmail:           item.OwnerEmail,
+			BusinessRatio:        item.BusinessRatio,
+			ExpenseAccount:       item.ExpenseAccount,
+			IsMedical:            item.IsMedical,
 		}
 	}
 	sort.Slice(categories, func(i, j int) bool {
@@ -395,6 +746,9 @@ func (c *Client) PutCategory(ctx context.Context, cat *model.Category) error {
 		ExcludeFromBreakdown: cat.ExcludeFromBreakdown,
 		ExcludeFromSummary:   cat.ExcludeFromSummary,
 		OwnerEmail:           cat.OwnerEmail,
+		BusinessRatio:        cat.BusinessRatio,
+		ExpenseAccount:       cat.ExpenseAccount,
+		IsMedical:            cat.IsMedical,
 	}
 	av, err := attributevalue.MarshalMap(item)
 	if err != nil {
@@ -496,6 +850,9 @@ func (c *Client) GetAllPayers(ctx context.Context) ([]model.Payer, error) {
 		payers[i] = model.Payer{
 			ID: item.ID, Name: item.Name, SortOrder: item.SortOrder,
 			IsActive: item.IsActive, TrackBalance: item.TrackBalance,
+			OpeningBalance: item.OpeningBalance, OpeningDate: item.OpeningDate,
+			ClosingDay: item.ClosingDay, PaymentDay: item.PaymentDay,
+			PaymentMonthOffset: item.PaymentMonthOffset, PaymentSource: item.PaymentSource,
 		}
 	}
 	sort.Slice(payers, func(i, j int) bool {
@@ -509,6 +866,9 @@ func (c *Client) PutPayer(ctx context.Context, p *model.Payer) error {
 	item := payerItem{
 		Type: "payer", ID: p.ID, Name: p.Name, SortOrder: p.SortOrder,
 		IsActive: p.IsActive, TrackBalance: p.TrackBalance,
+		OpeningBalance: p.OpeningBalance, OpeningDate: p.OpeningDate,
+		ClosingDay: p.ClosingDay, PaymentDay: p.PaymentDay,
+		PaymentMonthOffset: p.PaymentMonthOffset, PaymentSource: p.PaymentSource,
 	}
 	av, err := attributevalue.MarshalMap(item)
 	if err != nil {
@@ -560,32 +920,122 @@ func (c *Client) GetUser(ctx context.Context, email string) (*model.User, error)
 	}
 	return &model.User{
 		Email:     item.ID,
+		Name:      item.Name,
 		Role:      item.Role,
 		CreatedAt: item.CreatedAt,
 	}, nil
 }
 
+// GetUsers は登録ユーザー一覧をメールアドレス順で返す
+func (c *Client) GetUsers(ctx context.Context) ([]model.User, error) {
+	items, err := c.queryMaster(ctx, "user")
+	if err != nil {
+		return nil, err
+	}
+	var dbItems []userItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &dbItems); err != nil {
+		return nil, fmt.Errorf("user のアンマーシャルに失敗: %w", err)
+	}
+	users := make([]model.User, len(dbItems))
+	for i, item := range dbItems {
+		users[i] = model.User{Email: item.ID, Name: item.Name, Role: item.Role, CreatedAt: item.CreatedAt}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+	return users, nil
+}
+
+// UpdateUserName はユーザーの表示名を更新する（登録済みユーザーのみ）
+func (c *Client) UpdateUserName(ctx context.Context, email string, name string) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.masterTable,
+		Key: map[string]types.AttributeValue{
+			"type": &types.AttributeValueMemberS{Value: "user"},
+			"id":   &types.AttributeValueMemberS{Value: email},
+		},
+		UpdateExpression:         aws.String("SET #name = :n"),
+		ConditionExpression:      aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: map[string]string{"#name": "name"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":n": &types.AttributeValueMemberS{Value: name},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("user の表示名更新に失敗: %w", err)
+	}
+	return nil
+}
+
+// --- Settings 操作 ---
+
+// GetHouseholdSettings は世帯設定を取得する（未保存の場合はゼロ値）
+func (c *Client) GetHouseholdSettings(ctx context.Context) (*model.HouseholdSettings, error) {
+	out, err := c.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &c.masterTable,
+		Key: map[string]types.AttributeValue{
+			"type": &types.AttributeValueMemberS{Value: "settings"},
+			"id":   &types.AttributeValueMemberS{Value: "household"},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("settings の取得に失敗: %w", err)
+	}
+	if out.Item == nil {
+		return &model.HouseholdSettings{}, nil
+	}
+	var item settingsItem
+	if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
+		return nil, fmt.Errorf("settings のアンマーシャルに失敗: %w", err)
+	}
+	return &model.HouseholdSettings{MonthStartDay: item.MonthStartDay, WeekStart: item.WeekStart, FiscalYearStartMonth: item.FiscalYearStartMonth, UpdatedAt: item.UpdatedAt}, nil
+}
+
+// PutHouseholdSettings は世帯設定を保存する
+func (c *Client) PutHouseholdSettings(ctx context.Context, s *model.HouseholdSettings) error {
+	av, err := attributevalue.MarshalMap(settingsItem{
+		Type: "settings", ID: "household", MonthStartDay: s.MonthStartDay, WeekStart: s.WeekStart,
+		FiscalYearStartMonth: s.FiscalYearStartMonth, UpdatedAt: s.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("settings のマーシャルに失敗: %w", err)
+	}
+	_, err = c.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &c.masterTable,
+		Item:      av,
+	})
+	if err != nil {
+		return fmt.Errorf("settings の保存に失敗: %w", err)
+	}
+	return nil
+}
+
 // --- Recurring 操作 ---
 
 // recurringItem は DynamoDB master テーブルの定期支出アイテム
 type recurringItem struct {
-	Type             string `dynamodbav:"type"`
-	ID               string `dynamodbav:"id"`
-	Category         string `dynamodbav:"category"`
-	Amount           int    `dynamodbav:"amount"`
-	Payer            string `dynamodbav:"payer"`
-	Place            string `dynamodbav:"place"`
-	Memo             string `dynamodbav:"memo"`
-	Visibility       string `dynamodbav:"visibility"`
-	Frequency        string `dynamodbav:"frequency"`
-	DayOfMonth       int    `dynamodbav:"dayOfMonth"`
-	RepeatMonth      int    `dynamodbav:"repeatMonth"`
-	StartMonth       string `dynamodbav:"startMonth"`
-	EndMonth         string `dynamodbav:"endMonth"`
-	IsActive         bool   `dynamodbav:"isActive"`
-	LastCreatedMonth string `dynamodbav:"lastCreatedMonth"`
-	CreatedAt        string `dynamodbav:"createdAt"`
-	UpdatedAt        string `dynamodbav:"updatedAt"`
+	Type             string     `dynamodbav:"type"`
+	ID               string     `dynamodbav:"id"`
+	Category         string     `dynamodbav:"category"`
+	Amount           int        `dynamodbav:"amount"`
+	Currency         string     `dynamodbav:"currency,omitempty"`
+	OriginalAmount   float64    `dynamodbav:"originalAmount,omitempty"`
+	Payer            string     `dynamodbav:"payer"`
+	Place            string     `dynamodbav:"place"`
+	Memo             string     `dynamodbav:"memo"`
+	Visibility       string     `dynamodbav:"visibility"`
+	PaidBy           string     `dynamodbav:"paidBy,omitempty"`
+	Split            *splitItem `dynamodbav:"split,omitempty"`
+	Tags             []string   `dynamodbav:"tags,stringset,omitempty"`
+	Frequency        string     `dynamodbav:"frequency"`
+	DayOfMonth       int        `dynamodbav:"dayOfMonth"`
+	RepeatMonth      int        `dynamodbav:"repeatMonth"`
+	StartMonth       string     `dynamodbav:"startMonth"`
+	EndMonth         string     `dynamodbav:"endMonth"`
+	IsActive         bool       `dynamodbav:"isActive"`
+	LastCreatedMonth string     `dynamodbav:"lastCreatedMonth"`
+	CreatedAt        string     `dynamodbav:"createdAt"`
+	UpdatedAt        string     `dynamodbav:"updatedAt"`
 }
 
 func (item *recurringItem) toModel() model.RecurringExpense {
@@ -593,10 +1043,15 @@ func (item *recurringItem) toModel() model.RecurringExpense {
 		ID:               item.ID,
 		Category:         item.Category,
 		Amount:           item.Amount,
+		Currency:         item.Currency,
+		OriginalAmount:   item.OriginalAmount,
 		Payer:            item.Payer,
 		Place:            item.Place,
 		Memo:             item.Memo,
 		Visibility:       item.Visibility,
+		PaidBy:           item.PaidBy,
+		Split:            item.Split.toModel(),
+		Tags:             sortedTags(item.Tags),
 		Frequency:        item.Frequency,
 		DayOfMonth:       item.DayOfMonth,
 		RepeatMonth:      item.RepeatMonth,
@@ -633,10 +1088,15 @@ func (c *Client) PutRecurringExpense(ctx context.Context, r *model.RecurringExpe
 		ID:               r.ID,
 		Category:         r.Category,
 		Amount:           r.Amount,
+		Currency:         r.Currency,
+		OriginalAmount:   r.OriginalAmount,
 		Payer:            r.Payer,
 		Place:            r.Place,
 		Memo:             r.Memo,
 		Visibility:       r.Visibility,
+		PaidBy:           r.PaidBy,
+		Split:            splitFromModel(r.Split),
+		Tags:             r.Tags,
 		Frequency:        r.Frequency,
 		DayOfMonth:       r.DayOfMonth,
 		RepeatMonth:      r.RepeatMonth,
@@ -695,118 +1155,273 @@ func (c *Client) UpdateRecurringLastCreated(ctx context.Context, id string, mont
 	return nil
 }
 
-// --- 月別集計キャッシュ ---
+// --- 為替レート ---
 
-// categorySumItem は DynamoDB 内のカテゴリ別集計
-type categorySumItem struct {
-	CategoryID string `dynamodbav:"categoryId"`
-	Category   string `dynamodbav:"category"`
-	Amount     int    `dynamodbav:"amount"`
-	Color      string `dynamodbav:"color"`
+// exchangeRateItem は DynamoDB master テーブルの為替レートアイテム（id = "<通貨>#<適用開始日>"）
+type exchangeRateItem struct {
+	Type      string  `dynamodbav:"type"`
+	ID        string  `dynamodbav:"id"`
+	Currency  string  `dynamodbav:"currency"`
+	Date      string  `dynamodbav:"date"`
+	Rate      float64 `dynamodbav:"rate"`
+	UpdatedAt string  `dynamodbav:"updatedAt"`
 }
 
-// monthlySumCacheItem は DynamoDB 内の月別集計キャッシュ
-type monthlySumCacheItem struct {
-	Type       string            `dynamodbav:"type"`
-	ID         string            `dynamodbav:"id"`
-	Total      int               `dynamodbav:"total"`
-	ByCategory []categorySumItem `dynamodbav:"byCategory"`
-	UpdatedAt  string            `dynamodbav:"updatedAt"`
+// ExchangeRateID は為替レートの id を返す
+func ExchangeRateID(currency string, date string) string {
+	return currency + "#" + date
 }
 
-// GetMonthlySummaryCache は月別集計キャッシュを取得する（nil = 未キャッシュ）
-func (c *Client) GetMonthlySummaryCache(ctx context.Context, yearMonth string) (*model.MonthData, error) {
-	out, err := c.db.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: &c.masterTable,
-		Key: map[string]types.AttributeValue{
-			"type": &types.AttributeValueMemberS{Value: "monthlySummary"},
-			"id":   &types.AttributeValueMemberS{Value: yearMonth},
-		},
-	})
+func exchangeRateFromModel(r *model.ExchangeRate) exchangeRateItem {
+	return exchangeRateItem{
+		Type:      "exchangeRate",
+		ID:        ExchangeRateID(r.Currency, r.Date),
+		Currency:  r.Currency,
+		Date:      r.Date,
+		Rate:      r.Rate,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// GetExchangeRates は全為替レートを通貨・適用開始日の昇順で返す
+func (c *Client) GetExchangeRates(ctx context.Context) ([]model.ExchangeRate, error) {
+	items, err := c.queryMaster(ctx, "exchangeRate")
 	if err != nil {
-		return nil, fmt.Errorf("monthlySummary の取得に失敗: %w", err)
+		return nil, err
 	}
-	if out.Item == nil {
-		return nil, nil
+	var dbItems []exchangeRateItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &dbItems); err != nil {
+		return nil, fmt.Errorf("exchangeRate のアンマーシャルに失敗: %w", err)
 	}
-	var item monthlySumCacheItem
-	if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
-		return nil, fmt.Errorf("monthlySummary のアンマーシャルに失敗: %w", err)
+	rates := make([]model.ExchangeRate, len(dbItems))
+	for i, item := range dbItems {
+		rates[i] = model.ExchangeRate{
+			ID: item.ID, Currency: item.Currency, Date: item.Date, Rate: item.Rate, UpdatedAt: item.UpdatedAt,
+		}
 	}
-	return monthlySumCacheToModel(&item), nil
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].ID < rates[j].ID
+	})
+	return rates, nil
 }
 
-// PutMonthlySummaryCache は月別集計キャッシュを保存する
-func (c *Client) PutMonthlySummaryCache(ctx context.Context, data *model.MonthData) error {
-	var cats []categorySumItem
-	for _, cs := range data.ByCategory {
-		cats = append(cats, categorySumItem{
-			CategoryID: cs.CategoryID, Category: cs.Category, Amount: cs.Amount, Color: cs.Color,
-		})
-	}
-	item := monthlySumCacheItem{
-		Type:       "monthlySummary",
-		ID:         data.Month,
-		Total:      data.Total,
-		ByCategory: cats,
-		UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
-	}
-	av, err := attributevalue.MarshalMap(item)
+// PutExchangeRate は為替レートを保存する（同じ通貨・適用開始日は上書き）
+func (c *Client) PutExchangeRate(ctx context.Context, r *model.ExchangeRate) error {
+	av, err := attributevalue.MarshalMap(exchangeRateFromModel(r))
 	if err != nil {
-		return fmt.Errorf("monthlySummary のマーシャルに失敗: %w", err)
+		return fmt.Errorf("exchangeRate のマーシャルに失敗: %w", err)
 	}
 	_, err = c.db.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: &c.masterTable,
 		Item:      av,
 	})
 	if err != nil {
-		return fmt.Errorf("monthlySummary の保存に失敗: %w", err)
+		return fmt.Errorf("exchangeRate の保存に失敗: %w", err)
 	}
 	return nil
 }
 
-// BatchGetMonthlySummaryCache は複数月の集計キャッシュを一括取得する
-func (c *Client) BatchGetMonthlySummaryCache(ctx context.Context, months []string) (map[string]*model.MonthData, error) {
-	if len(months) == 0 {
-		return make(map[string]*model.MonthData), nil
-	}
-	keys := make([]map[string]types.AttributeValue, len(months))
-	for i, m := range months {
-		keys[i] = map[string]types.AttributeValue{
-			"type": &types.AttributeValueMemberS{Value: "monthlySummary"},
-			"id":   &types.AttributeValueMemberS{Value: m},
+// BatchPutExchangeRates は為替レートを一括保存する
+func (c *Client) BatchPutExchangeRates(ctx context.Context, rates []model.ExchangeRate) error {
+	requests := make([]types.WriteRequest, 0, len(rates))
+	for i := range rates {
+		av, err := attributevalue.MarshalMap(exchangeRateFromModel(&rates[i]))
+		if err != nil {
+			return fmt.Errorf("exchangeRate のマーシャルに失敗: %w", err)
 		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: av}})
 	}
-	out, err := c.db.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
-		RequestItems: map[string]types.KeysAndAttributes{
-			c.masterTable: {Keys: keys},
+	return c.batchWriteMaster(ctx, requests)
+}
+
+// DeleteExchangeRate は為替レートを削除する
+func (c *Client) DeleteExchangeRate(ctx context.Context, id string) error {
+	_, err := c.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &c.masterTable,
+		Key: map[string]types.AttributeValue{
+			"type": &types.AttributeValueMemberS{Value: "exchangeRate"},
+			"id":   &types.AttributeValueMemberS{Value: id},
 		},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("monthlySummary の一括取得に失敗: %w", err)
+		return fmt.Errorf("exchangeRate の削除に失敗: %w", err)
 	}
-	result := make(map[string]*model.MonthData)
-	for _, raw := range out.Responses[c.masterTable] {
-		var item monthlySumCacheItem
-		if err := attributevalue.UnmarshalMap(raw, &item); err != nil {
-			continue
+	return nil
+}
+
+// --- 月別集計キャッシュ ---
+
+// summaryCacheItem は DynamoDB 内の月別集計キャッシュ（type=monthlySummary, id=YYYY-MM#開始日）
+type summaryCacheItem struct {
+	Type      string                               `dynamodbav:"type"`
+	ID        string                               `dynamodbav:"id"`
+	Month     string                               `dynamodbav:"month,omitempty"`
+	StartDay  int                                  `dynamodbav:"startDay,omitempty"`
+	Shared    map[string]map[string]int            `dynamodbav:"shared,omitempty"`
+	Private   map[string]map[string]map[string]int `dynamodbav:"private,omitempty"`
+	Version   int                                  `dynamodbav:"version"`
+	Valid     bool                                 `dynamodbav:"valid"`
+	UpdatedAt string                               `dynamodbav:"updatedAt,omitempty"`
+}
+
+// summaryCacheID は月別集計キャッシュの ID を返す
+func summaryCacheID(month string, startDay int) string {
+	return fmt.Sprintf("%s#%d", month, startDay)
+}
+
+func (item *summaryCacheItem) toModel() *model.SummaryCache {
+	cache := &model.SummaryCache{
+		Month: item.Month, StartDay: item.StartDay, Shared: item.Shared,
+		Private: make(map[string]model.SummaryAmounts, len(item.Private)),
+		Version: item.Version, Valid: item.Valid, UpdatedAt: item.UpdatedAt,
+	}
+	for email, amounts := range item.Private {
+		cache.Private[email] = amounts
+	}
+	return cache
+}
+
+// BatchGetSummaryCaches は複数月の集計キャッシュを一括取得する（月→キャッシュ。未作成の月は含まない）
+func (c *Client) BatchGetSummaryCaches(ctx context.Context, months []string, startDay int) (map[string]*model.SummaryCache, error) {
+	result := make(map[string]*model.SummaryCache, len(months))
+	for i := 0; i < len(months); i += 100 {
+		end := min(i+100, len(months))
+		keys := make([]map[string]types.AttributeValue, 0, end-i)
+		for _, m := range months[i:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"type": &types.AttributeValueMemberS{Value: "monthlySummary"},
+				"id":   &types.AttributeValueMemberS{Value: summaryCacheID(m, startDay)},
+			})
+		}
+		pending := map[string]types.KeysAndAttributes{c.masterTable: {Keys: keys}}
+		for attempt := 0; len(pending[c.masterTable].Keys) > 0; attempt++ {
+			if attempt >= 5 {
+				return nil, fmt.Errorf("monthlySummary の未処理のキーが残っています: %d件", len(pending[c.masterTable].Keys))
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(attempt*200) * time.Millisecond)
+			}
+			out, err := c.db.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: pending})
+			if err != nil {
+				return nil, fmt.Errorf("monthlySummary の一括取得に失敗: %w", err)
+			}
+			for _, raw := range out.Responses[c.masterTable] {
+				var item summaryCacheItem
+				if err := attributevalue.UnmarshalMap(raw, &item); err != nil {
+					return nil, fmt.Errorf("monthlySummary のアンマーシャルに失敗: %w", err)
+				}
+				result[item.Month] = item.toModel()
+			}
+			pending = out.UnprocessedKeys
 		}
-		result[item.ID] = monthlySumCacheToModel(&item)
 	}
 	return result, nil
 }
 
-func monthlySumCacheToModel(item *monthlySumCacheItem) *model.MonthData {
-	data := &model.MonthData{
-		Month: item.ID,
-		Total: item.Total,
+// PutSummaryCache は月別集計キャッシュを保存する。
+// 集計を始めた時点の版（cache.Version）から無効化されていた場合は保存せず false を返す。
+func (c *Client) PutSummaryCache(ctx context.Context, cache *model.SummaryCache) (bool, error) {
+	item := summaryCacheItem{
+		Type:      "monthlySummary",
+		ID:        summaryCacheID(cache.Month, cache.StartDay),
+		Month:     cache.Month,
+		StartDay:  cache.StartDay,
+		Shared:    cache.Shared,
+		Private:   make(map[string]map[string]map[string]int, len(cache.Private)),
+		Version:   cache.Version,
+		Valid:     true,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	for email, amounts := range cache.Private {
+		item.Private[email] = amounts
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return false, fmt.Errorf("monthlySummary のマーシャルに失敗: %w", err)
+	}
+	_, err = c.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &c.masterTable,
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(id) OR version = :v"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":v": &types.AttributeValueMemberN{Value: strconv.Itoa(cache.Version)},
+		},
+	})
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("monthlySummary の保存に失敗: %w", err)
+	}
+	return true, nil
+}
+
+// InvalidateSummaryCache は月別集計キャッシュを無効化する（集計を消して版を 1 つ進める）
+func (c *Client) InvalidateSummaryCache(ctx context.Context, month string, startDay int) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.masterTable,
+		Key: map[string]types.AttributeValue{
+			"type": &types.AttributeValueMemberS{Value: "monthlySummary"},
+			"id":   &types.AttributeValueMemberS{Value: summaryCacheID(month, startDay)},
+		},
+		UpdateExpression: aws.String("SET #month = :m, startDay = :d, valid = :f, updatedAt = :now ADD version :one REMOVE shared, #private"),
+		ExpressionAttributeNames: map[string]string{
+			"#month":   "month",
+			"#private": "private",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":m":   &types.AttributeValueMemberS{Value: month},
+			":d":   &types.AttributeValueMemberN{Value: strconv.Itoa(startDay)},
+			":f":   &types.AttributeValueMemberBOOL{Value: false},
+			":now": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("monthlySummary の無効化に失敗: %w", err)
 	}
-	for _, c := range item.ByCategory {
-		data.ByCategory = append(data.ByCategory, model.CategorySummary{
-			CategoryID: c.CategoryID, Category: c.Category, Amount: c.Amount, Color: c.Color,
+	return nil
+}
+
+// InvalidateAllSummaryCaches は月別集計キャッシュをすべて無効化する（月の開始日の変更・移行時）。
+// 削除すると版が 0 に戻り集計中の古い結果を保存できてしまうため、各月の版を進める。
+func (c *Client) InvalidateAllSummaryCaches(ctx context.Context) (int, error) {
+	var items []summaryCacheItem
+	var lastKey map[string]types.AttributeValue
+	for {
+		out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:              &c.masterTable,
+			KeyConditionExpression: aws.String("#t = :t"),
+			ProjectionExpression:   aws.String("#month, startDay"),
+			ExpressionAttributeNames: map[string]string{
+				"#t":     "type",
+				"#month": "month",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":t": &types.AttributeValueMemberS{Value: "monthlySummary"},
+			},
+			ExclusiveStartKey: lastKey,
 		})
+		if err != nil {
+			return 0, fmt.Errorf("monthlySummary のクエリに失敗: %w", err)
+		}
+		var page []summaryCacheItem
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return 0, fmt.Errorf("monthlySummary のアンマーシャルに失敗: %w", err)
+		}
+		items = append(items, page...)
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		lastKey = out.LastEvaluatedKey
+	}
+	for _, item := range items {
+		if err := c.InvalidateSummaryCache(ctx, item.Month, item.StartDay); err != nil {
+			return 0, err
+		}
 	}
-	return data
+	return len(items), nil
 }
 
 // PutMasterItem は master テーブルにアイテムを保存する（移行用）
@@ -887,3 +1502,427 @@ func (c *Client) BatchPutMasterItems(ctx context.Context, items []map[string]int
 	}
 	return count, nil
 }
+
+// --- 残高スナップショット ---
+
+// balanceSnapshotItem は DynamoDB 内の支払元別・月別の残高スナップショット
+type balanceSnapshotItem struct {
+	Type             string `dynamodbav:"type"`
+	ID               string `dynamodbav:"id"` // "<payer>#<YYYY-MM>"
+	Payer            string `dynamodbav:"payer"`
+	Month            string `dynamodbav:"month"`
+	Carryover        int    `dynamodbav:"carryover"`
+	MonthCharge      int    `dynamodbav:"monthCharge"`
+	MonthTransferIn  int    `dynamodbav:"monthTransferIn"`
+	MonthTransferOut int    `dynamodbav:"monthTransferOut"`
+	MonthSpent       int    `dynamodbav:"monthSpent"`
+	MonthAdjustment  int    `dynamodbav:"monthAdjustment"`
+	Balance          int    `dynamodbav:"balance"`
+	UpdatedAt        string `dynamodbav:"updatedAt"`
+}
+
+func balanceSnapshotID(payer string, month string) string {
+	return payer + "#" + month
+}
+
+func (item *balanceSnapshotItem) toModel() model.PayerBalance {
+	return model.PayerBalance{
+		Payer:            item.Payer,
+		Month:            item.Month,
+		Carryover:        item.Carryover,
+		MonthCharge:      item.MonthCharge,
+		MonthTransferIn:  item.MonthTransferIn,
+		MonthTransferOut: item.MonthTransferOut,
+		MonthSpent:       item.MonthSpent,
+		MonthAdjustment:  item.MonthAdjustment,
+		Balance:          item.Balance,
+	}
+}
+
+// GetBalanceSnapshots は指定支払元の残高スナップショットを月の昇順で返す
+func (c *Client) GetBalanceSnapshots(ctx context.Context, payer string) ([]model.PayerBalance, error) {
+	var items []map[string]types.AttributeValue
+	var lastKey map[string]types.AttributeValue
+	for {
+		out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:              &c.masterTable,
+			KeyConditionExpression: aws.String("#t = :t AND begins_with(id, :prefix)"),
+			ExpressionAttributeNames: map[string]string{
+				"#t": "type",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":t":      &types.AttributeValueMemberS{Value: "balanceSnapshot"},
+				":prefix": &types.AttributeValueMemberS{Value: payer + "#"},
+			},
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("balanceSnapshot のクエリに失敗: %w", err)
+		}
+		items = append(items, out.Items...)
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		lastKey = out.LastEvaluatedKey
+	}
+
+	var dbItems []balanceSnapshotItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &dbItems); err != nil {
+		return nil, fmt.Errorf("balanceSnapshot のアンマーシャルに失敗: %w", err)
+	}
+	result := make([]model.PayerBalance, 0, len(dbItems))
+	for _, item := range dbItems {
+		// 支払元名の前方一致で他の支払元が混ざらないよう完全一致で絞る
+		if item.Payer == payer {
+			result = append(result, item.toModel())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Month < result[j].Month
+	})
+	return result, nil
+}
+
+// GetAllBalanceSnapshotKeys は全残高スナップショットの (payer, month) を返す（再構築時の削除用）
+func (c *Client) GetAllBalanceSnapshotKeys(ctx context.Context) ([]model.PayerBalance, error) {
+	items, err := c.queryMaster(ctx, "balanceSnapshot")
+	if err != nil {
+		return nil, err
+	}
+	var dbItems []balanceSnapshotItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &dbItems); err != nil {
+		return nil, fmt.Errorf("balanceSnapshot のアンマーシャルに失敗: %w", err)
+	}
+	result := make([]model.PayerBalance, len(dbItems))
+	for i, item := range dbItems {
+		result[i] = model.PayerBalance{Payer: item.Payer, Month: item.Month}
+	}
+	return result, nil
+}
+
+// BatchPutBalanceSnapshots は残高スナップショットを最大25件ずつ BatchWriteItem で保存する
+func (c *Client) BatchPutBalanceSnapshots(ctx context.Context, snapshots []model.PayerBalance) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var requests []types.WriteRequest
+	for _, b := range snapshots {
+		item := balanceSnapshotItem{
+			Type:             "balanceSnapshot",
+			ID:               balanceSnapshotID(b.Payer, b.Month),
+			Payer:            b.Payer,
+			Month:            b.Month,
+			Carryover:        b.Carryover,
+			MonthCharge:      b.MonthCharge,
+			MonthTransferIn:  b.MonthTransferIn,
+			MonthTransferOut: b.MonthTransferOut,
+			MonthSpent:       b.MonthSpent,
+			MonthAdjustment:  b.MonthAdjustment,
+			Balance:          b.Balance,
+			UpdatedAt:        now,
+		}
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return fmt.Errorf("balanceSnapshot のマーシャルに失敗: %w", err)
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: av},
+		})
+	}
+	if err := c.batchWriteMaster(ctx, requests); err != nil {
+		return fmt.Errorf("balanceSnapshot の一括保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// BatchDeleteBalanceSnapshots は残高スナップショットを最大25件ずつ BatchWriteItem で削除する
+func (c *Client) BatchDeleteBalanceSnapshots(ctx context.Context, keys []model.PayerBalance) error {
+	var requests []types.WriteRequest
+	for _, k := range keys {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+				"type": &types.AttributeValueMemberS{Value: "balanceSnapshot"},
+				"id":   &types.AttributeValueMemberS{Value: balanceSnapshotID(k.Payer, k.Month)},
+			}},
+		})
+	}
+	if err := c.batchWriteMaster(ctx, requests); err != nil {
+		return fmt.Errorf("balanceSnapshot の一括削除に失敗: %w", err)
+	}
+	return nil
+}
+
+// balanceDirtyItem は残高スナップショットの再計算待ちの月（type=balanceDirty, id=YYYY-MM）
+type balanceDirtyItem struct {
+	Type      string `dynamodbav:"type"`
+	ID        string `dynamodbav:"id"`
+	Version   int    `dynamodbav:"version"`
+	UpdatedAt string `dynamodbav:"updatedAt"`
+}
+
+// MarkBalanceMonthDirty は月の残高スナップショットを再計算待ちにする（記録の変更ごとに版を 1 つ進める）
+func (c *Client) MarkBalanceMonthDirty(ctx context.Context, month string) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.masterTable,
+		Key: map[string]types.AttributeValue{
+			"type": &types.AttributeValueMemberS{Value: "balanceDirty"},
+			"id":   &types.AttributeValueMemberS{Value: month},
+		},
+		UpdateExpression: aws.String("SET updatedAt = :now ADD version :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("balanceDirty の保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// GetDirtyBalanceMonths は再計算待ちの月と版を返す（月→版）
+func (c *Client) GetDirtyBalanceMonths(ctx context.Context) (map[string]int, error) {
+	items, err := c.queryMaster(ctx, "balanceDirty")
+	if err != nil {
+		return nil, err
+	}
+	var dbItems []balanceDirtyItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &dbItems); err != nil {
+		return nil, fmt.Errorf("balanceDirty のアンマーシャルに失敗: %w", err)
+	}
+	result := make(map[string]int, len(dbItems))
+	for _, item := range dbItems {
+		result[item.ID] = item.Version
+	}
+	return result, nil
+}
+
+// ClearBalanceMonthDirty は月の再計算待ちを解除する。
+// 再計算中に記録が変わって版が進んでいた場合は解除しない（次の読み出しで再計算する）。
+func (c *Client) ClearBalanceMonthDirty(ctx context.Context, month string, version int) error {
+	_, err := c.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &c.masterTable,
+		Key: map[string]types.AttributeValue{
+			"type": &types.AttributeValueMemberS{Value: "balanceDirty"},
+			"id":   &types.AttributeValueMemberS{Value: month},
+		},
+		ConditionExpression: aws.String("version = :v"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":v": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+		},
+	})
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("balanceDirty の削除に失敗: %w", err)
+	}
+	return nil
+}
+
+// batchWriteMaster は master テーブルへの書き込みリクエストを25件ずつ実行する（未処理分は再試行）
+func (c *Client) batchWriteMaster(ctx context.Context, requests []types.WriteRequest) error {
+	for i := 0; i < len(requests); i += 25 {
+		end := i + 25
+		if end > len(requests) {
+			end = len(requests)
+		}
+		pending := map[string][]types.WriteRequest{c.masterTable: requests[i:end]}
+		for attempt := 0; len(pending[c.masterTable]) > 0; attempt++ {
+			if attempt >= 5 {
+				return fmt.Errorf("未処理のリクエストが残っています: %d件", len(pending[c.masterTable]))
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(attempt*200) * time.Millisecond)
+			}
+			out, err := c.db.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+			if err != nil {
+				return err
+			}
+			pending = out.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+// --- DynamoDB Streams ---
+
+// ExpenseFromStreamImage は DynamoDB Streams のイメージ（NewImage / OldImage）を支出に変換する（空のイメージは nil）
+func ExpenseFromStreamImage(image map[string]events.DynamoDBAttributeValue) (*model.Expense, error) {
+	if len(image) == 0 {
+		return nil, nil
+	}
+	av := make(map[string]types.AttributeValue, len(image))
+	for name, v := range image {
+		converted, err := streamAttributeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("stream image の %s の変換に失敗: %w", name, err)
+		}
+		av[name] = converted
+	}
+	var item expenseItem
+	if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+		return nil, fmt.Errorf("expense のアンマーシャルに失敗: %w", err)
+	}
+	e := item.toModel()
+	return &e, nil
+}
+
+// streamAttributeValue は Lambda イベントの属性値を SDK の属性値に変換する
+func streamAttributeValue(v events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+	switch v.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: v.String()}, nil
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: v.Number()}, nil
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: v.Binary()}, nil
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: v.Boolean()}, nil
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: v.StringSet()}, nil
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: v.NumberSet()}, nil
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: v.BinarySet()}, nil
+	case events.DataTypeList:
+		list := v.List()
+		values := make([]types.AttributeValue, len(list))
+		for i, elem := range list {
+			converted, err := streamAttributeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = converted
+		}
+		return &types.AttributeValueMemberL{Value: values}, nil
+	case events.DataTypeMap:
+		values := make(map[string]types.AttributeValue, len(v.Map()))
+		for name, elem := range v.Map() {
+			converted, err := streamAttributeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			values[name] = converted
+		}
+		return &types.AttributeValueMemberM{Value: values}, nil
+	}
+	return nil, fmt.Errorf("未対応の属性型: %v", v.DataType())
+}
+
+// --- 変更履歴 ---
+
+// auditItem は master テーブルの変更履歴アイテム（type=audit、id=変更時刻#イベントID で時刻順）
+type auditItem struct {
+	Type       string   `dynamodbav:"type"`
+	ID         string   `dynamodbav:"id"`
+	Time       string   `dynamodbav:"time"`
+	Action     string   `dynamodbav:"action"`
+	ExpenseID  string   `dynamodbav:"expenseId"`
+	Date       string   `dynamodbav:"date"`
+	Payer      string   `dynamodbav:"payer"`
+	Category   string   `dynamodbav:"category,omitempty"`
+	Amount     int      `dynamodbav:"amount"`
+	Visibility string   `dynamodbav:"visibility,omitempty"`
+	CreatedBy  string   `dynamodbav:"createdBy,omitempty"`
+	Changes    []string `dynamodbav:"changes,omitempty"`
+	OldDate    string   `dynamodbav:"oldDate,omitempty"`
+	OldAmount  *int     `dynamodbav:"oldAmount,omitempty"`
+}
+
+// AuditID は変更履歴の ID（変更時刻#イベントID）。同じイベントの再処理は同じアイテムを上書きする
+func AuditID(t string, eventID string) string {
+	return t + "#" + eventID
+}
+
+func (item *auditItem) toModel() model.AuditEntry {
+	return model.AuditEntry{
+		ID:         item.ID,
+		Time:       item.Time,
+		Action:     item.Action,
+		ExpenseID:  item.ExpenseID,
+		Date:       item.Date,
+		Payer:      item.Payer,
+		Category:   item.Category,
+		Amount:     item.Amount,
+		Visibility: item.Visibility,
+		CreatedBy:  item.CreatedBy,
+		Changes:    item.Changes,
+		OldDate:    item.OldDate,
+		OldAmount:  item.OldAmount,
+	}
+}
+
+// BatchPutAuditEntries は変更履歴を最大25件ずつ BatchWriteItem で保存する
+func (c *Client) BatchPutAuditEntries(ctx context.Context, entries []model.AuditEntry) error {
+	var requests []types.WriteRequest
+	for _, e := range entries {
+		item := auditItem{
+			Type:       "audit",
+			ID:         e.ID,
+			Time:       e.Time,
+			Action:     e.Action,
+			ExpenseID:  e.ExpenseID,
+			Date:       e.Date,
+			Payer:      e.Payer,
+			Category:   e.Category,
+			Amount:     e.Amount,
+			Visibility: e.Visibility,
+			CreatedBy:  e.CreatedBy,
+			Changes:    e.Changes,
+			OldDate:    e.OldDate,
+			OldAmount:  e.OldAmount,
+		}
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return fmt.Errorf("audit のマーシャルに失敗: %w", err)
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: av},
+		})
+	}
+	if err := c.batchWriteMaster(ctx, requests); err != nil {
+		return fmt.Errorf("audit の一括保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// QueryAuditEntries は変更時刻（UTC）が指定月の変更履歴を新しい順に返す
+func (c *Client) QueryAuditEntries(ctx context.Context, month string) ([]model.AuditEntry, error) {
+	var items []map[string]types.AttributeValue
+	var lastKey map[string]types.AttributeValue
+	for {
+		out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:              &c.masterTable,
+			KeyConditionExpression: aws.String("#t = :t AND begins_with(id, :prefix)"),
+			ExpressionAttributeNames: map[string]string{
+				"#t": "type",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":t":      &types.AttributeValueMemberS{Value: "audit"},
+				":prefix": &types.AttributeValueMemberS{Value: month},
+			},
+			ScanIndexForward:  aws.Bool(false),
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("audit のクエリに失敗: %w", err)
+		}
+		items = append(items, out.Items...)
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		lastKey = out.LastEvaluatedKey
+	}
+
+	var dbItems []auditItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &dbItems); err != nil {
+		return nil, fmt.Errorf("audit のアンマーシャルに失敗: %w", err)
+	}
+	result := make([]model.AuditEntry, 0, len(dbItems))
+	for _, item := range dbItems {
+		result = append(result, item.toModel())
+	}
+	return result, nil
+}
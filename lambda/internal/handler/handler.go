@@ -6,12 +6,14 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 
 	"money-diary/internal/apperror"
 	"money-diary/internal/auth"
 	"money-diary/internal/backup"
+	"money-diary/internal/blob"
 	"money-diary/internal/dynamo"
 	"money-diary/internal/model"
 	"money-diary/internal/service"
@@ -139,7 +141,76 @@ func handleAction(ctx context.Context, client *dynamo.Client, req *model.ActionR
 		if req.Month == "" {
 			return nil, apperror.New("month は必須です")
 		}
-		return service.GetExpensesByMonth(ctx, client, req.Month, userEmail)
+		return service.GetExpensesByMonth(ctx, client, req.Month, req.Tags, userEmail)
+
+	case "searchExpenses":
+		if req.From == "" || req.To == "" {
+			return nil, apperror.New("from, to は必須です")
+		}
+		return service.SearchExpenses(ctx, client, req.From, req.To, req.Query, req.Tags, userEmail)
+
+	case "getTagSummary":
+		from, to := req.From, req.To
+		if from == "" || to == "" {
+			if req.Month == "" {
+				return nil, apperror.New("month または from, to は必須です")
+			}
+			from, to = req.Month, req.Month
+		}
+		return service.GetTagSummary(ctx, client, from, to, userEmail, req.Calendar)
+
+	case "getTaxSummary":
+		from, to := req.From, req.To
+		if from == "" || to == "" {
+			if req.Month == "" {
+				return nil, apperror.New("month または from, to は必須です")
+			}
+			from, to = req.Month, req.Month
+		}
+		return service.GetTaxSummary(ctx, client, from, to, userEmail, req.Calendar)
+
+	case "getPivot":
+		from, to := req.From, req.To
+		if from == "" || to == "" {
+			if req.Month == "" {
+				return nil, apperror.New("month または from, to は必須です")
+			}
+			from, to = req.Month, req.Month
+		}
+		if len(req.GroupBy) == 0 {
+			return nil, apperror.New("groupBy は必須です")
+		}
+		return service.GetPivot(ctx, client, from, to, req.GroupBy, req.Filters, userEmail, req.Calendar)
+
+	case "getInsights":
+		return service.GetInsights(ctx, client, req.Date, userEmail)
+
+	case "getDailyTotals":
+		return service.GetDailyTotals(ctx, client, req.Month, req.Year, userEmail)
+
+	case "getBusinessReport":
+		if req.Year == "" {
+			return nil, apperror.New("year は必須です")
+		}
+		return service.GetBusinessReport(ctx, client, req.Year, userEmail)
+
+	case "exportBusinessReport":
+		if req.Year == "" {
+			return nil, apperror.New("year は必須です")
+		}
+		return service.ExportBusinessReport(ctx, client, req.Year, userEmail)
+
+	case "getMedicalReport":
+		if req.Year == "" {
+			return nil, apperror.New("year は必須です")
+		}
+		return service.GetMedicalReport(ctx, client, req.Year, req.Income, userEmail)
+
+	case "exportMedicalReport":
+		if req.Year == "" {
+			return nil, apperror.New("year は必須です")
+		}
+		return service.ExportMedicalReport(ctx, client, req.Year, req.Income, userEmail)
 
 	case "createExpense":
 		if req.Expense == nil {
@@ -162,23 +233,103 @@ func handleAction(ctx context.Context, client *dynamo.Client, req *model.ActionR
 		}
 		return service.BulkCreateExpenses(ctx, client, req.Expenses, userEmail)
 
+	case "createRefund":
+		if req.Refund == nil {
+			return nil, apperror.New("refund は必須です")
+		}
+		return service.CreateRefund(ctx, client, req.Refund, userEmail)
+
 	case "deleteExpense":
 		if req.ID == "" {
 			return nil, apperror.New("id は必須です")
 		}
-		return nil, service.DeleteExpense(ctx, client, req.ID)
+		store, err := blob.NewStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, service.DeleteExpense(ctx, client, store, req.ID)
+
+	case "uploadAttachment":
+		if req.Attachment == nil {
+			return nil, apperror.New("attachment は必須です")
+		}
+		store, err := blob.NewStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return service.UploadAttachment(ctx, client, store, req.Attachment, userEmail)
+
+	case "confirmAttachment":
+		if req.Attachment == nil || req.ID == "" {
+			return nil, apperror.New("attachment, id は必須です")
+		}
+		store, err := blob.NewStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return service.ConfirmAttachment(ctx, client, store, req.ID, req.Attachment, userEmail)
+
+	case "getAttachments":
+		if req.ExpenseID == "" {
+			return nil, apperror.New("expenseId は必須です")
+		}
+		return service.GetAttachments(ctx, client, req.ExpenseID, userEmail)
+
+	case "getAttachment":
+		if req.ExpenseID == "" || req.ID == "" {
+			return nil, apperror.New("expenseId, id は必須です")
+		}
+		store, err := blob.NewStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return service.GetAttachment(ctx, client, store, req.ExpenseID, req.ID, userEmail)
+
+	case "deleteAttachment":
+		if req.ExpenseID == "" || req.ID == "" {
+			return nil, apperror.New("expenseId, id は必須です")
+		}
+		store, err := blob.NewStore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, service.DeleteAttachment(ctx, client, store, req.ExpenseID, req.ID, userEmail)
 
 	case "getMonthlySummary":
 		if req.Month == "" {
 			return nil, apperror.New("month は必須です")
 		}
-		return service.GetMonthlySummary(ctx, client, req.Month, req.Payer, userEmail)
+		return service.GetMonthlySummary(ctx, client, req.Month, req.Payer, userEmail, req.Calendar)
+
+	case "getWeeklySummary":
+		if req.Date == "" {
+			return nil, apperror.New("date は必須です")
+		}
+		return service.GetWeeklySummary(ctx, client, req.Date, req.Weeks, req.Payer, userEmail)
+
+	case "getMemberSummary":
+		if req.Month == "" {
+			return nil, apperror.New("month は必須です")
+		}
+		return service.GetMemberSummary(ctx, client, req.Month, userEmail, req.Calendar)
 
 	case "getYearlySummary":
 		if req.Month == "" {
 			return nil, apperror.New("month は必須です")
 		}
-		return service.GetYearlySummary(ctx, client, req.Month, req.Payer, userEmail)
+		return service.GetYearlySummary(ctx, client, req.Month, req.Payer, userEmail, req.Calendar)
+
+	case "getAnnualReport":
+		if req.Year == "" {
+			return nil, apperror.New("year は必須です")
+		}
+		return service.GetAnnualReport(ctx, client, req.Year, req.Fiscal, req.Payer, userEmail, req.Calendar)
+
+	case "comparePeriods":
+		if req.From == "" || req.To == "" {
+			return nil, apperror.New("from, to は必須です")
+		}
+		return service.ComparePeriods(ctx, client, req.From, req.To, req.CompareFrom, req.CompareTo, req.Payer, userEmail, req.Calendar)
 
 	case "getPayerBalance":
 		if req.Payer == "" {
@@ -189,6 +340,74 @@ func handleAction(ctx context.Context, client *dynamo.Client, req *model.ActionR
 		}
 		return service.GetPayerBalance(ctx, client, req.Payer, req.Month)
 
+	case "getPayerBalanceHistory":
+		if req.Payer == "" {
+			return nil, apperror.New("payer は必須です")
+		}
+		if req.From == "" || req.To == "" {
+			return nil, apperror.New("from, to は必須です")
+		}
+		return service.GetPayerBalanceHistory(ctx, client, req.Payer, req.From, req.To)
+
+	case "getCardStatement":
+		if req.Payer == "" {
+			return nil, apperror.New("payer は必須です")
+		}
+		from, to := req.From, req.To
+		if from == "" || to == "" {
+			if req.Month == "" {
+				return nil, apperror.New("month または from, to は必須です")
+			}
+			from, to = req.Month, req.Month
+		}
+		return service.GetCardStatements(ctx, client, req.Payer, from, to, userEmail)
+
+	case "reconcilePayer":
+		if req.Reconcile == nil {
+			return nil, apperror.New("reconcile は必須です")
+		}
+		return service.ReconcilePayer(ctx, client, req.Reconcile, userEmail)
+
+	case "getSettlement":
+		from, to := req.From, req.To
+		if from == "" || to == "" {
+			if req.Month == "" {
+				return nil, apperror.New("month または from, to は必須です")
+			}
+			from, to = req.Month, req.Month
+		}
+		return service.GetSettlement(ctx, client, from, to)
+
+	case "recordSettlement":
+		if req.Settlement == nil {
+			return nil, apperror.New("settlement は必須です")
+		}
+		return service.RecordSettlement(ctx, client, req.Settlement, userEmail)
+
+	case "getMembers":
+		return service.GetMembers(ctx, client)
+
+	case "updateDisplayName":
+		return service.UpdateDisplayName(ctx, client, userEmail, req.Name)
+
+	case "getAuditLog":
+		if req.Month == "" {
+			return nil, apperror.New("month は必須です")
+		}
+		if err := service.RequireAdmin(ctx, client, userEmail); err != nil {
+			return nil, err
+		}
+		return service.GetAuditLog(ctx, client, req.Month, userEmail)
+
+	case "rebuildSummaryCache":
+		if req.From == "" || req.To == "" {
+			return nil, apperror.New("from, to は必須です")
+		}
+		if err := service.RequireAdmin(ctx, client, userEmail); err != nil {
+			return nil, err
+		}
+		return service.RebuildSummaryCaches(ctx, client, req.From, req.To, req.Verify)
+
 	case "getMyRole":
 		role, err := service.GetUserRole(ctx, client, userEmail)
 		if err != nil {
@@ -301,6 +520,40 @@ func handleAction(ctx context.Context, client *dynamo.Client, req *model.ActionR
 		}
 		return nil, service.DeletePayer(ctx, client, req.ID)
 
+	case "getSettings":
+		return service.GetHouseholdSettings(ctx, client)
+
+	case "updateSettings":
+		if req.Settings == nil {
+			return nil, apperror.New("settings は必須です")
+		}
+		return service.UpdateHouseholdSettings(ctx, client, req.Settings)
+
+	case "getExchangeRates":
+		return service.GetExchangeRates(ctx, client, req.Currency)
+
+	case "putExchangeRate":
+		if req.ExchangeRate == nil {
+			return nil, apperror.New("exchangeRate は必須です")
+		}
+		return service.PutExchangeRate(ctx, client, req.ExchangeRate)
+
+	case "importExchangeRates":
+		if len(req.ExchangeRates) == 0 {
+			return nil, apperror.New("exchangeRates は必須です")
+		}
+		count, err := service.ImportExchangeRates(ctx, client, req.ExchangeRates)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int{"imported": count}, nil
+
+	case "deleteExchangeRate":
+		if req.ID == "" {
+			return nil, apperror.New("id は必須です")
+		}
+		return nil, service.DeleteExchangeRate(ctx, client, req.ID)
+
 	default:
 		return nil, apperror.Newf("不明なアクション: %s", req.Action)
 	}
@@ -336,6 +589,49 @@ func HandleBackup(ctx context.Context) (any, error) {
 	return map[string]string{"status": "ok"}, nil
 }
 
+// HandleExpenseStream は expenses テーブルの DynamoDB Streams から呼ばれ、
+// 変更履歴・集計キャッシュ・残高スナップショットを更新する（エラー時はバッチごと再試行される）
+func HandleExpenseStream(ctx context.Context, event events.DynamoDBEvent) (any, error) {
+	client, err := dynamo.NewClient(ctx)
+	if err != nil {
+		log.Printf("DynamoDB client error: %v", err)
+		return nil, err
+	}
+	changes := make([]model.ExpenseChange, 0, len(event.Records))
+	for _, record := range event.Records {
+		change, err := expenseChangeFromRecord(record)
+		if err != nil {
+			// 変換できないレコードは再試行しても失敗するため読み飛ばす
+			log.Printf("[SKIP] stream record %s: %v", record.EventID, err)
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if err := service.ProcessExpenseChanges(ctx, client, changes); err != nil {
+		log.Printf("ProcessExpenseChanges error: %v", err)
+		return nil, err
+	}
+	return map[string]int{"processed": len(changes)}, nil
+}
+
+// expenseChangeFromRecord は DynamoDB Streams のレコードを支出の変更に変換する
+func expenseChangeFromRecord(record events.DynamoDBEventRecord) (model.ExpenseChange, error) {
+	oldExpense, err := dynamo.ExpenseFromStreamImage(record.Change.OldImage)
+	if err != nil {
+		return model.ExpenseChange{}, err
+	}
+	newExpense, err := dynamo.ExpenseFromStreamImage(record.Change.NewImage)
+	if err != nil {
+		return model.ExpenseChange{}, err
+	}
+	return model.ExpenseChange{
+		EventID: record.EventID,
+		Time:    record.Change.ApproximateCreationDateTime.UTC().Format(time.RFC3339),
+		Old:     oldExpense,
+		New:     newExpense,
+	}, nil
+}
+
 func init() {
 	// 環境変数チェック
 	required := []string{"DYNAMO_EXPENSE_TABLE", "DYNAMO_MASTER_TABLE", "GOOGLE_CLIENT_ID"}
@@ -2,28 +2,131 @@ package model
 
 // Expense は支出データ
 type Expense struct {
-	ID         string `json:"id"`
-	Date       string `json:"date"`
-	Payer      string `json:"payer"`
-	Category   string `json:"category"`
-	Amount     int    `json:"amount"`
-	Memo       string `json:"memo"`
-	Place      string `json:"place"`
-	Visibility string `json:"visibility"` // "public" | "summary" | "private"（空="" は "public" 扱い）
-	CreatedBy  string `json:"createdBy"`
-	CreatedAt  string `json:"createdAt"`
-	UpdatedAt  string `json:"updatedAt"`
+	ID       string `json:"id"`
+	Type     string `json:"type"` // "expense" | "transfer" | "adjustment" | "settlement" | "refund"（空="" は "expense" 扱い）
+	Date     string `json:"date"`
+	Payer    string `json:"payer"`   // transfer の場合は振替元
+	ToPayer  string `json:"toPayer"` // 振替先（transfer のみ）
+	Category string `json:"category"`
+	Amount   int    `json:"amount"` // 基準通貨（円）換算額。集計・残額はこの値を使う
+	// 1 枚のレシートを複数カテゴリに分ける明細（空=Category に全額）。Category は最も金額の大きい明細のカテゴリ
+	Items []LineItem `json:"items,omitempty"`
+	// 消費税の税率別内訳（空=内訳なし）。税込金額の合計は Amount と一致する
+	TaxLines []TaxLine `json:"taxLines,omitempty"`
+	// 外貨建ての場合の元の通貨・金額と換算に使ったレート（基準通貨の場合は空）
+	Currency       string  `json:"currency,omitempty"`       // ISO 4217 通貨コード（例: "USD"）
+	OriginalAmount float64 `json:"originalAmount,omitempty"` // 元の通貨での金額
+	ExchangeRate   float64 `json:"exchangeRate,omitempty"`   // 1 通貨単位あたりの円
+	Memo           string  `json:"memo"`
+	Place          string  `json:"place"`
+	Visibility     string  `json:"visibility"` // "public" | "summary" | "private"（空="" は "public" 扱い）
+	// 世帯内の立替・精算（split が nil の支出は精算対象外）
+	PaidBy string `json:"paidBy,omitempty"` // 立て替えたメンバー（空=createdBy）。settlement の場合は支払ったメンバー
+	PaidTo string `json:"paidTo,omitempty"` // 精算の受取メンバー（settlement のみ）
+	Split  *Split `json:"split,omitempty"`
+	// カテゴリを横断する自由入力のタグ（重複なし・昇順）
+	Tags        []string     `json:"tags,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"` // レシート・請求書などの添付ファイル
+	// 返金（refund の Amount は返金額。元の支出のカテゴリから差し引く）
+	RefundOf       string `json:"refundOf,omitempty"`       // 返金元の支出ID（refund のみ）
+	RefundedAmount int    `json:"refundedAmount,omitempty"` // 返金済みの合計額（返金元の支出のみ）
+	// 返金済み額のカテゴリ別内訳（返金元の支出のみ。明細ごとの返金額の上限に使う）
+	RefundedByCategory map[string]int `json:"refundedByCategory,omitempty"`
+	// 事業割合（%、nil=カテゴリの事業割合）
+	BusinessRatio *int `json:"businessRatio,omitempty"`
+	// 医療費控除（医療費カテゴリの支出のみ）
+	Patient          string `json:"patient,omitempty"`          // 医療を受けた方
+	Provider         string `json:"provider,omitempty"`         // 病院・薬局などの支払先（空=場所）
+	MedicalKind      string `json:"medicalKind,omitempty"`      // "treatment" | "care" | "medicine" | "other"（空="treatment" 扱い）
+	ReimbursedAmount int    `json:"reimbursedAmount,omitempty"` // 保険金などで補填される金額
+	CreatedBy        string `json:"createdBy"`
+	CreatedAt        string `json:"createdAt"`
+	UpdatedAt        string `json:"updatedAt"`
+}
+
+// Split は支出の負担割合
+type Split struct {
+	Method string       `json:"method"` // "equal" | "ratio" | "fixed"
+	Shares []SplitShare `json:"shares"` // equal で空の場合は登録ユーザー全員で均等
+}
+
+// SplitShare はメンバーごとの負担（ratio は比率、fixed は円、equal は未使用）
+type SplitShare struct {
+	Email string `json:"email"`
+	Value int    `json:"value"`
+}
+
+// LineItem は支出の明細（金額の合計が支出の金額と一致する）
+type LineItem struct {
+	Category string `json:"category"`
+	Amount   int    `json:"amount"`
+	Memo     string `json:"memo,omitempty"`
+}
+
+// TaxLine は消費税の税率別内訳（標準税率 10%・軽減税率 8%・非課税 0%）
+type TaxLine struct {
+	Rate   int `json:"rate"`   // 税率（%）
+	Amount int `json:"amount"` // 税込金額
+	Tax    int `json:"tax"`    // うち消費税額
+}
+
+// Attachment は支出の添付ファイル（本体は blob ストアに保存）
+type Attachment struct {
+	ID          string `json:"id"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	UploadedBy  string `json:"uploadedBy"`
+	UploadedAt  string `json:"uploadedAt"`
+}
+
+// AttachmentInput は添付ファイルのアップロードリクエスト。
+// data（base64）指定時は直接アップロード、省略時は署名付き URL を発行する。
+type AttachmentInput struct {
+	ExpenseID   string `json:"expenseId"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Data        string `json:"data"`
+}
+
+// AttachmentUpload は添付ファイルのアップロード結果
+type AttachmentUpload struct {
+	Attachment Attachment `json:"attachment"`
+	UploadURL  string     `json:"uploadUrl,omitempty"` // 署名付き URL（直接アップロード時は空）。Content-Type を指定して PUT する
+}
+
+// AttachmentDownload は添付ファイルの取得結果（署名付き URL を発行できないストアは data に base64 で返す）
+type AttachmentDownload struct {
+	Attachment Attachment `json:"attachment"`
+	URL        string     `json:"url,omitempty"`
+	Data       string     `json:"data,omitempty"`
 }
 
 // ExpenseInput は支出登録・更新のリクエスト
 type ExpenseInput struct {
-	Date       string `json:"date"`
-	Payer      string `json:"payer"`
-	Category   string `json:"category"`
-	Amount     int    `json:"amount"`
-	Memo       string `json:"memo"`
-	Place      string `json:"place"`
-	Visibility string `json:"visibility"`
+	Type             string     `json:"type"`
+	Date             string     `json:"date"`
+	Payer            string     `json:"payer"`
+	ToPayer          string     `json:"toPayer"`
+	Category         string     `json:"category"`
+	Amount           int        `json:"amount"` // 外貨建ての場合は 0 で登録日のレートから換算、明細がある場合は 0 で明細の合計
+	Items            []LineItem `json:"items"`
+	TaxLines         []TaxLine  `json:"taxLines"`
+	TaxExcluded      bool       `json:"taxExcluded"` // true の場合 taxLines の amount は税抜金額（登録時に税込に変換する）
+	Currency         string     `json:"currency"`
+	OriginalAmount   float64    `json:"originalAmount"`
+	Memo             string     `json:"memo"`
+	Place            string     `json:"place"`
+	Visibility       string     `json:"visibility"`
+	PaidBy           string     `json:"paidBy"`
+	Split            *Split     `json:"split"`
+	Tags             []string   `json:"tags"`
+	BusinessRatio    *int       `json:"businessRatio"` // 事業割合（%、null=カテゴリの事業割合）
+	Patient          string     `json:"patient"`
+	Provider         string     `json:"provider"`
+	MedicalKind      string     `json:"medicalKind"`
+	ReimbursedAmount int        `json:"reimbursedAmount"`
 }
 
 // Place は場所マスタ
@@ -36,11 +139,18 @@ type Place struct {
 
 // Payer は支払元マスタ
 type Payer struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	SortOrder    int    `json:"sortOrder"`
-	IsActive     bool   `json:"isActive"`
-	TrackBalance bool   `json:"trackBalance"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	SortOrder      int    `json:"sortOrder"`
+	IsActive       bool   `json:"isActive"`
+	TrackBalance   bool   `json:"trackBalance"`
+	OpeningBalance int    `json:"openingBalance"` // 残高管理開始時点の残高
+	OpeningDate    string `json:"openingDate"`    // 残高管理開始日 "YYYY-MM-DD"（空=全期間）
+	// クレジットカード設定（closingDay=0 はカード以外）
+	ClosingDay         int    `json:"closingDay"`         // 締め日 1-31（月末より大きい場合は月末）
+	PaymentDay         int    `json:"paymentDay"`         // 支払日 1-31（月末より大きい場合は月末）
+	PaymentMonthOffset int    `json:"paymentMonthOffset"` // 締め月から支払月までの月数（0 は翌月払い扱い）
+	PaymentSource      string `json:"paymentSource"`      // 引き落とし元の支払元名
 }
 
 // Category はカテゴリマスタ
@@ -54,24 +164,289 @@ type Category struct {
 	ExcludeFromBreakdown bool   `json:"excludeFromBreakdown"` // 内訳から除外（総額には含む）
 	ExcludeFromSummary   bool   `json:"excludeFromSummary"`   // 集計から完全除外（Balanceのみ表示）
 	OwnerEmail           string `json:"ownerEmail"`           // 空=共有、値あり=個人カテゴリ
+	// 確定申告の事業按分（家賃・通信費など）
+	BusinessRatio  int    `json:"businessRatio"`  // 事業割合（%、0=按分なし）
+	ExpenseAccount string `json:"expenseAccount"` // 勘定科目（空=カテゴリ名）
+	IsMedical      bool   `json:"isMedical"`      // 医療費控除の対象
 }
 
 // PayerBalance は支払元の残額情報（月別）
 type PayerBalance struct {
-	Payer       string `json:"payer"`
-	Carryover   int    `json:"carryover"`   // 前月繰越
-	MonthCharge int    `json:"monthCharge"` // 月内チャージ
-	MonthSpent  int    `json:"monthSpent"`  // 月内支出
-	Balance     int    `json:"balance"`     // 残額
+	Payer            string `json:"payer"`
+	Month            string `json:"month"`
+	Carryover        int    `json:"carryover"`        // 前月繰越
+	MonthCharge      int    `json:"monthCharge"`      // 月内チャージ
+	MonthTransferIn  int    `json:"monthTransferIn"`  // 月内振替入金
+	MonthTransferOut int    `json:"monthTransferOut"` // 月内振替出金
+	MonthSpent       int    `json:"monthSpent"`       // 月内支出
+	MonthAdjustment  int    `json:"monthAdjustment"`  // 月内残高調整（符号付き）
+	Balance          int    `json:"balance"`          // 残額
+}
+
+// PayerBalanceHistory は支払元の残額推移（月別、昇順）
+type PayerBalanceHistory struct {
+	Payer  string         `json:"payer"`
+	Months []PayerBalance `json:"months"`
+}
+
+// CardStatement はクレジットカードの請求（締め期間ごと）
+type CardStatement struct {
+	Payer         string    `json:"payer"`
+	PeriodStart   string    `json:"periodStart"`   // 締め期間の開始日 "YYYY-MM-DD"
+	PeriodEnd     string    `json:"periodEnd"`     // 締め日 "YYYY-MM-DD"
+	PaymentDate   string    `json:"paymentDate"`   // 支払日 "YYYY-MM-DD"
+	PaymentSource string    `json:"paymentSource"` // 引き落とし元
+	Amount        int       `json:"amount"`        // 請求額
+	Count         int       `json:"count"`         // 明細件数
+	Expenses      []Expense `json:"expenses"`      // 明細（visibility フィルタ適用済み）
+}
+
+// ExchangeRate は為替レート（適用開始日以降、次のレートまで有効）
+type ExchangeRate struct {
+	ID        string  `json:"id"` // "<通貨>#<適用開始日>"
+	Currency  string  `json:"currency"`
+	Date      string  `json:"date"` // 適用開始日 "YYYY-MM-DD"
+	Rate      float64 `json:"rate"` // 1 通貨単位あたりの円
+	UpdatedAt string  `json:"updatedAt"`
+}
+
+// ExchangeRateInput は為替レート登録・インポートのリクエスト
+type ExchangeRateInput struct {
+	Currency string  `json:"currency"`
+	Date     string  `json:"date"`
+	Rate     float64 `json:"rate"`
+}
+
+// RefundInput は返金の登録リクエスト（返品・一部返金）
+type RefundInput struct {
+	ExpenseID string `json:"expenseId"` // 返金元の支出ID
+	Date      string `json:"date"`
+	Amount    int    `json:"amount"`   // 返金額（返金元の金額から返金済み額を引いた額まで）
+	Payer     string `json:"payer"`    // 返金先の支払元（空=返金元の支払元）
+	Category  string `json:"category"` // 差し引くカテゴリ（空=返金元のカテゴリ、明細がある場合は明細のカテゴリから選ぶ）
+	Memo      string `json:"memo"`
+}
+
+// SettlementInput は精算（メンバー間の支払い）の記録リクエスト
+type SettlementInput struct {
+	Date   string `json:"date"`
+	From   string `json:"from"` // 支払ったメンバー
+	To     string `json:"to"`   // 受け取ったメンバー
+	Amount int    `json:"amount"`
+	Memo   string `json:"memo"`
+}
+
+// SettlementMember はメンバーごとの立替・負担の集計
+type SettlementMember struct {
+	Email      string `json:"email"`
+	Paid       int    `json:"paid"`       // 立て替えた額
+	Share      int    `json:"share"`      // 負担すべき額
+	SettledOut int    `json:"settledOut"` // 精算で支払った額
+	SettledIn  int    `json:"settledIn"`  // 精算で受け取った額
+	Net        int    `json:"net"`        // 正=受け取る側、負=支払う側
+}
+
+// SettlementTransfer は精算に必要な支払い
+type SettlementTransfer struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+// Settlement は期間内の精算結果
+type Settlement struct {
+	From      string               `json:"from"` // "YYYY-MM"
+	To        string               `json:"to"`   // "YYYY-MM"
+	Members   []SettlementMember   `json:"members"`
+	Transfers []SettlementTransfer `json:"transfers"` // 未精算額を解消する支払い
+	Payments  []Expense            `json:"payments"`  // 期間内に記録済みの精算
+}
+
+// TagAmount はタグ別集計
+type TagAmount struct {
+	Tag    string `json:"tag"`
+	Amount int    `json:"amount"`
+	Count  int    `json:"count"`
+}
+
+// TagSummary は期間内のタグ別集計（複数タグの支出は各タグに計上）
+type TagSummary struct {
+	From  string      `json:"from"`  // "YYYY-MM"
+	To    string      `json:"to"`    // "YYYY-MM"
+	ByTag []TagAmount `json:"byTag"` // 金額の大きい順
+}
+
+// PivotCell はピボット集計の 1 セル（groupBy の次元ごとの値の組み合わせ）
+type PivotCell struct {
+	Keys   []string `json:"keys"`   // groupBy の順の値（カテゴリは ID、週は週初日 "YYYY-MM-DD"、月は "YYYY-MM"）
+	Labels []string `json:"labels"` // 表示名（カテゴリ名など）
+	Amount int      `json:"amount"`
+	Count  int      `json:"count"` // 支出件数（返金は含まない）
+}
+
+// PivotSummary は期間内の支出を 1〜2 次元で集計した結果
+type PivotSummary struct {
+	From    string      `json:"from"` // "YYYY-MM"
+	To      string      `json:"to"`   // "YYYY-MM"
+	GroupBy []string    `json:"groupBy"`
+	Total   int         `json:"total"` // excludeFromBreakdown を含む合計（絞り込み後）
+	Rows    []PivotCell `json:"rows"`  // 1 次元目ごとの合計
+	Cells   []PivotCell `json:"cells"` // groupBy の全次元の組み合わせごとの合計
+}
+
+// DailyTotal は日別の支出合計
+type DailyTotal struct {
+	Date          string `json:"date"` // "YYYY-MM-DD"
+	Total         int    `json:"total"`
+	Count         int    `json:"count"` // 支出件数（返金は含まない）
+	TopCategoryID string `json:"topCategoryId,omitempty"`
+	TopCategory   string `json:"topCategory,omitempty"`
+	TopColor      string `json:"topColor,omitempty"`
+}
+
+// DailyTotals は月または年の日別合計（支出のない日は含まない）
+type DailyTotals struct {
+	From  string       `json:"from"` // "YYYY-MM-DD"
+	To    string       `json:"to"`   // "YYYY-MM-DD"
+	Total int          `json:"total"`
+	Days  []DailyTotal `json:"days"` // 日付の昇順
+}
+
+// BusinessLine は事業按分の明細（支出のカテゴリ・明細ごと、金額は本人の負担分）
+type BusinessLine struct {
+	ExpenseID      string `json:"expenseId"`
+	Date           string `json:"date"`
+	CategoryID     string `json:"categoryId"`
+	Category       string `json:"category"`
+	Account        string `json:"account"` // 勘定科目
+	Place          string `json:"place"`
+	Memo           string `json:"memo"`
+	Amount         int    `json:"amount"`         // 本人の負担額（返金はマイナス）
+	Ratio          int    `json:"ratio"`          // 事業割合（%）
+	BusinessAmount int    `json:"businessAmount"` // 事業按分額（1 円未満切り捨て）
+}
+
+// BusinessAccount は勘定科目別の事業按分額
+type BusinessAccount struct {
+	Account        string `json:"account"`
+	Amount         int    `json:"amount"`
+	BusinessAmount int    `json:"businessAmount"`
+	Count          int    `json:"count"`
+}
+
+// BusinessReport は年間の事業按分レポート（確定申告用）
+type BusinessReport struct {
+	Year      string            `json:"year"` // "YYYY"
+	Total     int               `json:"total"`
+	ByAccount []BusinessAccount `json:"byAccount"` // 勘定科目名順
+	Lines     []BusinessLine    `json:"lines"`     // 日付順
+}
+
+// MedicalRow は医療費控除の明細書の 1 行（医療を受けた方・支払先ごと）
+type MedicalRow struct {
+	Patient    string   `json:"patient"`
+	Provider   string   `json:"provider"`
+	Kinds      []string `json:"kinds"`      // 医療費の区分（"treatment" | "care" | "medicine" | "other"）
+	Amount     int      `json:"amount"`     // 支払った医療費の額
+	Reimbursed int      `json:"reimbursed"` // うち保険金などで補填される金額
+	Count      int      `json:"count"`
+}
+
+// MedicalPatient は医療を受けた方ごとの合計
+type MedicalPatient struct {
+	Patient    string `json:"patient"`
+	Amount     int    `json:"amount"`
+	Reimbursed int    `json:"reimbursed"`
+}
+
+// MedicalReport は年間の医療費控除の明細書（金額は明細書の (A)〜(F) と控除額）
+type MedicalReport struct {
+	Year       string           `json:"year"` // "YYYY"
+	Rows       []MedicalRow     `json:"rows"` // 医療を受けた方・支払先の名前順
+	ByPatient  []MedicalPatient `json:"byPatient"`
+	Total      int              `json:"total"`      // (A) 医療費の合計
+	Reimbursed int              `json:"reimbursed"` // (B) 保険金などで補填される金額
+	Net        int              `json:"net"`        // (C) 差引額 (A)-(B)
+	Income     int              `json:"income"`     // (D) 所得金額等の合計額（0=未指定）
+	Threshold  int              `json:"threshold"`  // (F) 10 万円と (D)×5% のいずれか少ない金額
+	Deduction  int              `json:"deduction"`  // 医療費控除額 (C)-(F)（最高 200 万円）
+}
+
+// CSVExport は CSV ファイルのダウンロード内容
+type CSVExport struct {
+	FileName string `json:"fileName"`
+	Content  string `json:"content"`
+}
+
+// TaxRateAmount は税率別の税抜金額・消費税額
+type TaxRateAmount struct {
+	Rate    int `json:"rate"`
+	Taxable int `json:"taxable"` // 税抜金額
+	Tax     int `json:"tax"`
+}
+
+// TaxCategoryAmount はカテゴリ別の税抜金額・消費税額
+type TaxCategoryAmount struct {
+	CategoryID string `json:"categoryId"`
+	Category   string `json:"category"`
+	Color      string `json:"color"`
+	Taxable    int    `json:"taxable"`
+	Tax        int    `json:"tax"`
+}
+
+// TaxMonth は月別の消費税集計
+type TaxMonth struct {
+	Month      string              `json:"month"`
+	Taxable    int                 `json:"taxable"`
+	Tax        int                 `json:"tax"`
+	ByRate     []TaxRateAmount     `json:"byRate"`     // 税率の高い順
+	ByCategory []TaxCategoryAmount `json:"byCategory"` // カテゴリマスタの sortOrder 順
+}
+
+// TaxSummary は期間の消費税集計（税率別内訳のある支出のみ対象）
+type TaxSummary struct {
+	From    string          `json:"from"` // "YYYY-MM"
+	To      string          `json:"to"`   // "YYYY-MM"
+	Taxable int             `json:"taxable"`
+	Tax     int             `json:"tax"`
+	ByRate  []TaxRateAmount `json:"byRate"`
+	Months  []TaxMonth      `json:"months"` // 昇順
+}
+
+// ReconcileInput は残高照合のリクエスト
+type ReconcileInput struct {
+	Payer   string `json:"payer"`
+	Date    string `json:"date"`    // 照合日 "YYYY-MM-DD"（当日の記録を含む）
+	Balance int    `json:"balance"` // 実際の残高
+	Memo    string `json:"memo"`
+}
+
+// ReconcileResult は残高照合の結果
+type ReconcileResult struct {
+	Payer      string   `json:"payer"`
+	Date       string   `json:"date"`
+	Expected   int      `json:"expected"`   // 記録上の残高
+	Actual     int      `json:"actual"`     // 実際の残高
+	Difference int      `json:"difference"` // 実際 - 記録上
+	Adjustment *Expense `json:"adjustment"` // 登録した残高調整（差額なしの場合は nil）
 }
 
 // User は許可ユーザー
 type User struct {
 	Email     string `json:"email"`
+	Name      string `json:"name,omitempty"` // 表示名（空=メールアドレスの @ より前）
 	Role      string `json:"role"`
 	CreatedAt string `json:"createdAt"`
 }
 
+// HouseholdSettings は世帯共通の設定
+type HouseholdSettings struct {
+	MonthStartDay        int    `json:"monthStartDay"`        // 月の開始日（1〜28、1=カレンダー月。25 なら "2025-03" は 3/25〜4/24）
+	WeekStart            string `json:"weekStart"`            // 週の開始曜日 "monday" | "sunday"（空=monday）
+	FiscalYearStartMonth int    `json:"fiscalYearStartMonth"` // 年度の開始月（1〜12、1=暦年。4 なら 2025 年度は 2025-04〜2026-03）
+	UpdatedAt            string `json:"updatedAt,omitempty"`
+}
+
 // AuthUser は認証済みユーザー情報
 type AuthUser struct {
 	Email   string `json:"email"`
@@ -87,13 +462,89 @@ type CategorySummary struct {
 	Color      string `json:"color"`
 }
 
-// MonthComparison は月比較データ
+// MonthComparison は月比較データ（週別集計の前週比・平均比にも使う）
 type MonthComparison struct {
-	Total       int     `json:"total"`
+	Total       int                  `json:"total"`
+	Diff        int                  `json:"diff"`
+	DiffPercent float64              `json:"diffPercent"`
+	ByCategory  []CategoryComparison `json:"byCategory,omitempty"` // カテゴリ別の比較（週別集計のみ）
+}
+
+// CategoryComparison はカテゴリ別の比較データ
+type CategoryComparison struct {
+	CategoryID  string  `json:"categoryId"`
+	Category    string  `json:"category"`
+	Amount      int     `json:"amount"` // 比較対象の金額
 	Diff        int     `json:"diff"`
 	DiffPercent float64 `json:"diffPercent"`
 }
 
+// WeekData は週別の集計データ
+type WeekData struct {
+	Week       string            `json:"week"` // 週初日 "YYYY-MM-DD"
+	Total      int               `json:"total"`
+	ByCategory []CategorySummary `json:"byCategory"`
+}
+
+// WeeklySummary は週別集計（前週比と直近 N 週の平均との比較）
+type WeeklySummary struct {
+	Week          string            `json:"week"`      // 週初日 "YYYY-MM-DD"
+	WeekEnd       string            `json:"weekEnd"`   // 週の最終日 "YYYY-MM-DD"
+	WeekStart     string            `json:"weekStart"` // 週の開始曜日 "monday" | "sunday"
+	Total         int               `json:"total"`
+	ByCategory    []CategorySummary `json:"byCategory"`
+	PreviousWeek  *MonthComparison  `json:"previousWeek"`
+	AverageWeeks  int               `json:"averageWeeks"`  // 平均の対象週数
+	RecentAverage *MonthComparison  `json:"recentAverage"` // 前週までの直近 averageWeeks 週の平均との比較
+	Weeks         []WeekData        `json:"weeks"`         // 直近 averageWeeks 週と当週（古い順）
+}
+
+// InsightFlag は通常と異なる支出の検出結果
+type InsightFlag struct {
+	Kind       string  `json:"kind"` // "monthToDate" | "last30Days" | "largeExpense"
+	CategoryID string  `json:"categoryId"`
+	Category   string  `json:"category"`
+	Amount     int     `json:"amount"` // 対象期間の金額（largeExpense は支出のカテゴリ分の金額）
+	Median     int     `json:"median"` // 過去の中央値
+	Spread     int     `json:"spread"` // 過去のばらつき（中央絶対偏差を標準偏差相当に換算）
+	Ratio      float64 `json:"ratio"`  // amount / median（中央値が 0 の場合は 0）
+	ExpenseID  string  `json:"expenseId,omitempty"`
+	Date       string  `json:"date,omitempty"`
+	Place      string  `json:"place,omitempty"`
+	Memo       string  `json:"memo,omitempty"`
+}
+
+// Insights は基準日時点の支出の異常検知結果
+type Insights struct {
+	Date       string        `json:"date"`       // 基準日 "YYYY-MM-DD"
+	Month      string        `json:"month"`      // 基準日が属する月（開始日基準）
+	MonthStart string        `json:"monthStart"` // 月の初日 "YYYY-MM-DD"
+	Flags      []InsightFlag `json:"flags"`
+}
+
+// MemberTotal はメンバー（登録者）別の支出集計
+type MemberTotal struct {
+	Email      string            `json:"email"`
+	Name       string            `json:"name"`
+	Total      int               `json:"total"`      // excludeFromBreakdown を含む合計
+	ByCategory []CategorySummary `json:"byCategory"` // 他人の「金額のみ公開」はカテゴリ ""（個人出費）にまとめる
+}
+
+// MemberMonth は月別のメンバーごとの合計
+type MemberMonth struct {
+	Month  string         `json:"month"`
+	Totals map[string]int `json:"totals"` // メールアドレス→合計
+}
+
+// MemberSummary はメンバー別集計（当月と直近 13 ヶ月）
+type MemberSummary struct {
+	Month   string        `json:"month"`
+	From    string        `json:"from"`    // 13 ヶ月の先頭月
+	Members []MemberTotal `json:"members"` // 当月
+	Window  []MemberTotal `json:"window"`  // 直近 13 ヶ月の合計
+	Months  []MemberMonth `json:"months"`  // 月別推移（古い順）
+}
+
 // MonthlySummary は月別集計
 type MonthlySummary struct {
 	Month             string           `json:"month"`
@@ -110,46 +561,160 @@ type MonthData struct {
 	ByCategory []CategorySummary `json:"byCategory"`
 }
 
-// YearlySummary は年間集計
+// YearlySummary は年間集計（指定月までの直近 13 ヶ月。Year は指定月の年）
 type YearlySummary struct {
 	Year   string      `json:"year"`
 	Months []MonthData `json:"months"`
 }
 
+// SummaryAmounts は支払元→カテゴリID→金額（カテゴリ設定を適用する前の集計。返金はマイナス）
+type SummaryAmounts map[string]map[string]int
+
+// SummaryCache は月別集計キャッシュ（月と開始日ごと）。
+// Shared は private 以外の記録、Private は登録者ごとの private の記録の集計で、
+// 閲覧者の集計は Shared と自分の Private の合計に閲覧者のカテゴリ設定を適用して求める。
+type SummaryCache struct {
+	Month     string                    `json:"month"`
+	StartDay  int                       `json:"startDay"`
+	Shared    SummaryAmounts            `json:"shared"`
+	Private   map[string]SummaryAmounts `json:"private"`
+	Version   int                       `json:"version"` // 無効化のたびに増える（古い集計での上書きを防ぐ）
+	Valid     bool                      `json:"valid"`   // false=無効化済み（Version のみ有効）
+	UpdatedAt string                    `json:"updatedAt,omitempty"`
+}
+
+// SummaryCacheDiff は集計キャッシュと記録からの集計の差異（共有分の支払元・カテゴリごと）
+type SummaryCacheDiff struct {
+	Payer      string `json:"payer"`
+	CategoryID string `json:"categoryId"`
+	Cached     int    `json:"cached"`
+	Actual     int    `json:"actual"`
+}
+
+// SummaryCacheMonth は月ごとの集計キャッシュの照合結果
+type SummaryCacheMonth struct {
+	Month             string             `json:"month"`
+	StartDay          int                `json:"startDay"`
+	Status            string             `json:"status"` // ok | missing | invalid | mismatch
+	Diffs             []SummaryCacheDiff `json:"diffs,omitempty"`
+	PrivateMismatches int                `json:"privateMismatches,omitempty"` // private の集計が食い違う登録者の数（内容は返さない）
+	Rebuilt           bool               `json:"rebuilt"`
+}
+
+// SummaryCacheReport は集計キャッシュの照合・再構築の結果
+type SummaryCacheReport struct {
+	From       string              `json:"from"`
+	To         string              `json:"to"`
+	Verify     bool                `json:"verify"` // true=照合のみ（保存しない）
+	Months     []SummaryCacheMonth `json:"months"`
+	Mismatches int                 `json:"mismatches"` // status=mismatch の月数
+	Rebuilt    int                 `json:"rebuilt"`
+}
+
+// ExpenseChange は expenses テーブルの 1 件の変更（DynamoDB Streams のレコード）。
+// 登録時は Old、削除時は New が nil。
+type ExpenseChange struct {
+	EventID string
+	Time    string // 変更時刻（RFC3339、UTC）
+	Old     *Expense
+	New     *Expense
+}
+
+// AuditEntry は支出の変更履歴（DynamoDB Streams から記録する）
+type AuditEntry struct {
+	ID         string   `json:"id"`
+	Time       string   `json:"time"`
+	Action     string   `json:"action"` // create | update | delete
+	ExpenseID  string   `json:"expenseId"`
+	Date       string   `json:"date"`
+	Payer      string   `json:"payer"`
+	Category   string   `json:"category,omitempty"`
+	Amount     int      `json:"amount"`
+	Visibility string   `json:"visibility,omitempty"`
+	CreatedBy  string   `json:"createdBy,omitempty"`
+	Changes    []string `json:"changes,omitempty"`   // update で変わった項目
+	OldDate    string   `json:"oldDate,omitempty"`   // update で日付が変わった場合の変更前
+	OldAmount  *int     `json:"oldAmount,omitempty"` // update で金額が変わった場合の変更前
+}
+
+// AnnualCategoryRow は年間レポートのカテゴリ別の行
+type AnnualCategoryRow struct {
+	CategoryID string `json:"categoryId"`
+	Category   string `json:"category"`
+	Color      string `json:"color"`
+	Months     []int  `json:"months"` // AnnualReport.Months と同じ並びの月額
+	Total      int    `json:"total"`
+	Average    int    `json:"average"` // 経過月数での月平均
+}
+
+// AnnualReport は暦年・年度の年間レポート
+type AnnualReport struct {
+	Year          string              `json:"year"`
+	StartMonth    int                 `json:"startMonth"` // 年の開始月（1=暦年、4=4 月始まりの年度）
+	Months        []string            `json:"months"`
+	MonthTotals   []int               `json:"monthTotals"` // 月別の合計（excludeFromBreakdown を含む）
+	Rows          []AnnualCategoryRow `json:"rows"`
+	Total         int                 `json:"total"`
+	Average       int                 `json:"average"`
+	ElapsedMonths int                 `json:"elapsedMonths"` // 平均・前年比較に使う経過月数
+	PreviousYear  *MonthComparison    `json:"previousYear"`  // 前年の同じ経過月数までとの比較（カテゴリ別を含む）
+}
+
+// PeriodComparison は同じ月数の 2 つの期間の比較
+type PeriodComparison struct {
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	CompareFrom string            `json:"compareFrom"`
+	CompareTo   string            `json:"compareTo"`
+	Total       int               `json:"total"`
+	ByCategory  []CategorySummary `json:"byCategory"`
+	Comparison  *MonthComparison  `json:"comparison"` // 比較期間との比較（カテゴリ別を含む）
+}
+
 // RecurringExpense は定期支出テンプレート
 type RecurringExpense struct {
-	ID               string `json:"id"`
-	Category         string `json:"category"`
-	Amount           int    `json:"amount"`
-	Payer            string `json:"payer"`
-	Place            string `json:"place"`
-	Memo             string `json:"memo"`
-	Visibility       string `json:"visibility"`       // "public" | "summary" | "private"（空="" は "public" 扱い）
-	Frequency        string `json:"frequency"`        // "monthly" | "bimonthly" | "yearly"
-	DayOfMonth       int    `json:"dayOfMonth"`       // 1-31
-	RepeatMonth      int    `json:"repeatMonth"`      // 1-12（yearly のみ）
-	StartMonth       string `json:"startMonth"`       // "YYYY-MM"（空=制限なし）
-	EndMonth         string `json:"endMonth"`         // "YYYY-MM"（空=制限なし）
-	IsActive         bool   `json:"isActive"`
-	LastCreatedMonth string `json:"lastCreatedMonth"` // "YYYY-MM"
-	CreatedAt        string `json:"createdAt"`
-	UpdatedAt        string `json:"updatedAt"`
+	ID               string   `json:"id"`
+	Category         string   `json:"category"`
+	Amount           int      `json:"amount"`
+	Currency         string   `json:"currency,omitempty"`       // 外貨建ての場合の通貨（空=基準通貨）
+	OriginalAmount   float64  `json:"originalAmount,omitempty"` // 外貨建ての金額（登録時のレートで換算）
+	Payer            string   `json:"payer"`
+	Place            string   `json:"place"`
+	Memo             string   `json:"memo"`
+	Visibility       string   `json:"visibility"`       // "public" | "summary" | "private"（空="" は "public" 扱い）
+	PaidBy           string   `json:"paidBy,omitempty"` // 立て替えるメンバー（split 指定時は必須）
+	Split            *Split   `json:"split,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	Frequency        string   `json:"frequency"`   // "monthly" | "bimonthly" | "yearly"
+	DayOfMonth       int      `json:"dayOfMonth"`  // 1-31
+	RepeatMonth      int      `json:"repeatMonth"` // 1-12（yearly のみ）
+	StartMonth       string   `json:"startMonth"`  // "YYYY-MM"（空=制限なし）
+	EndMonth         string   `json:"endMonth"`    // "YYYY-MM"（空=制限なし）
+	IsActive         bool     `json:"isActive"`
+	LastCreatedMonth string   `json:"lastCreatedMonth"` // "YYYY-MM"
+	CreatedAt        string   `json:"createdAt"`
+	UpdatedAt        string   `json:"updatedAt"`
 }
 
 // RecurringExpenseInput は定期支出テンプレートの登録・更新リクエスト
 type RecurringExpenseInput struct {
-	Category    string `json:"category"`
-	Amount      int    `json:"amount"`
-	Payer       string `json:"payer"`
-	Place       string `json:"place"`
-	Memo        string `json:"memo"`
-	Visibility  string `json:"visibility"`
-	Frequency   string `json:"frequency"`
-	DayOfMonth  int    `json:"dayOfMonth"`
-	RepeatMonth int    `json:"repeatMonth"`
-	StartMonth  string `json:"startMonth"`
-	EndMonth    string `json:"endMonth"`
-	IsActive    bool   `json:"isActive"`
+	Category       string   `json:"category"`
+	Amount         int      `json:"amount"`
+	Currency       string   `json:"currency"`
+	OriginalAmount float64  `json:"originalAmount"`
+	Payer          string   `json:"payer"`
+	Place          string   `json:"place"`
+	Memo           string   `json:"memo"`
+	Visibility     string   `json:"visibility"`
+	PaidBy         string   `json:"paidBy"`
+	Split          *Split   `json:"split"`
+	Tags           []string `json:"tags"`
+	Frequency      string   `json:"frequency"`
+	DayOfMonth     int      `json:"dayOfMonth"`
+	RepeatMonth    int      `json:"repeatMonth"`
+	StartMonth     string   `json:"startMonth"`
+	EndMonth       string   `json:"endMonth"`
+	IsActive       bool     `json:"isActive"`
 }
 
 // CategoryInput はカテゴリ登録・更新のリクエスト
@@ -162,6 +727,9 @@ type CategoryInput struct {
 	ExcludeFromBreakdown bool   `json:"excludeFromBreakdown"`
 	ExcludeFromSummary   bool   `json:"excludeFromSummary"`
 	OwnerEmail           string `json:"ownerEmail"`
+	BusinessRatio        int    `json:"businessRatio"`
+	ExpenseAccount       string `json:"expenseAccount"`
+	IsMedical            bool   `json:"isMedical"`
 }
 
 // PlaceInput は場所登録・更新のリクエスト
@@ -173,10 +741,16 @@ type PlaceInput struct {
 
 // PayerInput は支払元登録・更新のリクエスト
 type PayerInput struct {
-	Name         string `json:"name"`
-	SortOrder    int    `json:"sortOrder"`
-	IsActive     bool   `json:"isActive"`
-	TrackBalance bool   `json:"trackBalance"`
+	Name               string `json:"name"`
+	SortOrder          int    `json:"sortOrder"`
+	IsActive           bool   `json:"isActive"`
+	TrackBalance       bool   `json:"trackBalance"`
+	OpeningBalance     int    `json:"openingBalance"`
+	OpeningDate        string `json:"openingDate"`
+	ClosingDay         int    `json:"closingDay"`
+	PaymentDay         int    `json:"paymentDay"`
+	PaymentMonthOffset int    `json:"paymentMonthOffset"`
+	PaymentSource      string `json:"paymentSource"`
 }
 
 // APIResponse はAPIレスポンス
@@ -188,15 +762,39 @@ type APIResponse struct {
 
 // ActionRequest はリクエストボディ
 type ActionRequest struct {
-	Action  string        `json:"action"`
-	Month   string        `json:"month,omitempty"`
-	Year    string        `json:"year,omitempty"`
-	ID      string        `json:"id,omitempty"`
-	Payer   string        `json:"payer,omitempty"`
+	Action           string                 `json:"action"`
+	Month            string                 `json:"month,omitempty"`
+	Year             string                 `json:"year,omitempty"`
+	From             string                 `json:"from,omitempty"`
+	To               string                 `json:"to,omitempty"`
+	ID               string                 `json:"id,omitempty"`
+	Payer            string                 `json:"payer,omitempty"`
 	Expense          *ExpenseInput          `json:"expense,omitempty"`
 	Expenses         []ExpenseInput         `json:"expenses,omitempty"`
 	RecurringExpense *RecurringExpenseInput `json:"recurringExpense,omitempty"`
 	Category         *CategoryInput         `json:"category,omitempty"`
 	Place            *PlaceInput            `json:"place,omitempty"`
 	PayerData        *PayerInput            `json:"payerData,omitempty"`
+	Reconcile        *ReconcileInput        `json:"reconcile,omitempty"`
+	ExchangeRate     *ExchangeRateInput     `json:"exchangeRate,omitempty"`
+	ExchangeRates    []ExchangeRateInput    `json:"exchangeRates,omitempty"`
+	Currency         string                 `json:"currency,omitempty"`
+	Settlement       *SettlementInput       `json:"settlement,omitempty"`
+	Tags             []string               `json:"tags,omitempty"`
+	Query            string                 `json:"query,omitempty"`
+	ExpenseID        string                 `json:"expenseId,omitempty"`
+	Attachment       *AttachmentInput       `json:"attachment,omitempty"`
+	Refund           *RefundInput           `json:"refund,omitempty"`
+	Income           int                    `json:"income,omitempty"`
+	Settings         *HouseholdSettings     `json:"settings,omitempty"`
+	Calendar         bool                   `json:"calendar,omitempty"` // true=月の開始日の設定によらずカレンダー月で集計
+	GroupBy          []string               `json:"groupBy,omitempty"`
+	Filters          map[string][]string    `json:"filters,omitempty"`
+	Date             string                 `json:"date,omitempty"`
+	Weeks            int                    `json:"weeks,omitempty"`
+	Name             string                 `json:"name,omitempty"`
+	CompareFrom      string                 `json:"compareFrom,omitempty"`
+	CompareTo        string                 `json:"compareTo,omitempty"`
+	Fiscal           bool                   `json:"fiscal,omitempty"` // true=世帯設定の年度開始月から 12 ヶ月
+	Verify           bool                   `json:"verify,omitempty"` // true=集計キャッシュを照合のみ
 }
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// effectiveFiscalYearStartMonth は年度の開始月を返す（未設定・範囲外は 1=暦年）
+func effectiveFiscalYearStartMonth(month int) int {
+	if month < 1 || month > 12 {
+		return 1
+	}
+	return month
+}
+
+// loadFiscalYearStartMonth は世帯設定の年度の開始月を返す（fiscal=false の場合は 1）
+func loadFiscalYearStartMonth(ctx context.Context, client *dynamo.Client, fiscal bool) (int, error) {
+	if !fiscal {
+		return 1, nil
+	}
+	settings, err := client.GetHouseholdSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return effectiveFiscalYearStartMonth(settings.FiscalYearStartMonth), nil
+}
+
+// yearMonths は年 year（"YYYY"）の開始月 startMonth から 12 ヶ月の月一覧を返す
+func yearMonths(year string, startMonth int) ([]string, error) {
+	t, err := time.Parse("2006", year)
+	if err != nil {
+		return nil, apperror.Newf("year の形式が不正です: %s", year)
+	}
+	months := make([]string, 12)
+	first := time.Date(t.Year(), time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
+	for i := range months {
+		months[i] = first.AddDate(0, i, 0).Format("2006-01")
+	}
+	return months, nil
+}
+
+// GetAnnualReport は暦年（fiscal=true は世帯設定の年度）の月別・カテゴリ別の集計と前年との比較を返す。
+// 年度は開始月の年で呼ぶ（4 月始まりの 2025 年度は 2025-04〜2026-03）。
+// 平均と前年比較は経過月数（年の途中なら今月まで）で計算する。集計の規則・月の区切りは GetMonthlySummary と同じ。
+func GetAnnualReport(ctx context.Context, client *dynamo.Client, year string, fiscal bool, payer string, userEmail string, calendar bool) (*model.AnnualReport, error) {
+	startMonth, err := loadFiscalYearStartMonth(ctx, client, fiscal)
+	if err != nil {
+		return nil, err
+	}
+	months, err := yearMonths(year, startMonth)
+	if err != nil {
+		return nil, err
+	}
+	y, _ := strconv.Atoi(year)
+	prevMonths, err := yearMonths(strconv.Itoa(y-1), startMonth)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	dataMap, err := getMonthDataMap(ctx, client, append(append([]string{}, prevMonths...), months...), payer, catMaps, userEmail, startDay)
+	if err != nil {
+		return nil, err
+	}
+	currentMonth := monthOf(time.Now().Format("2006-01-02"), startDay)
+	return buildAnnualReport(year, startMonth, months, prevMonths, dataMap, catMaps, currentMonth), nil
+}
+
+// buildAnnualReport は月別の集計から年間レポートを作る（currentMonth より後の月は経過月数に含めない）
+func buildAnnualReport(year string, startMonth int, months []string, prevMonths []string, dataMap map[string]*model.MonthData, catMaps *CategoryMaps, currentMonth string) *model.AnnualReport {
+	report := &model.AnnualReport{
+		Year:        year,
+		StartMonth:  startMonth,
+		Months:      months,
+		MonthTotals: make([]int, len(months)),
+		Rows:        []model.AnnualCategoryRow{},
+	}
+	for _, ym := range months {
+		if ym <= currentMonth {
+			report.ElapsedMonths++
+		}
+	}
+
+	rows := make(map[string]*model.AnnualCategoryRow)
+	for i, ym := range months {
+		data := dataMap[ym]
+		report.MonthTotals[i] = data.Total
+		report.Total += data.Total
+		for _, c := range data.ByCategory {
+			row, ok := rows[c.CategoryID]
+			if !ok {
+				row = &model.AnnualCategoryRow{CategoryID: c.CategoryID, Category: c.Category, Color: c.Color, Months: make([]int, len(months))}
+				rows[c.CategoryID] = row
+			}
+			row.Months[i] += c.Amount
+			row.Total += c.Amount
+		}
+	}
+	for _, row := range rows {
+		if report.ElapsedMonths > 0 {
+			row.Average = roundDiv(row.Total, report.ElapsedMonths)
+		}
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		return categoryBefore(report.Rows[i].CategoryID, report.Rows[j].CategoryID, catMaps)
+	})
+	if report.ElapsedMonths == 0 {
+		return report
+	}
+	report.Average = roundDiv(report.Total, report.ElapsedMonths)
+
+	// 前年は同じ経過月数までと比べる
+	current := sumMonthData(months[:report.ElapsedMonths], dataMap, catMaps)
+	prev := sumMonthData(prevMonths[:report.ElapsedMonths], dataMap, catMaps)
+	if prev.Total > 0 || current.Total > 0 {
+		report.PreviousYear = makeCategoryComparison(current.Total, prev.Total, current.ByCategory, prev.ByCategory)
+	}
+	return report
+}
+
+// ComparePeriods は from〜to の集計を同じ月数の比較期間 compareFrom〜compareTo と比べる。
+// 比較期間を省略した場合は直前の同じ月数の期間と比べる。集計の規則・月の区切りは GetMonthlySummary と同じ。
+func ComparePeriods(ctx context.Context, client *dynamo.Client, from, to, compareFrom, compareTo string, payer string, userEmail string, calendar bool) (*model.PeriodComparison, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if compareFrom == "" && compareTo == "" {
+		compareTo = previousMonth(from)
+		compareFrom = compareTo
+		for i := 1; i < len(months); i++ {
+			compareFrom = previousMonth(compareFrom)
+		}
+	}
+	compareMonths, err := monthRange(compareFrom, compareTo)
+	if err != nil {
+		return nil, apperror.Newf("比較期間の指定が不正です: %s〜%s", compareFrom, compareTo)
+	}
+	if len(compareMonths) != len(months) {
+		return nil, apperror.Newf("比較期間は対象期間と同じ %d ヶ月で指定してください", len(months))
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(append([]string{}, months...), compareMonths...)
+	dataMap, err := getMonthDataMap(ctx, client, all, payer, catMaps, userEmail, startDay)
+	if err != nil {
+		return nil, err
+	}
+	return buildPeriodComparison(months, compareMonths, dataMap, catMaps), nil
+}
+
+// buildPeriodComparison は 2 つの期間の月別集計から比較を作る
+func buildPeriodComparison(months []string, compareMonths []string, dataMap map[string]*model.MonthData, catMaps *CategoryMaps) *model.PeriodComparison {
+	current := sumMonthData(months, dataMap, catMaps)
+	compare := sumMonthData(compareMonths, dataMap, catMaps)
+	return &model.PeriodComparison{
+		From:        months[0],
+		To:          months[len(months)-1],
+		CompareFrom: compareMonths[0],
+		CompareTo:   compareMonths[len(compareMonths)-1],
+		Total:       current.Total,
+		ByCategory:  current.ByCategory,
+		Comparison:  makeCategoryComparison(current.Total, compare.Total, current.ByCategory, compare.ByCategory),
+	}
+}
+
+// sumMonthData は複数月の合計・カテゴリ別金額を合算する（カテゴリマスタの並び順）
+func sumMonthData(months []string, dataMap map[string]*model.MonthData, catMaps *CategoryMaps) model.MonthData {
+	var sum model.MonthData
+	amounts := make(map[string]int)
+	categories := make(map[string]model.CategorySummary)
+	for _, ym := range months {
+		data := dataMap[ym]
+		sum.Total += data.Total
+		for _, c := range data.ByCategory {
+			amounts[c.CategoryID] += c.Amount
+			categories[c.CategoryID] = c
+		}
+	}
+	for id, c := range categories {
+		c.Amount = amounts[id]
+		sum.ByCategory = append(sum.ByCategory, c)
+	}
+	sort.Slice(sum.ByCategory, func(i, j int) bool {
+		return categoryBefore(sum.ByCategory[i].CategoryID, sum.ByCategory[j].CategoryID, catMaps)
+	})
+	return sum
+}
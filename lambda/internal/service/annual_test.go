@@ -0,0 +1,116 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestYearMonths(t *testing.T) {
+	tests := []struct {
+		year       string
+		startMonth int
+		wantFirst  string
+		wantLast   string
+	}{
+		{year: "2025", startMonth: 1, wantFirst: "2025-01", wantLast: "2025-12"},
+		{year: "2025", startMonth: 4, wantFirst: "2025-04", wantLast: "2026-03"},
+		{year: "2025", startMonth: 12, wantFirst: "2025-12", wantLast: "2026-11"},
+	}
+	for _, tt := range tests {
+		got, err := yearMonths(tt.year, tt.startMonth)
+		if err != nil {
+			t.Fatalf("yearMonths(%s, %d) error: %v", tt.year, tt.startMonth, err)
+		}
+		if len(got) != 12 || got[0] != tt.wantFirst || got[11] != tt.wantLast {
+			t.Errorf("yearMonths(%s, %d) = %v, want %s〜%s", tt.year, tt.startMonth, got, tt.wantFirst, tt.wantLast)
+		}
+	}
+	if _, err := yearMonths("25", 1); err == nil {
+		t.Error("yearMonths(25) should fail")
+	}
+}
+
+func TestBuildAnnualReport(t *testing.T) {
+	catMaps := &CategoryMaps{SortOrder: map[string]int{"food": 1, "daily": 2}}
+	food := func(amount int) model.CategorySummary {
+		return model.CategorySummary{CategoryID: "food", Category: "食費", Amount: amount, Color: "#f00"}
+	}
+	daily := func(amount int) model.CategorySummary {
+		return model.CategorySummary{CategoryID: "daily", Category: "日用品", Amount: amount, Color: "#0f0"}
+	}
+	months, _ := yearMonths("2026", 4)
+	prevMonths, _ := yearMonths("2025", 4)
+	dataMap := make(map[string]*model.MonthData)
+	for _, ym := range append(append([]string{}, prevMonths...), months...) {
+		dataMap[ym] = &model.MonthData{Month: ym}
+	}
+	// 前年度: 4〜6 月は食費 10,000 円、7 月以降は集計に含めない
+	for _, ym := range prevMonths[:3] {
+		dataMap[ym] = &model.MonthData{Month: ym, Total: 10000, ByCategory: []model.CategorySummary{food(10000)}}
+	}
+	dataMap["2025-07"] = &model.MonthData{Month: "2025-07", Total: 99999, ByCategory: []model.CategorySummary{food(99999)}}
+	// 今年度: 4〜6 月（6 月まで経過）、家賃など内訳除外は合計のみ
+	dataMap["2026-04"] = &model.MonthData{Month: "2026-04", Total: 90000, ByCategory: []model.CategorySummary{daily(2000), food(8000)}}
+	dataMap["2026-05"] = &model.MonthData{Month: "2026-05", Total: 12000, ByCategory: []model.CategorySummary{food(12000)}}
+	dataMap["2026-06"] = &model.MonthData{Month: "2026-06", Total: 13000, ByCategory: []model.CategorySummary{food(10000), daily(3000)}}
+
+	got := buildAnnualReport("2026", 4, months, prevMonths, dataMap, catMaps, "2026-06")
+
+	if got.ElapsedMonths != 3 || got.Total != 115000 || got.Average != 38333 {
+		t.Errorf("elapsed/total/average = %d/%d/%d, want 3/115000/38333", got.ElapsedMonths, got.Total, got.Average)
+	}
+	if got.MonthTotals[0] != 90000 || got.MonthTotals[11] != 0 {
+		t.Errorf("MonthTotals = %v", got.MonthTotals)
+	}
+	wantRows := []model.AnnualCategoryRow{
+		{CategoryID: "food", Category: "食費", Color: "#f00", Months: []int{8000, 12000, 10000, 0, 0, 0, 0, 0, 0, 0, 0, 0}, Total: 30000, Average: 10000},
+		{CategoryID: "daily", Category: "日用品", Color: "#0f0", Months: []int{2000, 0, 3000, 0, 0, 0, 0, 0, 0, 0, 0, 0}, Total: 5000, Average: 1667},
+	}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("Rows = %+v, want %+v", got.Rows, wantRows)
+	}
+	if got.PreviousYear == nil || got.PreviousYear.Total != 30000 || got.PreviousYear.Diff != 85000 {
+		t.Fatalf("PreviousYear = %+v, want total 30000 diff 85000", got.PreviousYear)
+	}
+	if c := got.PreviousYear.ByCategory[0]; c.CategoryID != "food" || c.Amount != 30000 || c.Diff != 0 {
+		t.Errorf("PreviousYear.ByCategory[0] = %+v, want food 30000 ±0", c)
+	}
+
+	// 未来の年は経過月数 0 で平均・前年比較なし
+	future := buildAnnualReport("2026", 4, months, prevMonths, dataMap, catMaps, "2026-03")
+	if future.ElapsedMonths != 0 || future.Average != 0 || future.PreviousYear != nil {
+		t.Errorf("future report = %+v", future)
+	}
+}
+
+func TestBuildPeriodComparison(t *testing.T) {
+	catMaps := &CategoryMaps{SortOrder: map[string]int{"food": 1, "daily": 2}}
+	dataMap := map[string]*model.MonthData{
+		"2025-11": {Month: "2025-11", Total: 5000, ByCategory: []model.CategorySummary{{CategoryID: "daily", Category: "日用品", Amount: 5000}}},
+		"2025-12": {Month: "2025-12", Total: 7000, ByCategory: []model.CategorySummary{{CategoryID: "food", Category: "食費", Amount: 7000}}},
+		"2026-11": {Month: "2026-11", Total: 4000, ByCategory: []model.CategorySummary{{CategoryID: "daily", Category: "日用品", Amount: 4000}}},
+		"2026-12": {Month: "2026-12", Total: 11000, ByCategory: []model.CategorySummary{{CategoryID: "daily", Category: "日用品", Amount: 1000}, {CategoryID: "food", Category: "食費", Amount: 10000}}},
+	}
+
+	got := buildPeriodComparison([]string{"2026-11", "2026-12"}, []string{"2025-11", "2025-12"}, dataMap, catMaps)
+
+	if got.From != "2026-11" || got.To != "2026-12" || got.CompareFrom != "2025-11" || got.CompareTo != "2025-12" {
+		t.Errorf("period = %s〜%s vs %s〜%s", got.From, got.To, got.CompareFrom, got.CompareTo)
+	}
+	wantCategories := []model.CategorySummary{
+		{CategoryID: "food", Category: "食費", Amount: 10000},
+		{CategoryID: "daily", Category: "日用品", Amount: 5000},
+	}
+	if got.Total != 15000 || !reflect.DeepEqual(got.ByCategory, wantCategories) {
+		t.Errorf("total/byCategory = %d %+v", got.Total, got.ByCategory)
+	}
+	wantComparison := &model.MonthComparison{Total: 12000, Diff: 3000, DiffPercent: 25, ByCategory: []model.CategoryComparison{
+		{CategoryID: "food", Category: "食費", Amount: 7000, Diff: 3000, DiffPercent: 42.85},
+		{CategoryID: "daily", Category: "日用品", Amount: 5000, Diff: 0},
+	}}
+	if !reflect.DeepEqual(got.Comparison, wantComparison) {
+		t.Errorf("Comparison = %+v, want %+v", got.Comparison, wantComparison)
+	}
+}
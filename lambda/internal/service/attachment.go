@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/blob"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+const (
+	maxAttachmentsPerExpense = 10
+	maxAttachmentSize        = 10 << 20 // 10MB
+	// maxDirectUploadSize は直接アップロードの上限（Lambda のリクエストサイズ上限 6MB に base64 分の余裕を持たせる）
+	maxDirectUploadSize = 4 << 20
+	presignExpires      = 15 * time.Minute
+)
+
+// allowedAttachmentTypes は添付できるファイル形式（レシート・請求書の画像と PDF）
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"image/heic":      true,
+	"application/pdf": true,
+}
+
+// attachmentKey は添付ファイルの blob キーを返す
+func attachmentKey(expenseID string, attachmentID string) string {
+	return "attachments/" + expenseID + "/" + attachmentID
+}
+
+// getAccessibleExpense は添付ファイルを扱える支出を取得する（見つからない・権限がない場合は 404）
+func getAccessibleExpense(ctx context.Context, client *dynamo.Client, expenseID string, userEmail string) (*model.Expense, error) {
+	expense, err := client.GetExpense(ctx, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if expense == nil || !canAccessExpense(expense, userEmail) {
+		return nil, apperror.WithStatus(404, "支出データが見つかりません")
+	}
+	return expense, nil
+}
+
+// validateAttachmentInput は添付ファイルのメタデータを検証し、ファイル名を正規化する
+func validateAttachmentInput(input *model.AttachmentInput) *apperror.AppError {
+	input.FileName = path.Base(strings.ReplaceAll(strings.TrimSpace(input.FileName), "\\", "/"))
+	if input.ExpenseID == "" || input.FileName == "" || input.FileName == "." || input.FileName == "/" {
+		return apperror.New("expenseId、ファイル名は必須です")
+	}
+	if !allowedAttachmentTypes[input.ContentType] {
+		return apperror.New("添付できるのは JPEG, PNG, WebP, HEIC 画像と PDF のみです")
+	}
+	if input.Size <= 0 || input.Size > maxAttachmentSize {
+		return apperror.Newf("ファイルサイズは %dMB 以下で指定してください", maxAttachmentSize>>20)
+	}
+	return nil
+}
+
+// UploadAttachment は支出に添付ファイルを追加する。
+// data 指定時は blob ストアに直接保存して支出に記録する。省略時はブラウザからアップロードするための
+// 署名付き URL（Content-Type とサイズを固定）を返し、アップロード後の ConfirmAttachment で支出に記録する。
+func UploadAttachment(ctx context.Context, client *dynamo.Client, store blob.Store, input *model.AttachmentInput, userEmail string) (*model.AttachmentUpload, error) {
+	if err := validateAttachmentInput(input); err != nil {
+		return nil, err
+	}
+	expense, err := getAccessibleExpense(ctx, client, input.ExpenseID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if len(expense.Attachments) >= maxAttachmentsPerExpense {
+		return nil, apperror.Newf("添付ファイルは 1 件の支出につき %d 個までです", maxAttachmentsPerExpense)
+	}
+
+	attachment := model.Attachment{
+		ID:          uuid.New().String(),
+		FileName:    input.FileName,
+		ContentType: input.ContentType,
+		Size:        input.Size,
+		UploadedBy:  userEmail,
+		UploadedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	key := attachmentKey(expense.ID, attachment.ID)
+	result := &model.AttachmentUpload{Attachment: attachment}
+
+	if input.Data != "" {
+		data, decodeErr := base64.StdEncoding.DecodeString(input.Data)
+		if decodeErr != nil {
+			return nil, apperror.New("data は base64 で指定してください")
+		}
+		if len(data) > maxDirectUploadSize {
+			return nil, apperror.Newf("直接アップロードできるのは %dMB までです", maxDirectUploadSize>>20)
+		}
+		result.Attachment.Size = int64(len(data))
+		if err := store.Put(ctx, key, bytes.NewReader(data), input.ContentType); err != nil {
+			return nil, err
+		}
+		if err := addAttachment(ctx, client, expense, result.Attachment); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	url, err := store.PresignPut(ctx, key, input.ContentType, input.Size, presignExpires)
+	if errors.Is(err, blob.ErrPresignNotSupported) {
+		return nil, apperror.New("このストアでは data を指定して直接アップロードしてください")
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.UploadURL = url
+	return result, nil
+}
+
+// ConfirmAttachment は署名付き URL でアップロードされた添付ファイルを確認し、支出に記録する。
+// 本体が無い・サイズや Content-Type が申告と異なる場合は記録しない（食い違う本体は削除する）。
+func ConfirmAttachment(ctx context.Context, client *dynamo.Client, store blob.Store, attachmentID string, input *model.AttachmentInput, userEmail string) (*model.Attachment, error) {
+	if err := validateAttachmentInput(input); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		return nil, apperror.New("id が不正です")
+	}
+	expense, err := getAccessibleExpense(ctx, client, input.ExpenseID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if idx := findAttachment(expense.Attachments, attachmentID); idx >= 0 {
+		return &expense.Attachments[idx], nil
+	}
+	if len(expense.Attachments) >= maxAttachmentsPerExpense {
+		return nil, apperror.Newf("添付ファイルは 1 件の支出につき %d 個までです", maxAttachmentsPerExpense)
+	}
+
+	key := attachmentKey(expense.ID, attachmentID)
+	info, err := store.Stat(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, apperror.WithStatus(404, "アップロードされたファイルが見つかりません")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !uploadMatches(info, input) {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("attachment blob delete failed for %s/%s: %v", expense.ID, attachmentID, err)
+		}
+		return nil, apperror.New("アップロードされたファイルが申告されたサイズ・形式と一致しません")
+	}
+
+	attachment := model.Attachment{
+		ID:          attachmentID,
+		FileName:    input.FileName,
+		ContentType: input.ContentType,
+		Size:        info.Size,
+		UploadedBy:  userEmail,
+		UploadedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := addAttachment(ctx, client, expense, attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// uploadMatches はアップロードされた本体が申告どおりのサイズ・Content-Type かどうかを返す
+// （Content-Type を保存しないストアはサイズのみ比べる）
+func uploadMatches(info *blob.ObjectInfo, input *model.AttachmentInput) bool {
+	return info.Size == input.Size && (info.ContentType == "" || info.ContentType == input.ContentType)
+}
+
+// addAttachment は支出に添付ファイルを記録する
+func addAttachment(ctx context.Context, client *dynamo.Client, expense *model.Expense, attachment model.Attachment) error {
+	expense.Attachments = append(expense.Attachments, attachment)
+	expense.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return client.PutExpense(ctx, expense)
+}
+
+// GetAttachments は支出の添付ファイル一覧を返す
+func GetAttachments(ctx context.Context, client *dynamo.Client, expenseID string, userEmail string) ([]model.Attachment, error) {
+	expense, err := getAccessibleExpense(ctx, client, expenseID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if expense.Attachments == nil {
+		return []model.Attachment{}, nil
+	}
+	return expense.Attachments, nil
+}
+
+// GetAttachment は添付ファイルの取得用 URL を返す（署名付き URL を発行できないストアは本体を base64 で返す）
+func GetAttachment(ctx context.Context, client *dynamo.Client, store blob.Store, expenseID string, attachmentID string, userEmail string) (*model.AttachmentDownload, error) {
+	expense, err := getAccessibleExpense(ctx, client, expenseID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	idx := findAttachment(expense.Attachments, attachmentID)
+	if idx < 0 {
+		return nil, apperror.WithStatus(404, "添付ファイルが見つかりません")
+	}
+	result := &model.AttachmentDownload{Attachment: expense.Attachments[idx]}
+	key := attachmentKey(expense.ID, attachmentID)
+
+	url, err := store.PresignGet(ctx, key, presignExpires)
+	if err == nil {
+		result.URL = url
+		return result, nil
+	}
+	if !errors.Is(err, blob.ErrPresignNotSupported) {
+		return nil, err
+	}
+	body, err := store.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, apperror.WithStatus(404, "添付ファイルが見つかりません")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	result.Data = base64.StdEncoding.EncodeToString(data)
+	return result, nil
+}
+
+// DeleteAttachment は添付ファイルを削除する
+func DeleteAttachment(ctx context.Context, client *dynamo.Client, store blob.Store, expenseID string, attachmentID string, userEmail string) error {
+	expense, err := getAccessibleExpense(ctx, client, expenseID, userEmail)
+	if err != nil {
+		return err
+	}
+	idx := findAttachment(expense.Attachments, attachmentID)
+	if idx < 0 {
+		return apperror.WithStatus(404, "添付ファイルが見つかりません")
+	}
+	if err := store.Delete(ctx, attachmentKey(expense.ID, attachmentID)); err != nil {
+		return err
+	}
+	expense.Attachments = append(expense.Attachments[:idx], expense.Attachments[idx+1:]...)
+	expense.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return client.PutExpense(ctx, expense)
+}
+
+// deleteAttachmentBlobs は削除した支出の添付ファイル本体を削除する（失敗はログのみ）
+func deleteAttachmentBlobs(ctx context.Context, store blob.Store, expense *model.Expense) {
+	for _, a := range expense.Attachments {
+		if err := store.Delete(ctx, attachmentKey(expense.ID, a.ID)); err != nil {
+			log.Printf("attachment blob delete failed for %s/%s: %v", expense.ID, a.ID, err)
+		}
+	}
+}
+
+func findAttachment(attachments []model.Attachment, id string) int {
+	for i, a := range attachments {
+		if a.ID == id {
+			return i
+		}
+	}
+	return -1
+}
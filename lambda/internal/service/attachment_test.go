@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"money-diary/internal/blob"
+	"money-diary/internal/model"
+)
+
+func TestValidateAttachmentInput(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        model.AttachmentInput
+		wantFileName string
+		wantErr      bool
+	}{
+		{
+			name:         "画像",
+			input:        model.AttachmentInput{ExpenseID: "e1", FileName: "receipt.jpg", ContentType: "image/jpeg", Size: 1024},
+			wantFileName: "receipt.jpg",
+		},
+		{
+			name:         "パスはファイル名のみに正規化",
+			input:        model.AttachmentInput{ExpenseID: "e1", FileName: `..\dir/../invoice.pdf`, ContentType: "application/pdf", Size: 1024},
+			wantFileName: "invoice.pdf",
+		},
+		{name: "ファイル名なし", input: model.AttachmentInput{ExpenseID: "e1", ContentType: "image/png", Size: 1}, wantErr: true},
+		{name: "未対応の形式", input: model.AttachmentInput{ExpenseID: "e1", FileName: "a.zip", ContentType: "application/zip", Size: 1}, wantErr: true},
+		{name: "サイズ超過", input: model.AttachmentInput{ExpenseID: "e1", FileName: "a.png", ContentType: "image/png", Size: maxAttachmentSize + 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		input := tt.input
+		err := validateAttachmentInput(&input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateAttachmentInput() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && input.FileName != tt.wantFileName {
+			t.Errorf("%s: fileName = %q, want %q", tt.name, input.FileName, tt.wantFileName)
+		}
+	}
+}
+
+func TestUploadMatches(t *testing.T) {
+	input := &model.AttachmentInput{ExpenseID: "e1", FileName: "a.png", ContentType: "image/png", Size: 1024}
+	tests := []struct {
+		name string
+		info blob.ObjectInfo
+		want bool
+	}{
+		{name: "一致", info: blob.ObjectInfo{Size: 1024, ContentType: "image/png"}, want: true},
+		{name: "Content-Type を保存しないストア", info: blob.ObjectInfo{Size: 1024}, want: true},
+		{name: "サイズ違い", info: blob.ObjectInfo{Size: 1025, ContentType: "image/png"}},
+		{name: "形式違い", info: blob.ObjectInfo{Size: 1024, ContentType: "text/html"}},
+	}
+	for _, tt := range tests {
+		if got := uploadMatches(&tt.info, input); got != tt.want {
+			t.Errorf("%s: uploadMatches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
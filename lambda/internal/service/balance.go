@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// GetPayerBalance は支払元の月別残額を返す。
+// trackBalance=true の payer のみ有効。
+// 開始残高 = 残高管理開始日（openingDate）時点の残高。開始日より前の記録は無視する
+// チャージ = 現金チャージカテゴリ（isExpense=false かつ収入を除く）の合計
+// 振替 = type=transfer の振替先なら入金、振替元なら出金
+// 調整 = type=adjustment の残高調整額（符号付き）
+// 支出 = 対象 payer の isExpense=true カテゴリ合計
+// 前月繰越 + 月内チャージ + 振替入金 - 振替出金 - 月内支出 + 調整 = 残額
+// 計算結果は残高スナップショットから取得する（全件スキャンしない）。月の区切りは世帯設定の開始日に従う。
+func GetPayerBalance(ctx context.Context, client *dynamo.Client, payerName string, month string) (*model.PayerBalance, error) {
+	payer, err := findTrackedPayer(ctx, client, payerName)
+	if err != nil {
+		return nil, err
+	}
+	if payer == nil {
+		return &model.PayerBalance{Payer: payerName, Month: month}, nil
+	}
+
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := loadBalanceSnapshots(ctx, client, payer, startDay)
+	if err != nil {
+		return nil, err
+	}
+	return &balancesFromSnapshots(payer, snapshots, []string{month}, startDay)[0], nil
+}
+
+// GetPayerBalanceHistory は支払元の指定期間（from〜to、両端を含む）の月別残額推移を返す。
+// 各月の繰越・チャージ・支出・残額を残高スナップショットからまとめて取得する。
+func GetPayerBalanceHistory(ctx context.Context, client *dynamo.Client, payerName string, from string, to string) (*model.PayerBalanceHistory, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	payer, err := findTrackedPayer(ctx, client, payerName)
+	if err != nil {
+		return nil, err
+	}
+	history := &model.PayerBalanceHistory{Payer: payerName}
+	if payer == nil {
+		history.Months = make([]model.PayerBalance, len(months))
+		for i, ym := range months {
+			history.Months[i] = model.PayerBalance{Payer: payerName, Month: ym}
+		}
+		return history, nil
+	}
+
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := loadBalanceSnapshots(ctx, client, payer, startDay)
+	if err != nil {
+		return nil, err
+	}
+	history.Months = balancesFromSnapshots(payer, snapshots, months, startDay)
+	return history, nil
+}
+
+// ReconcilePayer は実際の残高と記録上の残高を突き合わせ、差額を残高調整として登録する。
+// 差額がない場合は調整を登録しない。
+func ReconcilePayer(ctx context.Context, client *dynamo.Client, input *model.ReconcileInput, userEmail string) (*model.ReconcileResult, error) {
+	if input.Payer == "" || input.Date == "" {
+		return nil, apperror.New("支払元、日付は必須です")
+	}
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		return nil, apperror.New("日付は YYYY-MM-DD 形式で指定してください")
+	}
+
+	payer, err := findTrackedPayer(ctx, client, input.Payer)
+	if err != nil {
+		return nil, err
+	}
+	if payer == nil {
+		return nil, apperror.New("残高管理対象の支払元ではありません")
+	}
+	if payer.OpeningDate != "" && input.Date < payer.OpeningDate {
+		return nil, apperror.New("残高管理開始日より前の日付は指定できません")
+	}
+
+	// 前月までの残高はスナップショットから、当月分は照合日までの記録から計算する
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return nil, err
+	}
+	month := monthOf(input.Date, startDay)
+	snapshots, err := loadBalanceSnapshots(ctx, client, payer, startDay)
+	if err != nil {
+		return nil, err
+	}
+	carryover := balancesFromSnapshots(payer, snapshots, []string{month}, startDay)[0].Carryover
+	monthExpenses, err := queryMonthExpenses(ctx, client, month, startDay)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, "")
+	if err != nil {
+		return nil, err
+	}
+
+	expected := computeMonthBalanceAsOf(monthExpenses, payer, input.Date, carryover, catMaps)
+	result := &model.ReconcileResult{
+		Payer:      payer.Name,
+		Date:       input.Date,
+		Expected:   expected,
+		Actual:     input.Balance,
+		Difference: input.Balance - expected,
+	}
+	if result.Difference == 0 {
+		return result, nil
+	}
+
+	memo := input.Memo
+	if memo == "" {
+		memo = "残高調整"
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	adjustment := model.Expense{
+		ID:        uuid.New().String(),
+		Type:      ExpenseTypeAdjustment,
+		Date:      input.Date,
+		Payer:     payer.Name,
+		Amount:    result.Difference,
+		Memo:      memo,
+		CreatedBy: userEmail,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := client.PutExpense(ctx, &adjustment); err != nil {
+		return nil, err
+	}
+	// 続けて照合した場合に調整前の繰越で二重に調整しないよう、翌月以降の繰越を再計算待ちにする
+	markBalanceSnapshotsDirty(ctx, client, adjustment.Date)
+	result.Adjustment = &adjustment
+	return result, nil
+}
+
+// findTrackedPayer は trackBalance=true のアクティブな支払元を名前で探す（nil = 対象外）
+func findTrackedPayer(ctx context.Context, client *dynamo.Client, payerName string) (*model.Payer, error) {
+	payers, err := getTrackedPayers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payers {
+		if p.Name == payerName {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// chargeCategoryIDs はチャージ対象カテゴリ（isExpense=false かつ「収入」以外）を返す
+func chargeCategoryIDs(catMaps *CategoryMaps) map[string]bool {
+	chargeCategories := make(map[string]bool)
+	for id, isExp := range catMaps.IsExpense {
+		if !isExp && catMaps.Name[id] != "収入" {
+			chargeCategories[id] = true
+		}
+	}
+	return chargeCategories
+}
+
+// balanceCategoryChanged はカテゴリの変更（after=nil は削除）で支払元残額の計算が変わるかどうかを返す。
+// チャージ・支出の判定は isExpense と名前（「収入」）による。マスタに無いカテゴリは支出として扱う。
+func balanceCategoryChanged(before *model.Category, after *model.Category) bool {
+	switch {
+	case before == nil:
+		return false
+	case after == nil:
+		return !before.IsExpense
+	}
+	return before.IsExpense != after.IsExpense || before.Name != after.Name
+}
+
+// payerFlow は1件の記録が支払元残額に与える影響
+type payerFlow struct {
+	charge      int
+	transferIn  int
+	transferOut int
+	spent       int
+	adjustment  int
+}
+
+// net は残額の増減を返す
+func (f payerFlow) net() int {
+	return f.charge + f.transferIn - f.transferOut - f.spent + f.adjustment
+}
+
+// classifyPayerFlow は記録を指定 payer のチャージ・振替・支出・調整に振り分ける
+func classifyPayerFlow(e *model.Expense, payerName string, chargeCategories map[string]bool, catMaps *CategoryMaps) payerFlow {
+	var f payerFlow
+	switch EffectiveExpenseType(e.Type) {
+	case ExpenseTypeTransfer:
+		// 振替（振替先は入金、振替元は出金）
+		if e.ToPayer == payerName {
+			f.transferIn = e.Amount
+		}
+		if e.Payer == payerName {
+			f.transferOut = e.Amount
+		}
+	case ExpenseTypeAdjustment:
+		if e.Payer == payerName {
+			f.adjustment = e.Amount
+		}
+	case ExpenseTypeSettlement:
+		// メンバー間の精算は支払元残額に影響しない
+	case ExpenseTypeRefund:
+		// 返金は返金元の支出（またはチャージ）の取り消しとして扱う
+		original := *e
+		original.Type = ExpenseTypeExpense
+		f = classifyPayerFlow(&original, payerName, chargeCategories, catMaps)
+		f.charge, f.spent = -f.charge, -f.spent
+	default:
+		if chargeCategories[e.Category] {
+			// チャージ（現金チャージ等）
+			f.charge = e.Amount
+		} else if e.Payer == payerName {
+			isExp, ok := catMaps.IsExpense[e.Category]
+			if !ok {
+				isExp = true
+			}
+			if isExp {
+				// 対象 payer の支出
+				f.spent = e.Amount
+			}
+		}
+	}
+	return f
+}
+
+// inBalancePeriod は残高管理開始日以降の記録かどうかを返す
+func inBalancePeriod(e *model.Expense, payer *model.Payer) bool {
+	return payer.OpeningDate == "" || e.Date >= payer.OpeningDate
+}
+
+// computePayerBalance は全支出から指定 payer の月別残額を計算する
+func computePayerBalance(expenses []model.Expense, payer *model.Payer, month string, startDay int, catMaps *CategoryMaps) *model.PayerBalance {
+	history := computePayerBalanceHistory(expenses, payer, []string{month}, startDay, catMaps)
+	return &history[0]
+}
+
+// computePayerBalanceHistory は全支出を1回走査し、指定月（昇順、開始日基準）ごとの残額を計算する
+func computePayerBalanceHistory(expenses []model.Expense, payer *model.Payer, months []string, startDay int, catMaps *CategoryMaps) []model.PayerBalance {
+	if len(months) == 0 {
+		return nil
+	}
+	chargeCategories := chargeCategoryIDs(catMaps)
+	first, last := months[0], months[len(months)-1]
+	opening := openingMonth(payer, startDay)
+
+	// 先頭月より前の増減は繰越にまとめ、範囲内は月別に集計する
+	carryover := 0
+	if opening != "" && opening < first {
+		carryover = payer.OpeningBalance
+	}
+	flows := make(map[string]*model.PayerBalance, len(months))
+	for _, ym := range months {
+		flows[ym] = &model.PayerBalance{Payer: payer.Name, Month: ym}
+	}
+	for i := range expenses {
+		e := &expenses[i]
+		ym := monthOf(e.Date, startDay)
+		if ym > last || !inBalancePeriod(e, payer) {
+			continue
+		}
+
+		f := classifyPayerFlow(e, payer.Name, chargeCategories, catMaps)
+		if ym < first {
+			carryover += f.net()
+			continue
+		}
+		b, ok := flows[ym]
+		if !ok {
+			continue
+		}
+		b.MonthCharge += f.charge
+		b.MonthTransferIn += f.transferIn
+		b.MonthTransferOut += f.transferOut
+		b.MonthSpent += f.spent
+		b.MonthAdjustment += f.adjustment
+	}
+
+	result := make([]model.PayerBalance, len(months))
+	for i, ym := range months {
+		b := flows[ym]
+		if opening != "" && opening == ym {
+			// 開始月は開始残高を繰越として扱う
+			carryover += payer.OpeningBalance
+		}
+		b.Carryover = carryover
+		b.Balance = b.Carryover + b.MonthCharge + b.MonthTransferIn - b.MonthTransferOut - b.MonthSpent + b.MonthAdjustment
+		carryover = b.Balance
+		result[i] = *b
+	}
+	return result
+}
+
+// computeMonthBalanceAsOf は月初の繰越に当月の記録を指定日（当日を含む）まで加算した残額を計算する
+func computeMonthBalanceAsOf(monthExpenses []model.Expense, payer *model.Payer, date string, carryover int, catMaps *CategoryMaps) int {
+	chargeCategories := chargeCategoryIDs(catMaps)
+
+	balance := carryover
+	for i := range monthExpenses {
+		e := &monthExpenses[i]
+		if e.Date > date || !inBalancePeriod(e, payer) {
+			continue
+		}
+		balance += classifyPayerFlow(e, payer.Name, chargeCategories, catMaps).net()
+	}
+	return balance
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 残高スナップショットは trackBalance=true の支払元ごとに月別の増減・繰越・残額を
+// master テーブル（type=balanceSnapshot）に保持する。
+// 記録の変更時は対象月の増減だけを月別クエリで再計算し、以降の月は保存済みの増減から
+// 繰越・残額を連鎖的に更新する。スナップショットが無い月は記録なし（増減 0）として扱う。
+// 記録を変更した API は対象月を再計算待ち（type=balanceDirty）にするだけで、再計算は次の残高の読み出し
+// （loadBalanceSnapshots）か DynamoDB Streams（ProcessExpenseChanges）のどちらか早い方で行う。
+
+// refreshBalanceSnapshotsFor は指定月（開始日基準）の記録変更を全追跡対象支払元の残高スナップショットに反映する
+func refreshBalanceSnapshotsFor(ctx context.Context, client *dynamo.Client, yearMonth string, startDay int) error {
+	payers, err := getTrackedPayers(ctx, client)
+	if err != nil {
+		return err
+	}
+	if len(payers) == 0 {
+		return nil
+	}
+
+	expenses, err := queryMonthExpenses(ctx, client, yearMonth, startDay)
+	if err != nil {
+		return err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, "")
+	if err != nil {
+		return err
+	}
+
+	for i := range payers {
+		payer := &payers[i]
+		snapshots, err := client.GetBalanceSnapshots(ctx, payer.Name)
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			// 未作成の場合は全件から作成する（対象月の変更も含まれる）
+			if _, err := rebuildPayerSnapshots(ctx, client, payer, nil, startDay, catMaps); err != nil {
+				return err
+			}
+			continue
+		}
+		flows := computePayerBalanceHistory(expenses, payer, []string{yearMonth}, startDay, catMaps)[0]
+		changed := applySnapshotFlows(payer, snapshots, flows, startDay)
+		if err := client.BatchPutBalanceSnapshots(ctx, changed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildBalanceSnapshots は全支出から全追跡対象支払元の残高スナップショットを作り直す。
+// 追跡対象外になった支払元や範囲外の月のスナップショットは削除する。
+func RebuildBalanceSnapshots(ctx context.Context, client *dynamo.Client) (map[string]int, error) {
+	payers, err := getTrackedPayers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := GetAllExpenses(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, "")
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int, len(payers))
+	keep := make(map[string]bool)
+	for i := range payers {
+		payer := &payers[i]
+		snapshots, err := rebuildPayerSnapshots(ctx, client, payer, expenses, startDay, catMaps)
+		if err != nil {
+			return result, err
+		}
+		for _, s := range snapshots {
+			keep[s.Payer+"#"+s.Month] = true
+		}
+		result[payer.Name] = len(snapshots)
+		log.Printf("[balanceSnapshot] %s: %d ヶ月分を再構築", payer.Name, len(snapshots))
+	}
+
+	existing, err := client.GetAllBalanceSnapshotKeys(ctx)
+	if err != nil {
+		return result, err
+	}
+	var stale []model.PayerBalance
+	for _, k := range existing {
+		if !keep[k.Payer+"#"+k.Month] {
+			stale = append(stale, k)
+		}
+	}
+	if err := client.BatchDeleteBalanceSnapshots(ctx, stale); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// rebuildBalanceSnapshotsAfter は設定の変更後に全支払元の残高スナップショットを作り直す（失敗はログのみ）
+func rebuildBalanceSnapshotsAfter(ctx context.Context, client *dynamo.Client, reason string) {
+	if _, err := RebuildBalanceSnapshots(ctx, client); err != nil {
+		log.Printf("balanceSnapshot rebuild failed after %s: %v", reason, err)
+	}
+}
+
+// rebuildPayerBalanceSnapshots は指定支払元の残高スナップショットを全件から作り直す（支払元設定の変更時）
+func rebuildPayerBalanceSnapshots(ctx context.Context, client *dynamo.Client, payer *model.Payer) {
+	catMaps, err := GetCategoryMaps(ctx, client, "")
+	if err == nil {
+		var startDay int
+		if startDay, err = loadMonthStartDay(ctx, client, false); err == nil {
+			_, err = rebuildPayerSnapshots(ctx, client, payer, nil, startDay, catMaps)
+		}
+	}
+	if err != nil {
+		log.Printf("balanceSnapshot rebuild failed for %s: %v", payer.Name, err)
+	}
+}
+
+// deletePayerBalanceSnapshots は指定支払元名の残高スナップショットをすべて削除する（失敗はログのみ）
+func deletePayerBalanceSnapshots(ctx context.Context, client *dynamo.Client, payerName string) {
+	snapshots, err := client.GetBalanceSnapshots(ctx, payerName)
+	if err == nil {
+		err = client.BatchDeleteBalanceSnapshots(ctx, snapshots)
+	}
+	if err != nil {
+		log.Printf("balanceSnapshot delete failed for %s: %v", payerName, err)
+	}
+}
+
+// rebuildPayerSnapshots は全支出から指定支払元のスナップショットを作成して保存する。
+// expenses が nil の場合は全件スキャンする。作成範囲は最初の記録月（または開始月）から当月まで。
+func rebuildPayerSnapshots(ctx context.Context, client *dynamo.Client, payer *model.Payer, expenses []model.Expense, startDay int, catMaps *CategoryMaps) ([]model.PayerBalance, error) {
+	if expenses == nil {
+		var err error
+		expenses, err = GetAllExpenses(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	first := monthOf(time.Now().Format("2006-01-02"), startDay)
+	last := first
+	if opening := openingMonth(payer, startDay); opening != "" && opening < first {
+		first = opening
+	}
+	for i := range expenses {
+		e := &expenses[i]
+		if len(e.Date) < 7 || !inBalancePeriod(e, payer) {
+			continue
+		}
+		if ym := monthOf(e.Date, startDay); ym < first {
+			first = ym
+		} else if ym > last {
+			last = ym
+		}
+	}
+
+	months, err := monthsBetween(first, last)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := computePayerBalanceHistory(expenses, payer, months, startDay, catMaps)
+
+	existing, err := client.GetBalanceSnapshots(ctx, payer.Name)
+	if err != nil {
+		return nil, err
+	}
+	var stale []model.PayerBalance
+	for _, s := range existing {
+		if s.Month < first || s.Month > last {
+			stale = append(stale, s)
+		}
+	}
+	if err := client.BatchDeleteBalanceSnapshots(ctx, stale); err != nil {
+		return nil, err
+	}
+	if err := client.BatchPutBalanceSnapshots(ctx, snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// markBalanceSnapshotsDirty は日付の記録を含む月（開始日基準）の残高スナップショットを再計算待ちにする（失敗はログのみ）
+func markBalanceSnapshotsDirty(ctx context.Context, client *dynamo.Client, dates ...string) {
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err == nil {
+		err = markBalanceMonthsDirty(ctx, client, dates, startDay)
+	}
+	if err != nil {
+		log.Printf("balanceSnapshot dirty mark failed for %v: %v", dates, err)
+	}
+}
+
+// markBalanceMonthsDirty は日付の記録を含む月（開始日 startDay 基準）を再計算待ちにする
+func markBalanceMonthsDirty(ctx context.Context, client *dynamo.Client, dates []string, startDay int) error {
+	for _, month := range balanceMonths(dates, startDay) {
+		if err := client.MarkBalanceMonthDirty(ctx, month); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// balanceMonths は日付が属する月（開始日基準）を重複なく昇順で返す
+func balanceMonths(dates []string, startDay int) []string {
+	seen := make(map[string]bool)
+	var months []string
+	for _, date := range dates {
+		if len(date) < 7 {
+			continue
+		}
+		if month := monthOf(date, startDay); !seen[month] {
+			seen[month] = true
+			months = append(months, month)
+		}
+	}
+	sort.Strings(months)
+	return months
+}
+
+// refreshDirtyBalanceSnapshots は再計算待ちの月の残高スナップショットを月の昇順に再計算し、再計算待ちを解除する
+func refreshDirtyBalanceSnapshots(ctx context.Context, client *dynamo.Client, startDay int) error {
+	dirty, err := client.GetDirtyBalanceMonths(ctx)
+	if err != nil || len(dirty) == 0 {
+		return err
+	}
+	months := make([]string, 0, len(dirty))
+	for month := range dirty {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	for _, month := range months {
+		if err := refreshBalanceSnapshotsFor(ctx, client, month, startDay); err != nil {
+			return err
+		}
+		if err := client.ClearBalanceMonthDirty(ctx, month, dirty[month]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBalanceSnapshots は指定支払元のスナップショットを返す。再計算待ちの月は再計算し、未作成の場合は作成する。
+func loadBalanceSnapshots(ctx context.Context, client *dynamo.Client, payer *model.Payer, startDay int) ([]model.PayerBalance, error) {
+	if err := refreshDirtyBalanceSnapshots(ctx, client, startDay); err != nil {
+		return nil, err
+	}
+	snapshots, err := client.GetBalanceSnapshots(ctx, payer.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) > 0 {
+		return snapshots, nil
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, "")
+	if err != nil {
+		return nil, err
+	}
+	return rebuildPayerSnapshots(ctx, client, payer, nil, startDay, catMaps)
+}
+
+// getTrackedPayers は trackBalance=true のアクティブな支払元一覧を返す
+func getTrackedPayers(ctx context.Context, client *dynamo.Client) ([]model.Payer, error) {
+	payers, err := GetPayers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	var tracked []model.Payer
+	for _, p := range payers {
+		if p.TrackBalance {
+			tracked = append(tracked, p)
+		}
+	}
+	return tracked, nil
+}
+
+// openingMonth は支払元の残高管理開始日が属する月を返す（空=全期間）
+func openingMonth(payer *model.Payer, startDay int) string {
+	return monthOf(payer.OpeningDate, startDay)
+}
+
+// applySnapshotFlows は月の増減をスナップショット（月の昇順）に反映し、
+// その月以降の繰越・残額を再計算して保存が必要なスナップショットを返す
+func applySnapshotFlows(payer *model.Payer, snapshots []model.PayerBalance, flows model.PayerBalance, startDay int) []model.PayerBalance {
+	idx := sort.Search(len(snapshots), func(i int) bool { return snapshots[i].Month >= flows.Month })
+	if idx == len(snapshots) || snapshots[idx].Month != flows.Month {
+		snapshots = append(snapshots, model.PayerBalance{})
+		copy(snapshots[idx+1:], snapshots[idx:])
+	}
+	snapshots[idx] = model.PayerBalance{
+		Payer:            payer.Name,
+		Month:            flows.Month,
+		MonthCharge:      flows.MonthCharge,
+		MonthTransferIn:  flows.MonthTransferIn,
+		MonthTransferOut: flows.MonthTransferOut,
+		MonthSpent:       flows.MonthSpent,
+		MonthAdjustment:  flows.MonthAdjustment,
+	}
+
+	opening := openingMonth(payer, startDay)
+	running := 0
+	openingAdded := false
+	var changed []model.PayerBalance
+	for i := range snapshots {
+		s := &snapshots[i]
+		if !openingAdded && opening != "" && opening <= s.Month {
+			running += payer.OpeningBalance
+			openingAdded = true
+		}
+		before := *s
+		s.Carryover = running
+		s.Balance = s.Carryover + s.MonthCharge + s.MonthTransferIn - s.MonthTransferOut - s.MonthSpent + s.MonthAdjustment
+		running = s.Balance
+		if i == idx || (i > idx && *s != before) {
+			changed = append(changed, *s)
+		}
+	}
+	return changed
+}
+
+// balancesFromSnapshots はスナップショット（月の昇順）から指定月（昇順）の残額を返す。
+// スナップショットが無い月は増減 0 として直前の残額を繰り越す。
+func balancesFromSnapshots(payer *model.Payer, snapshots []model.PayerBalance, months []string, startDay int) []model.PayerBalance {
+	opening := openingMonth(payer, startDay)
+	result := make([]model.PayerBalance, len(months))
+	idx := 0
+	running := 0
+	openingAdded := false
+	for i, ym := range months {
+		for idx < len(snapshots) && snapshots[idx].Month < ym {
+			running = snapshots[idx].Balance
+			openingAdded = opening != "" && opening <= snapshots[idx].Month
+			idx++
+		}
+		if idx < len(snapshots) && snapshots[idx].Month == ym {
+			result[i] = snapshots[idx]
+			running = snapshots[idx].Balance
+			openingAdded = opening != "" && opening <= ym
+			idx++
+			continue
+		}
+		if !openingAdded && opening != "" && opening <= ym {
+			running += payer.OpeningBalance
+			openingAdded = true
+		}
+		result[i] = model.PayerBalance{Payer: payer.Name, Month: ym, Carryover: running, Balance: running}
+	}
+	return result
+}
+
+// monthsBetween は "YYYY-MM" の from〜to（両端を含む）の月一覧を昇順で返す（件数制限なし）
+func monthsBetween(from string, to string) ([]string, error) {
+	start, err := time.Parse("2006-01", from)
+	if err != nil {
+		return nil, fmt.Errorf("月の形式が不正です: %s", from)
+	}
+	end, err := time.Parse("2006-01", to)
+	if err != nil {
+		return nil, fmt.Errorf("月の形式が不正です: %s", to)
+	}
+	var months []string
+	for t := start; !t.After(end); t = t.AddDate(0, 1, 0) {
+		months = append(months, t.Format("2006-01"))
+	}
+	return months, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestApplySnapshotFlows(t *testing.T) {
+	payer := &model.Payer{Name: "財布", OpeningBalance: 10000, OpeningDate: "2025-01-01"}
+	expenses := []model.Expense{
+		{ID: "1", Date: "2025-01-10", Payer: "財布", Category: "food", Amount: 1000},
+		{ID: "2", Date: "2025-03-10", Payer: "財布", Category: "food", Amount: 2000},
+	}
+	months := []string{"2025-01", "2025-02", "2025-03"}
+	snapshots := computePayerBalanceHistory(expenses, payer, months, 1, testCategoryMaps())
+
+	// 2月に支出を追加して2月の増減だけを反映する
+	expenses = append(expenses, model.Expense{ID: "3", Date: "2025-02-05", Payer: "財布", Category: "food", Amount: 500})
+	flows := computePayerBalanceHistory(expenses[2:], payer, []string{"2025-02"}, 1, testCategoryMaps())[0]
+	changed := applySnapshotFlows(payer, snapshots, flows, 1)
+
+	if len(changed) != 2 || changed[0].Month != "2025-02" || changed[1].Month != "2025-03" {
+		t.Fatalf("changed = %+v, want 2025-02 and 2025-03", changed)
+	}
+
+	// 全件から再計算した結果と一致すること
+	want := computePayerBalanceHistory(expenses, payer, months, 1, testCategoryMaps())
+	for i := range want {
+		if snapshots[i] != want[i] {
+			t.Errorf("snapshots[%d] = %+v, want %+v", i, snapshots[i], want[i])
+		}
+	}
+}
+
+func TestApplySnapshotFlowsInsertsMonth(t *testing.T) {
+	payer := &model.Payer{Name: "財布"}
+	snapshots := []model.PayerBalance{
+		{Payer: "財布", Month: "2025-01", MonthCharge: 5000, Balance: 5000},
+		{Payer: "財布", Month: "2025-04", Carryover: 5000, MonthSpent: 1000, Balance: 4000},
+	}
+	changed := applySnapshotFlows(payer, snapshots, model.PayerBalance{Month: "2025-02", MonthSpent: 300}, 1)
+	if len(changed) != 2 {
+		t.Fatalf("expected 2 changed snapshots, got %+v", changed)
+	}
+	if changed[0].Month != "2025-02" || changed[0].Carryover != 5000 || changed[0].Balance != 4700 {
+		t.Errorf("inserted snapshot = %+v", changed[0])
+	}
+	if changed[1].Month != "2025-04" || changed[1].Carryover != 4700 || changed[1].Balance != 3700 {
+		t.Errorf("following snapshot = %+v", changed[1])
+	}
+}
+
+func TestBalancesFromSnapshots(t *testing.T) {
+	payer := &model.Payer{Name: "財布", OpeningBalance: 10000, OpeningDate: "2025-03-01"}
+	expenses := []model.Expense{
+		{ID: "1", Date: "2025-03-10", Payer: "財布", Category: "food", Amount: 1000},
+		{ID: "2", Date: "2025-05-10", Payer: "財布", Category: "food", Amount: 2000},
+	}
+	snapshots := computePayerBalanceHistory(expenses, payer, []string{"2025-03", "2025-04", "2025-05"}, 1, testCategoryMaps())
+	// 記録のない月はスナップショットが無くても繰り越されること
+	snapshots = append(snapshots[:1], snapshots[2])
+
+	months := []string{"2025-02", "2025-03", "2025-04", "2025-05", "2025-06"}
+	got := balancesFromSnapshots(payer, snapshots, months, 1)
+	want := computePayerBalanceHistory(expenses, payer, months, 1, testCategoryMaps())
+	for i := range want {
+		if got[i].Month != want[i].Month || got[i].Carryover != want[i].Carryover || got[i].Balance != want[i].Balance {
+			t.Errorf("balances[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPayerSnapshotUpdate(t *testing.T) {
+	before := &model.Payer{ID: "p1", Name: "財布", IsActive: true, TrackBalance: true, OpeningBalance: 1000, OpeningDate: "2025-01-01"}
+	tests := []struct {
+		name        string
+		before      *model.Payer
+		edit        func(p *model.Payer)
+		wantRebuild bool
+		wantStale   string
+	}{
+		{name: "名前の変更は新しい名前で作り直して旧名を削除", before: before, edit: func(p *model.Payer) { p.Name = "お財布" }, wantRebuild: true, wantStale: "財布"},
+		{name: "開始日の変更は作り直す", before: before, edit: func(p *model.Payer) { p.OpeningDate = "2025-02-01" }, wantRebuild: true},
+		{name: "開始残高の変更は作り直す", before: before, edit: func(p *model.Payer) { p.OpeningBalance = 2000 }, wantRebuild: true},
+		{name: "並び順だけの変更はそのまま", before: before, edit: func(p *model.Payer) { p.SortOrder = 3 }},
+		{name: "追跡対象外にしたら削除", before: before, edit: func(p *model.Payer) { p.TrackBalance = false }, wantStale: "財布"},
+		{name: "新規の追跡対象は作成", edit: func(p *model.Payer) {}, wantRebuild: true},
+	}
+	for _, tt := range tests {
+		after := *before
+		tt.edit(&after)
+		rebuild, stale := payerSnapshotUpdate(tt.before, &after)
+		if rebuild != tt.wantRebuild || stale != tt.wantStale {
+			t.Errorf("%s: payerSnapshotUpdate() = %v, %q, want %v, %q", tt.name, rebuild, stale, tt.wantRebuild, tt.wantStale)
+		}
+	}
+}
+
+func TestBalanceCategoryChanged(t *testing.T) {
+	charge := &model.Category{ID: "charge", Name: "チャージ", IsExpense: false}
+	food := &model.Category{ID: "food", Name: "食費", IsExpense: true}
+	renamed := *charge
+	renamed.Name = "収入"
+	toExpense := *charge
+	toExpense.IsExpense = true
+	recolored := *food
+	recolored.Color = "#000"
+
+	tests := []struct {
+		name          string
+		before, after *model.Category
+		want          bool
+	}{
+		{name: "支出区分の変更", before: charge, after: &toExpense, want: true},
+		{name: "名前の変更", before: charge, after: &renamed, want: true},
+		{name: "色だけの変更", before: food, after: &recolored, want: false},
+		{name: "チャージカテゴリの削除", before: charge, want: true},
+		{name: "支出カテゴリの削除", before: food, want: false},
+		{name: "新規", after: food, want: false},
+	}
+	for _, tt := range tests {
+		if got := balanceCategoryChanged(tt.before, tt.after); got != tt.want {
+			t.Errorf("%s: balanceCategoryChanged() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBalanceMonths(t *testing.T) {
+	// 開始日 25 では 3/1・3/10 は 2025-02、3/25 は 2025-03 の月。重複と空の日付は除く
+	got := balanceMonths([]string{"2025-03-25", "2025-03-01", "", "2025-03-10", "2025-02-25"}, 25)
+	want := []string{"2025-02", "2025-03"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("balanceMonths() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func testCategoryMaps() *CategoryMaps {
+	return &CategoryMaps{
+		Name:      map[string]string{"food": "食費", "charge": "現金チャージ", "income": "収入"},
+		IsExpense: map[string]bool{"food": true, "charge": false, "income": false},
+	}
+}
+
+func TestComputePayerBalance(t *testing.T) {
+	expenses := []model.Expense{
+		{ID: "1", Date: "2025-01-10", Payer: "財布", Category: "food", Amount: 1000},
+		{ID: "2", Date: "2025-01-05", Type: "transfer", Payer: "銀行", ToPayer: "財布", Amount: 10000},
+		{ID: "3", Date: "2025-02-01", Type: "transfer", Payer: "銀行", ToPayer: "財布", Amount: 5000},
+		{ID: "4", Date: "2025-02-03", Type: "transfer", Payer: "財布", ToPayer: "Suica", Amount: 2000},
+		{ID: "5", Date: "2025-02-10", Payer: "財布", Category: "food", Amount: 700},
+		{ID: "6", Date: "2025-02-20", Payer: "財布", Category: "income", Amount: 300000},
+		{ID: "7", Date: "2025-03-01", Payer: "財布", Category: "food", Amount: 9999},
+	}
+
+	b := computePayerBalance(expenses, &model.Payer{Name: "財布"}, "2025-02", 1, testCategoryMaps())
+
+	if b.Carryover != 9000 {
+		t.Errorf("Carryover = %d, want 9000", b.Carryover)
+	}
+	if b.MonthTransferIn != 5000 || b.MonthTransferOut != 2000 {
+		t.Errorf("transfer in/out = %d/%d, want 5000/2000", b.MonthTransferIn, b.MonthTransferOut)
+	}
+	if b.MonthSpent != 700 {
+		t.Errorf("MonthSpent = %d, want 700", b.MonthSpent)
+	}
+	if b.Balance != 11300 {
+		t.Errorf("Balance = %d, want 11300", b.Balance)
+	}
+
+	// 振替は振替先の支払元にのみ入金される
+	suica := computePayerBalance(expenses, &model.Payer{Name: "Suica"}, "2025-02", 1, testCategoryMaps())
+	if suica.MonthTransferIn != 2000 || suica.Balance != 2000 {
+		t.Errorf("Suica balance = %+v, want transferIn=2000 balance=2000", suica)
+	}
+}
+
+func TestComputePayerBalanceWithOpening(t *testing.T) {
+	expenses := []model.Expense{
+		{ID: "1", Date: "2024-12-31", Payer: "財布", Category: "food", Amount: 5000},
+		{ID: "2", Date: "2025-01-10", Payer: "財布", Category: "food", Amount: 1000},
+		{ID: "3", Date: "2025-01-20", Type: "adjustment", Payer: "財布", Amount: -300},
+		{ID: "4", Date: "2025-02-05", Payer: "財布", Category: "food", Amount: 200},
+	}
+	payer := &model.Payer{Name: "財布", OpeningBalance: 20000, OpeningDate: "2025-01-01"}
+
+	// 開始日より前の月は残高なし
+	before := computePayerBalance(expenses, payer, "2024-12", 1, testCategoryMaps())
+	if before.Balance != 0 {
+		t.Errorf("balance before opening = %d, want 0", before.Balance)
+	}
+
+	jan := computePayerBalance(expenses, payer, "2025-01", 1, testCategoryMaps())
+	if jan.Carryover != 20000 || jan.MonthSpent != 1000 || jan.MonthAdjustment != -300 || jan.Balance != 18700 {
+		t.Errorf("January balance = %+v, want carryover=20000 spent=1000 adjustment=-300 balance=18700", jan)
+	}
+
+	feb := computePayerBalance(expenses, payer, "2025-02", 1, testCategoryMaps())
+	if feb.Carryover != 18700 || feb.Balance != 18500 {
+		t.Errorf("February balance = %+v, want carryover=18700 balance=18500", feb)
+	}
+
+	if got := computeMonthBalanceAsOf(expenses, payer, "2025-01-10", 20000, testCategoryMaps()); got != 19000 {
+		t.Errorf("balance as of 2025-01-10 = %d, want 19000", got)
+	}
+}
+
+func TestComputePayerBalanceHistory(t *testing.T) {
+	expenses := []model.Expense{
+		{ID: "1", Date: "2025-01-05", Type: "transfer", Payer: "銀行", ToPayer: "財布", Amount: 10000},
+		{ID: "2", Date: "2025-01-10", Payer: "財布", Category: "food", Amount: 1000},
+		{ID: "3", Date: "2025-03-10", Payer: "財布", Category: "food", Amount: 2000},
+		{ID: "4", Date: "2025-04-01", Payer: "財布", Category: "food", Amount: 500},
+	}
+	payer := &model.Payer{Name: "財布"}
+
+	history := computePayerBalanceHistory(expenses, payer, []string{"2025-02", "2025-03", "2025-04"}, 1, testCategoryMaps())
+	if len(history) != 3 {
+		t.Fatalf("expected 3 months, got %d", len(history))
+	}
+	want := []struct {
+		month     string
+		carryover int
+		spent     int
+		balance   int
+	}{
+		{"2025-02", 9000, 0, 9000},
+		{"2025-03", 9000, 2000, 7000},
+		{"2025-04", 7000, 500, 6500},
+	}
+	for i, w := range want {
+		h := history[i]
+		if h.Month != w.month || h.Carryover != w.carryover || h.MonthSpent != w.spent || h.Balance != w.balance {
+			t.Errorf("history[%d] = %+v, want %+v", i, h, w)
+		}
+	}
+
+	// 単月計算と一致すること
+	single := computePayerBalance(expenses, payer, "2025-03", 1, testCategoryMaps())
+	if *single != history[1] {
+		t.Errorf("computePayerBalance = %+v, want %+v", *single, history[1])
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 事業按分は、支出のうち本人の負担分（割り勘は負担額、それ以外は本人が支払った全額）に
+// 事業割合を掛けた金額を勘定科目別に集計する。事業割合は支出ごとの指定、なければカテゴリの設定を使う。
+
+// GetBusinessReport は年間（1〜12 月）の事業按分レポートを返す
+func GetBusinessReport(ctx context.Context, client *dynamo.Client, year string, userEmail string) (*model.BusinessReport, error) {
+	if _, err := time.Parse("2006", year); err != nil {
+		return nil, apperror.New("year は YYYY 形式で指定してください")
+	}
+	months, err := monthRange(year+"-01", year+"-12")
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, months, 1)
+	if err != nil {
+		return nil, err
+	}
+	expenses := flattenMonths(months, byMonth)
+	hasSplit := false
+	for _, e := range expenses {
+		hasSplit = hasSplit || e.Split != nil
+	}
+	var members []string
+	if hasSplit {
+		if members, err = GetMembers(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	return buildBusinessReport(year, expenses, members, catMaps, userEmail), nil
+}
+
+// buildBusinessReport は支出から本人の事業按分の明細と勘定科目別の合計を作る
+func buildBusinessReport(year string, expenses []model.Expense, members []string, catMaps *CategoryMaps, userEmail string) *model.BusinessReport {
+	report := &model.BusinessReport{Year: year, ByAccount: []model.BusinessAccount{}, Lines: []model.BusinessLine{}}
+	byAccount := make(map[string]*model.BusinessAccount)
+	for i := range expenses {
+		for _, line := range businessLines(&expenses[i], members, catMaps, userEmail) {
+			report.Lines = append(report.Lines, line)
+			report.Total += line.BusinessAmount
+			a, ok := byAccount[line.Account]
+			if !ok {
+				a = &model.BusinessAccount{Account: line.Account}
+				byAccount[line.Account] = a
+			}
+			a.Amount += line.Amount
+			a.BusinessAmount += line.BusinessAmount
+			a.Count++
+		}
+	}
+
+	sort.SliceStable(report.Lines, func(i, j int) bool { return report.Lines[i].Date < report.Lines[j].Date })
+	for _, a := range byAccount {
+		report.ByAccount = append(report.ByAccount, *a)
+	}
+	sort.Slice(report.ByAccount, func(i, j int) bool { return report.ByAccount[i].Account < report.ByAccount[j].Account })
+	return report
+}
+
+// businessLines は支出の本人負担分を明細のカテゴリごとに按分し、事業割合のあるものを返す（返金はマイナス）
+func businessLines(e *model.Expense, members []string, catMaps *CategoryMaps, userEmail string) []model.BusinessLine {
+	if !IsExpenseEntry(e) && !IsRefund(e) {
+		return nil
+	}
+	amount := 0
+	switch {
+	case e.Split != nil:
+		amount = computeSplitShares(e.Amount, e.Split, members)[userEmail]
+	case expensePaidBy(e) == userEmail:
+		amount = e.Amount
+	}
+	if amount == 0 {
+		return nil
+	}
+	if IsRefund(e) {
+		amount = -amount
+	}
+
+	lines := expenseLines(e)
+	if IsRefund(e) {
+		lines = []model.LineItem{{Category: e.Category, Amount: e.Amount}}
+	}
+	weights := make([]int, len(lines))
+	for i, li := range lines {
+		weights[i] = li.Amount
+	}
+	// 他人が登録した公開以外の支出は内容を伏せる
+	place, memo := e.Place, e.Memo
+	if e.CreatedBy != userEmail && EffectiveVisibility(e.Visibility) != VisibilityPublic {
+		place, memo = "", ""
+	}
+
+	var result []model.BusinessLine
+	for i, share := range prorate(amount, weights) {
+		category := lines[i].Category
+		ratio := catMaps.BusinessRatio[category]
+		if e.BusinessRatio != nil {
+			ratio = *e.BusinessRatio
+		}
+		if ratio == 0 || share == 0 {
+			continue
+		}
+		name := catMaps.Name[category]
+		if name == "" {
+			name = category
+		}
+		account := catMaps.ExpenseAccount[category]
+		if account == "" {
+			account = name
+		}
+		result = append(result, model.BusinessLine{
+			ExpenseID:      e.ID,
+			Date:           e.Date,
+			CategoryID:     category,
+			Category:       name,
+			Account:        account,
+			Place:          place,
+			Memo:           memo,
+			Amount:         share,
+			Ratio:          ratio,
+			BusinessAmount: share * ratio / 100,
+		})
+	}
+	return result
+}
+
+// expensePaidBy は支出を支払ったメンバーを返す（空=登録者）
+func expensePaidBy(e *model.Expense) string {
+	if e.PaidBy != "" {
+		return e.PaidBy
+	}
+	return e.CreatedBy
+}
+
+// ExportBusinessReport は事業按分レポートを CSV（UTF-8 BOM 付き、勘定科目ごとに小計）で返す
+func ExportBusinessReport(ctx context.Context, client *dynamo.Client, year string, userEmail string) (*model.CSVExport, error) {
+	report, err := GetBusinessReport(ctx, client, year, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	content, err := businessReportCSV(report)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CSVExport{FileName: "business-" + year + ".csv", Content: content}, nil
+}
+
+// businessReportCSV は明細を勘定科目・日付順に並べ、勘定科目ごとの小計と総計を付けた CSV を返す
+func businessReportCSV(report *model.BusinessReport) (string, error) {
+	lines := append([]model.BusinessLine(nil), report.Lines...)
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Account < lines[j].Account })
+	subtotals := make(map[string]model.BusinessAccount, len(report.ByAccount))
+	for _, a := range report.ByAccount {
+		subtotals[a.Account] = a
+	}
+
+	records := [][]string{{"勘定科目", "日付", "カテゴリ", "場所", "メモ", "支出額", "事業割合(%)", "事業按分額"}}
+	for i, l := range lines {
+		records = append(records, []string{
+			l.Account, l.Date, l.Category, l.Place, l.Memo,
+			strconv.Itoa(l.Amount), strconv.Itoa(l.Ratio), strconv.Itoa(l.BusinessAmount),
+		})
+		if i == len(lines)-1 || lines[i+1].Account != l.Account {
+			a := subtotals[l.Account]
+			records = append(records, []string{l.Account + " 小計", "", "", "", "", strconv.Itoa(a.Amount), "", strconv.Itoa(a.BusinessAmount)})
+		}
+	}
+	records = append(records, []string{"合計", "", "", "", "", "", "", strconv.Itoa(report.Total)})
+	return csvWithBOM(records)
+}
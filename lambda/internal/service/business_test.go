@@ -0,0 +1,55 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestBuildBusinessReport(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:           map[string]string{"rent": "家賃", "net": "通信", "food": "食費"},
+		BusinessRatio:  map[string]int{"rent": 30, "net": 50},
+		ExpenseAccount: map[string]string{"rent": "地代家賃", "net": "通信費"},
+	}
+	members := []string{"a@example.com", "b@example.com"}
+	ratio40 := 40
+	expenses := []model.Expense{
+		// 家賃を a・b で折半（a の負担 50,000 の 30%）
+		{ID: "e1", Date: "2025-01-25", Category: "rent", Amount: 100000, Split: &model.Split{Method: SplitEqual}, CreatedBy: "b@example.com", Visibility: VisibilityPrivate, Memo: "1月分"},
+		// 支出ごとの事業割合はカテゴリの設定より優先
+		{ID: "e2", Date: "2025-01-10", Category: "net", Amount: 5000, BusinessRatio: &ratio40, CreatedBy: "a@example.com"},
+		// 返金は差し引く
+		{ID: "e3", Date: "2025-01-20", Type: ExpenseTypeRefund, Category: "net", Amount: 1000, BusinessRatio: &ratio40, CreatedBy: "a@example.com"},
+		// 事業割合のないカテゴリ・他人の支出は対象外
+		{ID: "e4", Date: "2025-01-11", Category: "food", Amount: 3000, CreatedBy: "a@example.com"},
+		{ID: "e5", Date: "2025-01-12", Category: "net", Amount: 4000, CreatedBy: "b@example.com"},
+	}
+
+	got := buildBusinessReport("2025", expenses, members, catMaps, "a@example.com")
+	wantAccounts := []model.BusinessAccount{
+		{Account: "地代家賃", Amount: 50000, BusinessAmount: 15000, Count: 1},
+		{Account: "通信費", Amount: 4000, BusinessAmount: 1600, Count: 2},
+	}
+	if !reflect.DeepEqual(got.ByAccount, wantAccounts) {
+		t.Errorf("ByAccount = %+v, want %+v", got.ByAccount, wantAccounts)
+	}
+	if got.Total != 16600 {
+		t.Errorf("Total = %d, want 16600", got.Total)
+	}
+	if len(got.Lines) != 3 || got.Lines[0].ExpenseID != "e2" || got.Lines[2].Memo != "" {
+		t.Errorf("Lines = %+v, want 日付順・他人の private はメモなし", got.Lines)
+	}
+
+	csv, err := businessReportCSV(got)
+	if err != nil {
+		t.Fatalf("businessReportCSV() error = %v", err)
+	}
+	for _, want := range []string{"地代家賃 小計,,,,,50000,,15000", "通信費,2025-01-20,通信,,,-1000,40,-400", "合計,,,,,,,16600"} {
+		if !strings.Contains(csv, want) {
+			t.Errorf("CSV に %q が含まれていません:\n%s", want, csv)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// GetCardStatements はクレジットカードの支払月 from〜to（両端を含む）の請求を返す。
+// 各請求は締め期間（前回締め日の翌日〜締め日）の支出合計で、支払日に引き落とし元から支払われる。
+// 請求額は全員分の支出を含み、明細はリクエスト者に応じて visibility フィルタを適用する。
+func GetCardStatements(ctx context.Context, client *dynamo.Client, payerName string, from string, to string, userEmail string) ([]model.CardStatement, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	payers, err := GetPayers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	var card *model.Payer
+	for _, p := range payers {
+		if p.Name == payerName {
+			card = &p
+			break
+		}
+	}
+	if card == nil || card.ClosingDay == 0 {
+		return nil, apperror.New("締め日が設定された支払元を指定してください")
+	}
+
+	catMaps, err := GetCategoryMaps(ctx, client, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// 締め期間にかかるカレンダー月の支出を重複なくまとめて取得する
+	periods := make([]cardPeriod, len(months))
+	var calendarMonths []string
+	seenMonths := make(map[string]bool)
+	for i, ym := range months {
+		periods[i] = cardBillingPeriod(card, ym)
+		for _, m := range []string{periods[i].start[:7], periods[i].end[:7]} {
+			if !seenMonths[m] {
+				seenMonths[m] = true
+				calendarMonths = append(calendarMonths, m)
+			}
+		}
+	}
+	monthExpenses, err := fetchMonths(ctx, calendarMonths, client.QueryExpensesByMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make([]model.CardStatement, len(periods))
+	for i, period := range periods {
+		var items []model.Expense
+		seen := make(map[string]bool)
+		for _, m := range []string{period.start[:7], period.end[:7]} {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			for _, e := range monthExpenses[m] {
+				if e.Date >= period.start && e.Date <= period.end && isCardCharge(&e, card.Name, catMaps) {
+					items = append(items, e)
+				}
+			}
+		}
+		sort.Slice(items, func(a, b int) bool {
+			return items[a].Date < items[b].Date
+		})
+
+		st := model.CardStatement{
+			Payer:         card.Name,
+			PeriodStart:   period.start,
+			PeriodEnd:     period.end,
+			PaymentDate:   period.paymentDate,
+			PaymentSource: card.PaymentSource,
+			Count:         len(items),
+			Expenses:      FilterExpensesForUser(items, userEmail),
+		}
+		for _, e := range items {
+			if IsRefund(&e) {
+				// 返金は請求額から差し引く
+				st.Amount -= e.Amount
+				continue
+			}
+			st.Amount += e.Amount
+		}
+		statements[i] = st
+	}
+	return statements, nil
+}
+
+// isCardCharge はカードの請求対象となる支出・返金かどうかを返す（振替・残高調整・収入等は対象外）
+func isCardCharge(e *model.Expense, cardName string, catMaps *CategoryMaps) bool {
+	if e.Payer != cardName || (!IsExpenseEntry(e) && !IsRefund(e)) {
+		return false
+	}
+	isExp, ok := catMaps.IsExpense[e.Category]
+	return !ok || isExp
+}
+
+// cardPeriod はカードの締め期間と支払日（いずれも "YYYY-MM-DD"）
+type cardPeriod struct {
+	start       string
+	end         string
+	paymentDate string
+}
+
+// cardBillingPeriod は支払月 paymentMonth（"YYYY-MM"）に支払われる締め期間を返す。
+// 締め日・支払日が月末を超える場合は月末日に丸める（31 = 月末締め）。
+func cardBillingPeriod(card *model.Payer, paymentMonth string) cardPeriod {
+	pm, _ := time.Parse("2006-01", paymentMonth)
+	offset := card.PaymentMonthOffset
+	if offset == 0 {
+		offset = 1
+	}
+	closingMonth := pm.AddDate(0, -offset, 0)
+	prevClosingMonth := closingMonth.AddDate(0, -1, 0)
+
+	end := dayInMonth(closingMonth, card.ClosingDay)
+	start := dayInMonth(prevClosingMonth, card.ClosingDay).AddDate(0, 0, 1)
+	payment := dayInMonth(pm, card.PaymentDay)
+	return cardPeriod{
+		start:       start.Format("2006-01-02"),
+		end:         end.Format("2006-01-02"),
+		paymentDate: payment.Format("2006-01-02"),
+	}
+}
+
+// dayInMonth は月初 month の day 日を返す（月末を超える場合は月末日）
+func dayInMonth(month time.Time, day int) time.Time {
+	lastDay := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestCardBillingPeriod(t *testing.T) {
+	tests := []struct {
+		name         string
+		card         model.Payer
+		paymentMonth string
+		want         cardPeriod
+	}{
+		{
+			name:         "15日締め翌月10日払い",
+			card:         model.Payer{ClosingDay: 15, PaymentDay: 10},
+			paymentMonth: "2025-03",
+			want:         cardPeriod{start: "2025-01-16", end: "2025-02-15", paymentDate: "2025-03-10"},
+		},
+		{
+			name:         "月末締め翌月27日払い",
+			card:         model.Payer{ClosingDay: 31, PaymentDay: 27},
+			paymentMonth: "2025-03",
+			want:         cardPeriod{start: "2025-02-01", end: "2025-02-28", paymentDate: "2025-03-27"},
+		},
+		{
+			name:         "月末締め翌々月4日払い（年跨ぎ）",
+			card:         model.Payer{ClosingDay: 31, PaymentDay: 4, PaymentMonthOffset: 2},
+			paymentMonth: "2025-02",
+			want:         cardPeriod{start: "2024-12-01", end: "2024-12-31", paymentDate: "2025-02-04"},
+		},
+		{
+			name:         "支払日が月末を超える",
+			card:         model.Payer{ClosingDay: 15, PaymentDay: 31},
+			paymentMonth: "2024-02",
+			want:         cardPeriod{start: "2023-12-16", end: "2024-01-15", paymentDate: "2024-02-29"},
+		},
+	}
+	for _, tt := range tests {
+		got := cardBillingPeriod(&tt.card, tt.paymentMonth)
+		if got != tt.want {
+			t.Errorf("%s: cardBillingPeriod() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePaymentSource(t *testing.T) {
+	payers := []model.Payer{{ID: "p1", Name: "銀行"}, {ID: "p2", Name: "カード"}}
+	tests := []struct {
+		name    string
+		source  string
+		id      string
+		wantErr bool
+	}{
+		{name: "未指定", source: ""},
+		{name: "登録済みの支払元", source: "銀行", id: "p2"},
+		{name: "未登録の支払元", source: "口座", id: "p2", wantErr: true},
+		{name: "更新中の支払元自身", source: "カード", id: "p2", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validatePaymentSource(&model.PayerInput{Name: "カード", PaymentSource: tt.source}, tt.id, payers)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validatePaymentSource() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
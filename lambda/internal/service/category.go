@@ -39,10 +39,21 @@ func GetAllCategories(ctx context.Context, client *dynamo.Client, userEmail stri
 	return filterCategoriesForUser(all, userEmail), nil
 }
 
+// validateCategoryInput はカテゴリ登録・更新のリクエストを検証する
+func validateCategoryInput(input *model.CategoryInput) *apperror.AppError {
+	if input.Name == "" {
+		return apperror.New("名前は必須です")
+	}
+	if input.BusinessRatio < 0 || input.BusinessRatio > 100 {
+		return apperror.New("事業割合は 0〜100 で指定してください")
+	}
+	return nil
+}
+
 // CreateCategory はカテゴリを作成する
 func CreateCategory(ctx context.Context, client *dynamo.Client, input *model.CategoryInput, userEmail string) (*model.Category, error) {
-	if input.Name == "" {
-		return nil, apperror.New("名前は必須です")
+	if err := validateCategoryInput(input); err != nil {
+		return nil, err
 	}
 	cat := &model.Category{
 		ID:                   uuid.New().String(),
@@ -54,6 +65,9 @@ func CreateCategory(ctx context.Context, client *dynamo.Client, input *model.Cat
 		ExcludeFromBreakdown: input.ExcludeFromBreakdown,
 		ExcludeFromSummary:   input.ExcludeFromSummary,
 		OwnerEmail:           input.OwnerEmail,
+		BusinessRatio:        input.BusinessRatio,
+		ExpenseAccount:       input.ExpenseAccount,
+		IsMedical:            input.IsMedical,
 	}
 	if err := client.PutCategory(ctx, cat); err != nil {
 		return nil, err
@@ -63,8 +77,8 @@ func CreateCategory(ctx context.Context, client *dynamo.Client, input *model.Cat
 
 // UpdateCategory はカテゴリを更新する（個人カテゴリは所有者のみ更新可能）
 func UpdateCategory(ctx context.Context, client *dynamo.Client, id string, input *model.CategoryInput, userEmail string) (*model.Category, error) {
-	if input.Name == "" {
-		return nil, apperror.New("名前は必須です")
+	if err := validateCategoryInput(input); err != nil {
+		return nil, err
 	}
 
 	// 既存カテゴリを取得して権限チェック
@@ -72,10 +86,15 @@ func UpdateCategory(ctx context.Context, client *dynamo.Client, id string, input
 	if err != nil {
 		return nil, err
 	}
-	for _, c := range existing {
-		if c.ID == id && c.OwnerEmail != "" && c.OwnerEmail != userEmail {
+	var before *model.Category
+	for i, c := range existing {
+		if c.ID != id {
+			continue
+		}
+		if c.OwnerEmail != "" && c.OwnerEmail != userEmail {
 			return nil, apperror.New("他人の個人カテゴリは更新できません")
 		}
+		before = &existing[i]
 	}
 
 	cat := &model.Category{
@@ -88,10 +107,16 @@ func UpdateCategory(ctx context.Context, client *dynamo.Client, id string, input
 		ExcludeFromBreakdown: input.ExcludeFromBreakdown,
 		ExcludeFromSummary:   input.ExcludeFromSummary,
 		OwnerEmail:           input.OwnerEmail,
+		BusinessRatio:        input.BusinessRatio,
+		ExpenseAccount:       input.ExpenseAccount,
+		IsMedical:            input.IsMedical,
 	}
 	if err := client.PutCategory(ctx, cat); err != nil {
 		return nil, err
 	}
+	if balanceCategoryChanged(before, cat) {
+		rebuildBalanceSnapshotsAfter(ctx, client, "category update")
+	}
 	return cat, nil
 }
 
@@ -101,12 +126,23 @@ func DeleteCategory(ctx context.Context, client *dynamo.Client, id string, userE
 	if err != nil {
 		return err
 	}
-	for _, c := range existing {
-		if c.ID == id && c.OwnerEmail != "" && c.OwnerEmail != userEmail {
+	var before *model.Category
+	for i, c := range existing {
+		if c.ID != id {
+			continue
+		}
+		if c.OwnerEmail != "" && c.OwnerEmail != userEmail {
 			return apperror.New("他人の個人カテゴリは削除できません")
 		}
+		before = &existing[i]
+	}
+	if err := client.DeleteCategory(ctx, id); err != nil {
+		return err
+	}
+	if balanceCategoryChanged(before, nil) {
+		rebuildBalanceSnapshotsAfter(ctx, client, "category delete")
 	}
-	return client.DeleteCategory(ctx, id)
+	return nil
 }
 
 // CategoryMaps はカテゴリの各種マップをまとめて保持する（キーはカテゴリID）
@@ -118,6 +154,9 @@ type CategoryMaps struct {
 	ExcludeFromBreakdown map[string]bool   // カテゴリID→内訳から除外
 	ExcludeFromSummary   map[string]bool   // カテゴリID→集計から完全除外
 	OwnerEmail           map[string]string // カテゴリID→ownerEmail
+	BusinessRatio        map[string]int    // カテゴリID→事業割合（%）
+	ExpenseAccount       map[string]string // カテゴリID→勘定科目
+	IsMedical            map[string]bool   // カテゴリID→医療費控除の対象
 }
 
 // GetCategoryMaps はカテゴリの各種マップをまとめて返す（キーはカテゴリID）。
@@ -137,6 +176,9 @@ func GetCategoryMaps(ctx context.Context, client *dynamo.Client, userEmail strin
 		ExcludeFromBreakdown: make(map[string]bool, len(categories)),
 		ExcludeFromSummary:   make(map[string]bool, len(categories)),
 		OwnerEmail:           make(map[string]string, len(categories)),
+		BusinessRatio:        make(map[string]int, len(categories)),
+		ExpenseAccount:       make(map[string]string, len(categories)),
+		IsMedical:            make(map[string]bool, len(categories)),
 	}
 	for _, c := range categories {
 		cm.Name[c.ID] = c.Name
@@ -146,6 +188,9 @@ func GetCategoryMaps(ctx context.Context, client *dynamo.Client, userEmail strin
 		cm.ExcludeFromBreakdown[c.ID] = c.ExcludeFromBreakdown
 		cm.ExcludeFromSummary[c.ID] = c.ExcludeFromSummary
 		cm.OwnerEmail[c.ID] = c.OwnerEmail
+		cm.BusinessRatio[c.ID] = c.BusinessRatio
+		cm.ExpenseAccount[c.ID] = c.ExpenseAccount
+		cm.IsMedical[c.ID] = c.IsMedical
 	}
 	return cm, nil
 }
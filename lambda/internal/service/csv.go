@@ -0,0 +1,16 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+// csvWithBOM はレコードを CSV にする（Excel で文字化けしないよう UTF-8 BOM を付ける）
+func csvWithBOM(records [][]string) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
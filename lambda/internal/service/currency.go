@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// BaseCurrency は世帯の基準通貨。Expense.Amount は常にこの通貨で保持する
+const BaseCurrency = "JPY"
+
+// 外貨建ての支出は登録時に為替レートで基準通貨に換算して Amount に保存し、
+// 元の通貨・金額・使用したレートを併せて保持する。
+// 集計・残額は Amount のみを使うため、レートを後から変更しても登録済みの支出は再換算しない。
+
+// GetExchangeRates は為替レート一覧を返す（currency 指定時はその通貨のみ）
+func GetExchangeRates(ctx context.Context, client *dynamo.Client, currency string) ([]model.ExchangeRate, error) {
+	rates, err := client.GetExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currency = normalizeCurrency(currency)
+	if currency == "" {
+		return rates, nil
+	}
+	filtered := make([]model.ExchangeRate, 0, len(rates))
+	for _, r := range rates {
+		if r.Currency == currency {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// PutExchangeRate は為替レートを登録する（同じ通貨・適用開始日は上書き）
+func PutExchangeRate(ctx context.Context, client *dynamo.Client, input *model.ExchangeRateInput) (*model.ExchangeRate, error) {
+	if err := validateExchangeRateInput(input); err != nil {
+		return nil, err
+	}
+	r := exchangeRateFromInput(input, time.Now().UTC().Format(time.RFC3339))
+	if err := client.PutExchangeRate(ctx, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ImportExchangeRates は為替レートを一括登録する。全件バリデーション後に保存する。
+func ImportExchangeRates(ctx context.Context, client *dynamo.Client, inputs []model.ExchangeRateInput) (int, error) {
+	if len(inputs) == 0 {
+		return 0, apperror.New("登録する為替レートがありません")
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	rates := make([]model.ExchangeRate, len(inputs))
+	for i := range inputs {
+		if err := validateExchangeRateInput(&inputs[i]); err != nil {
+			return 0, apperror.Newf("%d件目: %s", i+1, err.Message)
+		}
+		rates[i] = exchangeRateFromInput(&inputs[i], now)
+	}
+	if err := client.BatchPutExchangeRates(ctx, rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// DeleteExchangeRate は為替レートを削除する
+func DeleteExchangeRate(ctx context.Context, client *dynamo.Client, id string) error {
+	return client.DeleteExchangeRate(ctx, id)
+}
+
+func validateExchangeRateInput(input *model.ExchangeRateInput) *apperror.AppError {
+	input.Currency = normalizeCurrency(input.Currency)
+	if !isCurrencyCode(input.Currency) || input.Currency == BaseCurrency {
+		return apperror.New("通貨は基準通貨以外の3文字の通貨コード（例: USD）で指定してください")
+	}
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		return apperror.New("適用開始日は YYYY-MM-DD 形式で指定してください")
+	}
+	if input.Rate <= 0 {
+		return apperror.New("レートは0より大きい値で指定してください")
+	}
+	return nil
+}
+
+func exchangeRateFromInput(input *model.ExchangeRateInput, now string) model.ExchangeRate {
+	return model.ExchangeRate{
+		ID:        dynamo.ExchangeRateID(input.Currency, input.Date),
+		Currency:  input.Currency,
+		Date:      input.Date,
+		Rate:      input.Rate,
+		UpdatedAt: now,
+	}
+}
+
+// normalizeCurrency は通貨コードを大文字に正規化する
+func normalizeCurrency(c string) string {
+	return strings.ToUpper(strings.TrimSpace(c))
+}
+
+// isCurrencyCode は ISO 4217 形式（英字3文字）かどうかを返す
+func isCurrencyCode(c string) bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, ch := range c {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isForeignCurrency は基準通貨以外の通貨かどうかを返す
+func isForeignCurrency(c string) bool {
+	c = normalizeCurrency(c)
+	return c != "" && c != BaseCurrency
+}
+
+// loadExchangeRatesFor は外貨建ての入力がある場合のみ為替レートを取得する
+func loadExchangeRatesFor(ctx context.Context, client *dynamo.Client, inputs ...*model.ExpenseInput) ([]model.ExchangeRate, error) {
+	for _, input := range inputs {
+		if isForeignCurrency(input.Currency) {
+			return client.GetExchangeRates(ctx)
+		}
+	}
+	return nil, nil
+}
+
+// findExchangeRate は指定日時点で有効なレート（適用開始日が指定日以前で最新のもの）を返す
+func findExchangeRate(rates []model.ExchangeRate, currency string, date string) *model.ExchangeRate {
+	var found *model.ExchangeRate
+	for i := range rates {
+		r := &rates[i]
+		if r.Currency != currency || r.Date > date {
+			continue
+		}
+		if found == nil || r.Date > found.Date {
+			found = r
+		}
+	}
+	return found
+}
+
+// applyExchangeRate は外貨建ての入力を基準通貨に換算し、使用したレートを返す。
+// amount が指定済みの場合（カード明細の円換算額など）はその値を優先し、実効レートを返す。
+// 基準通貨の場合は通貨・元金額を空にして 0 を返す。
+func applyExchangeRate(input *model.ExpenseInput, rates []model.ExchangeRate) (float64, *apperror.AppError) {
+	input.Currency = normalizeCurrency(input.Currency)
+	if !isForeignCurrency(input.Currency) {
+		input.Currency = ""
+		input.OriginalAmount = 0
+		return 0, nil
+	}
+	if !isCurrencyCode(input.Currency) {
+		return 0, apperror.New("通貨は3文字の通貨コード（例: USD）で指定してください")
+	}
+	if input.OriginalAmount <= 0 {
+		return 0, apperror.New("外貨建ての場合は元の金額（0より大きい値）は必須です")
+	}
+	if input.Amount > 0 {
+		return float64(input.Amount) / input.OriginalAmount, nil
+	}
+	if input.Date == "" {
+		// 日付なしは validateExpenseInput で検出する
+		return 0, nil
+	}
+	r := findExchangeRate(rates, input.Currency, input.Date)
+	if r == nil {
+		return 0, apperror.Newf("%s の %s 時点の為替レートが登録されていません", input.Currency, input.Date)
+	}
+	input.Amount = int(math.Round(input.OriginalAmount * r.Rate))
+	return r.Rate, nil
+}
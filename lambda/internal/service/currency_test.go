@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestApplyExchangeRate(t *testing.T) {
+	rates := []model.ExchangeRate{
+		{Currency: "USD", Date: "2025-01-01", Rate: 150},
+		{Currency: "USD", Date: "2025-02-01", Rate: 155.5},
+		{Currency: "EUR", Date: "2025-01-01", Rate: 160},
+	}
+
+	tests := []struct {
+		name       string
+		input      model.ExpenseInput
+		wantAmount int
+		wantRate   float64
+		wantErr    bool
+	}{
+		{
+			name:       "基準通貨",
+			input:      model.ExpenseInput{Date: "2025-01-10", Amount: 1000, Currency: "jpy", OriginalAmount: 1000},
+			wantAmount: 1000,
+		},
+		{
+			name:       "適用開始日以前で最新のレート",
+			input:      model.ExpenseInput{Date: "2025-01-31", Currency: "usd", OriginalAmount: 12.5},
+			wantAmount: 1875,
+			wantRate:   150,
+		},
+		{
+			name:       "レート切替後・四捨五入",
+			input:      model.ExpenseInput{Date: "2025-02-01", Currency: "USD", OriginalAmount: 9.99},
+			wantAmount: 1553,
+			wantRate:   155.5,
+		},
+		{
+			name:       "円換算額の指定を優先",
+			input:      model.ExpenseInput{Date: "2025-01-10", Amount: 1600, Currency: "USD", OriginalAmount: 10},
+			wantAmount: 1600,
+			wantRate:   160,
+		},
+		{
+			name:    "レート未登録",
+			input:   model.ExpenseInput{Date: "2024-12-31", Currency: "USD", OriginalAmount: 10},
+			wantErr: true,
+		},
+		{
+			name:    "元金額なし",
+			input:   model.ExpenseInput{Date: "2025-01-10", Currency: "EUR"},
+			wantErr: true,
+		},
+		{
+			name:    "不正な通貨コード",
+			input:   model.ExpenseInput{Date: "2025-01-10", Currency: "US", OriginalAmount: 10},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		input := tt.input
+		rate, err := applyExchangeRate(&input, rates)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: applyExchangeRate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if input.Amount != tt.wantAmount || rate != tt.wantRate {
+			t.Errorf("%s: amount = %d, rate = %v, want %d, %v", tt.name, input.Amount, rate, tt.wantAmount, tt.wantRate)
+		}
+		if tt.wantRate == 0 && (input.Currency != "" || input.OriginalAmount != 0) {
+			t.Errorf("%s: 基準通貨の通貨・元金額が空になっていない: %+v", tt.name, input)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// GetDailyTotals は月（"YYYY-MM"）または年（"YYYY"）の日別合計を返す。
+// カレンダー・ヒートマップ用のため、月の開始日の設定によらずカレンダー上の日付で集計する。
+// 集計対象は summaryLines の明細。
+func GetDailyTotals(ctx context.Context, client *dynamo.Client, month string, year string, userEmail string) (*model.DailyTotals, error) {
+	var months []string
+	switch {
+	case month != "":
+		if _, err := time.Parse("2006-01", month); err != nil {
+			return nil, apperror.New("month は YYYY-MM 形式で指定してください")
+		}
+		months = []string{month}
+	case year != "":
+		if _, err := time.Parse("2006", year); err != nil {
+			return nil, apperror.New("year は YYYY 形式で指定してください")
+		}
+		for m := 1; m <= 12; m++ {
+			months = append(months, fmt.Sprintf("%s-%02d", year, m))
+		}
+	default:
+		return nil, apperror.New("month または year は必須です")
+	}
+
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	byMonth, err := queryMonthsExpenses(ctx, client, months, 1)
+	if err != nil {
+		return nil, err
+	}
+	expenses := FilterExpensesForSummary(flattenMonths(months, byMonth), userEmail)
+
+	result := buildDailyTotals(expenses, catMaps)
+	result.From, _ = monthDates(months[0], 1)
+	_, result.To = monthDates(months[len(months)-1], 1)
+	return result, nil
+}
+
+// buildDailyTotals は支出を日別に集計する。
+// 最多カテゴリは excludeFromBreakdown を除いた金額の最も大きいカテゴリ（同額はカテゴリの並び順）。
+func buildDailyTotals(expenses []model.Expense, catMaps *CategoryMaps) *model.DailyTotals {
+	result := &model.DailyTotals{Days: []model.DailyTotal{}}
+	days := make(map[string]*model.DailyTotal)
+	byCategory := make(map[string]map[string]int) // 日付→カテゴリID→金額
+
+	for i := range expenses {
+		e := &expenses[i]
+		lines := summaryLines(e, catMaps)
+		if len(lines) == 0 {
+			continue
+		}
+		d, ok := days[e.Date]
+		if !ok {
+			d = &model.DailyTotal{Date: e.Date}
+			days[e.Date] = d
+			byCategory[e.Date] = make(map[string]int)
+		}
+		for _, li := range lines {
+			d.Total += li.Amount
+			result.Total += li.Amount
+			if !catMaps.ExcludeFromBreakdown[li.Category] {
+				byCategory[e.Date][li.Category] += li.Amount
+			}
+		}
+		if !IsRefund(e) {
+			d.Count++
+		}
+	}
+
+	for date, d := range days {
+		top, topAmount := "", 0
+		for id, amount := range byCategory[date] {
+			if amount > topAmount || (amount == topAmount && top != "" && categoryBefore(id, top, catMaps)) {
+				top, topAmount = id, amount
+			}
+		}
+		if top != "" {
+			d.TopCategoryID = top
+			d.TopCategory = catMaps.Name[top]
+			d.TopColor = catMaps.Color[top]
+		}
+		result.Days = append(result.Days, *d)
+	}
+	sort.Slice(result.Days, func(i, j int) bool {
+		return result.Days[i].Date < result.Days[j].Date
+	})
+	return result
+}
+
+// categoryBefore はカテゴリの並び順で a が b より前かどうかを返す
+func categoryBefore(a, b string, catMaps *CategoryMaps) bool {
+	if oa, ob := catMaps.SortOrder[a], catMaps.SortOrder[b]; oa != ob {
+		return oa < ob
+	}
+	return a < b
+}
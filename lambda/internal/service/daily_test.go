@@ -0,0 +1,47 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestBuildDailyTotals(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:                 map[string]string{"food": "食費", "daily": "日用品", "rent": "家賃"},
+		Color:                map[string]string{"food": "#f00", "daily": "#0f0", "rent": "#00f"},
+		IsExpense:            map[string]bool{"food": true, "daily": true, "rent": true, "income": false, "savings": true},
+		SortOrder:            map[string]int{"food": 1, "daily": 2, "rent": 3},
+		ExcludeFromBreakdown: map[string]bool{"rent": true},
+		ExcludeFromSummary:   map[string]bool{"savings": true},
+	}
+	expenses := []model.Expense{
+		{Date: "2026-03-05", Category: "daily", Amount: 800},
+		{Date: "2026-03-02", Category: "food", Amount: 1200},
+		{Date: "2026-03-02", Amount: 1500, Items: []model.LineItem{{Category: "food", Amount: 300}, {Category: "daily", Amount: 1200}}},
+		// 同額は並び順が先のカテゴリ
+		{Date: "2026-03-03", Category: "daily", Amount: 500},
+		{Date: "2026-03-03", Category: "food", Amount: 500},
+		// 返金はマイナス、件数に含めない
+		{Date: "2026-03-05", Type: ExpenseTypeRefund, Category: "daily", Amount: 300},
+		// 内訳除外は合計のみ（最多カテゴリにしない）
+		{Date: "2026-03-25", Category: "rent", Amount: 80000},
+		// 対象外: 収入・集計除外・振替
+		{Date: "2026-03-10", Category: "income", Amount: 300000},
+		{Date: "2026-03-10", Category: "savings", Amount: 10000},
+		{Date: "2026-03-10", Type: ExpenseTypeTransfer, Amount: 5000},
+	}
+	want := &model.DailyTotals{
+		Total: 84200,
+		Days: []model.DailyTotal{
+			{Date: "2026-03-02", Total: 2700, Count: 2, TopCategoryID: "food", TopCategory: "食費", TopColor: "#f00"},
+			{Date: "2026-03-03", Total: 1000, Count: 2, TopCategoryID: "food", TopCategory: "食費", TopColor: "#f00"},
+			{Date: "2026-03-05", Total: 500, Count: 1, TopCategoryID: "daily", TopCategory: "日用品", TopColor: "#0f0"},
+			{Date: "2026-03-25", Total: 80000, Count: 1},
+		},
+	}
+	if got := buildDailyTotals(expenses, catMaps); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildDailyTotals() = %+v, want %+v", got, want)
+	}
+}
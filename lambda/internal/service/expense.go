@@ -2,24 +2,28 @@ package service
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
 
 	"money-diary/internal/apperror"
+	"money-diary/internal/blob"
 	"money-diary/internal/dynamo"
 	"money-diary/internal/model"
 )
 
 // GetExpensesByMonth は指定月の支出一覧を返す（新しい日付順、GSI 使用）。
-// リクエスト者に応じて visibility フィルタを適用する。
-func GetExpensesByMonth(ctx context.Context, client *dynamo.Client, month string, userEmail string) ([]model.Expense, error) {
-	expenses, err := client.QueryExpensesByMonth(ctx, month)
+// リクエスト者に応じて visibility フィルタを適用する。tags 指定時はすべてのタグを含む支出のみ返す。
+func GetExpensesByMonth(ctx context.Context, client *dynamo.Client, month string, tags []string, userEmail string) ([]model.Expense, error) {
+	tags, appErr := normalizeTags(tags)
+	if appErr != nil {
+		return nil, appErr
+	}
+	expenses, err := client.QueryExpensesByMonthWithTags(ctx, month, tags)
 	if err != nil {
 		return nil, err
 	}
-	return FilterExpensesForUser(expenses, userEmail), nil
+	return filterExpensesByTags(FilterExpensesForUser(expenses, userEmail), tags), nil
 }
 
 // GetAllExpenses は全支出データを返す
@@ -29,12 +33,36 @@ func GetAllExpenses(ctx context.Context, client *dynamo.Client) ([]model.Expense
 
 // CreateExpense は支出を登録する
 func CreateExpense(ctx context.Context, client *dynamo.Client, input *model.ExpenseInput, userEmail string) (*model.Expense, error) {
-	if input.Date == "" || input.Category == "" || input.Amount <= 0 {
-		return nil, apperror.New("日付、カテゴリ、金額（0より大きい値）は必須です")
+	rates, err := loadExchangeRatesFor(ctx, client, input)
+	if err != nil {
+		return nil, err
 	}
-
-	if !ValidateVisibility(input.Visibility) {
-		return nil, apperror.New("visibility は public, summary, private のいずれかを指定してください")
+	exchangeRate, appErr := applyExchangeRate(input, rates)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr = normalizeLineItems(input); appErr != nil {
+		return nil, appErr
+	}
+	if appErr = normalizeTaxLines(input); appErr != nil {
+		return nil, appErr
+	}
+	if err := validateExpenseInput(input); err != nil {
+		return nil, err
+	}
+	normalizeTransferInput(input)
+	if appErr = normalizeMedicalInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if input.Tags, appErr = normalizeTags(input.Tags); appErr != nil {
+		return nil, appErr
+	}
+	members, err := loadMembersFor(ctx, client, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSplit(input.Split, input.PaidBy, input.Amount, members); err != nil {
+		return nil, err
 	}
 
 	// 個人カテゴリの場合は visibility を private に強制
@@ -43,30 +71,46 @@ func CreateExpense(ctx context.Context, client *dynamo.Client, input *model.Expe
 		return nil, err
 	}
 	visibility := input.Visibility
-	if catMaps.OwnerEmail[input.Category] != "" {
+	if usesPersonalCategory(input, catMaps) {
 		visibility = VisibilityPrivate
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	expense := model.Expense{
-		ID:         uuid.New().String(),
-		Date:       input.Date,
-		Payer:      input.Payer,
-		Category:   input.Category,
-		Amount:     input.Amount,
-		Memo:       input.Memo,
-		Place:      input.Place,
-		Visibility: visibility,
-		CreatedBy:  userEmail,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:               uuid.New().String(),
+		Type:             input.Type,
+		Date:             input.Date,
+		Payer:            input.Payer,
+		ToPayer:          input.ToPayer,
+		Category:         input.Category,
+		Amount:           input.Amount,
+		Items:            input.Items,
+		TaxLines:         input.TaxLines,
+		Currency:         input.Currency,
+		OriginalAmount:   input.OriginalAmount,
+		ExchangeRate:     exchangeRate,
+		Memo:             input.Memo,
+		Place:            input.Place,
+		Visibility:       visibility,
+		PaidBy:           input.PaidBy,
+		Split:            input.Split,
+		Tags:             input.Tags,
+		BusinessRatio:    input.BusinessRatio,
+		Patient:          input.Patient,
+		Provider:         input.Provider,
+		MedicalKind:      input.MedicalKind,
+		ReimbursedAmount: input.ReimbursedAmount,
+		CreatedBy:        userEmail,
+		CreatedAt:        now,
+		UpdatedAt:        now,
 	}
 
 	if err := client.PutExpense(ctx, &expense); err != nil {
 		return nil, err
 	}
 
-	refreshSummaryCache(ctx, client, expense.Date)
+	invalidateSummaryCache(ctx, client, expense.Date)
+	markBalanceSnapshotsDirty(ctx, client, expense.Date)
 	return &expense, nil
 }
 
@@ -77,13 +121,44 @@ func BulkCreateExpenses(ctx context.Context, client *dynamo.Client, inputs []mod
 		return nil, apperror.New("登録する支出データがありません")
 	}
 
-	// 全件バリデーション
-	for i, input := range inputs {
-		if input.Date == "" || input.Category == "" || input.Amount <= 0 {
-			return nil, apperror.Newf("%d件目: 日付、カテゴリ、金額（0より大きい値）は必須です", i+1)
+	// 外貨建ての換算と全件バリデーション
+	inputPtrs := make([]*model.ExpenseInput, len(inputs))
+	for i := range inputs {
+		inputPtrs[i] = &inputs[i]
+	}
+	rates, err := loadExchangeRatesFor(ctx, client, inputPtrs...)
+	if err != nil {
+		return nil, err
+	}
+	members, err := loadMembersFor(ctx, client, inputPtrs...)
+	if err != nil {
+		return nil, err
+	}
+	exchangeRates := make([]float64, len(inputs))
+	for i := range inputs {
+		rate, appErr := applyExchangeRate(&inputs[i], rates)
+		if appErr != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, appErr.Message)
+		}
+		exchangeRates[i] = rate
+		if appErr = normalizeLineItems(&inputs[i]); appErr != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, appErr.Message)
+		}
+		if appErr = normalizeTaxLines(&inputs[i]); appErr != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, appErr.Message)
+		}
+		if err := validateExpenseInput(&inputs[i]); err != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, err.Message)
 		}
-		if !ValidateVisibility(input.Visibility) {
-			return nil, apperror.Newf("%d件目: visibility は public, summary, private のいずれかを指定してください", i+1)
+		normalizeTransferInput(&inputs[i])
+		if appErr = normalizeMedicalInput(&inputs[i]); appErr != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, appErr.Message)
+		}
+		if inputs[i].Tags, appErr = normalizeTags(inputs[i].Tags); appErr != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, appErr.Message)
+		}
+		if err := validateSplit(inputs[i].Split, inputs[i].PaidBy, inputs[i].Amount, members); err != nil {
+			return nil, apperror.Newf("%d件目: %s", i+1, err.Message)
 		}
 	}
 
@@ -95,49 +170,89 @@ func BulkCreateExpenses(ctx context.Context, client *dynamo.Client, inputs []mod
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	expenses := make([]model.Expense, 0, len(inputs))
-	affectedMonths := make(map[string]bool)
 
-	for _, input := range inputs {
+	for i, input := range inputs {
 		visibility := input.Visibility
-		if catMaps.OwnerEmail[input.Category] != "" {
+		if usesPersonalCategory(&input, catMaps) {
 			visibility = VisibilityPrivate
 		}
 		expense := model.Expense{
-			ID:         uuid.New().String(),
-			Date:       input.Date,
-			Payer:      input.Payer,
-			Category:   input.Category,
-			Amount:     input.Amount,
-			Memo:       input.Memo,
-			Place:      input.Place,
-			Visibility: visibility,
-			CreatedBy:  userEmail,
-			CreatedAt:  now,
-			UpdatedAt:  now,
+			ID:               uuid.New().String(),
+			Type:             input.Type,
+			Date:             input.Date,
+			Payer:            input.Payer,
+			ToPayer:          input.ToPayer,
+			Category:         input.Category,
+			Amount:           input.Amount,
+			Items:            input.Items,
+			TaxLines:         input.TaxLines,
+			Currency:         input.Currency,
+			OriginalAmount:   input.OriginalAmount,
+			ExchangeRate:     exchangeRates[i],
+			Memo:             input.Memo,
+			Place:            input.Place,
+			Visibility:       visibility,
+			PaidBy:           input.PaidBy,
+			Split:            input.Split,
+			Tags:             input.Tags,
+			BusinessRatio:    input.BusinessRatio,
+			Patient:          input.Patient,
+			Provider:         input.Provider,
+			MedicalKind:      input.MedicalKind,
+			ReimbursedAmount: input.ReimbursedAmount,
+			CreatedBy:        userEmail,
+			CreatedAt:        now,
+			UpdatedAt:        now,
 		}
 		if err := client.PutExpense(ctx, &expense); err != nil {
 			return nil, apperror.Newf("%s の登録に失敗しました: %v", input.Date, err)
 		}
 		expenses = append(expenses, expense)
-		if len(input.Date) >= 7 {
-			affectedMonths[input.Date[:7]] = true
-		}
 	}
 
-	// 影響月のキャッシュをまとめて更新
-	for month := range affectedMonths {
-		if err := RefreshMonthlySummaryCache(ctx, client, month); err != nil {
-			log.Printf("monthlySummary cache refresh failed for %s: %v", month, err)
-		}
+	// 影響月の集計キャッシュを無効化し、残高スナップショットを再計算待ちにする
+	dates := make([]string, 0, len(expenses))
+	for _, e := range expenses {
+		dates = append(dates, e.Date)
 	}
+	invalidateSummaryCache(ctx, client, dates...)
+	markBalanceSnapshotsDirty(ctx, client, dates...)
 
 	return expenses, nil
 }
 
 // UpdateExpense は支出を更新する
 func UpdateExpense(ctx context.Context, client *dynamo.Client, id string, input *model.ExpenseInput) (*model.Expense, error) {
-	if input.Date == "" || input.Category == "" || input.Amount <= 0 {
-		return nil, apperror.New("日付、カテゴリ、金額（0より大きい値）は必須です")
+	rates, err := loadExchangeRatesFor(ctx, client, input)
+	if err != nil {
+		return nil, err
+	}
+	exchangeRate, appErr := applyExchangeRate(input, rates)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr = normalizeLineItems(input); appErr != nil {
+		return nil, appErr
+	}
+	if appErr = normalizeTaxLines(input); appErr != nil {
+		return nil, appErr
+	}
+	if err := validateExpenseInput(input); err != nil {
+		return nil, err
+	}
+	normalizeTransferInput(input)
+	if appErr = normalizeMedicalInput(input); appErr != nil {
+		return nil, appErr
+	}
+	if input.Tags, appErr = normalizeTags(input.Tags); appErr != nil {
+		return nil, appErr
+	}
+	members, err := loadMembersFor(ctx, client, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSplit(input.Split, input.PaidBy, input.Amount, members); err != nil {
+		return nil, err
 	}
 
 	existing, err := client.GetExpense(ctx, id)
@@ -147,131 +262,96 @@ func UpdateExpense(ctx context.Context, client *dynamo.Client, id string, input
 	if existing == nil {
 		return nil, apperror.WithStatus(404, "支出データが見つかりません")
 	}
+	if IsRefund(existing) {
+		return nil, apperror.New("返金は編集できません。削除して登録し直してください")
+	}
+	if existing.RefundedAmount > 0 && (EffectiveExpenseType(input.Type) != ExpenseTypeExpense || input.Amount < existing.RefundedAmount) {
+		return nil, apperror.Newf("返金済み（%d）の支出は振替に変更したり、金額を返金済み額より小さくしたりできません", existing.RefundedAmount)
+	}
+	if existing.RefundedAmount > 0 && refundLinesChanged(existing, input) {
+		return nil, apperror.Newf("返金済み（%d）の支出はカテゴリ・明細を変更できません。先に返金を削除してください", existing.RefundedAmount)
+	}
 
 	oldDate := existing.Date
 
-	if !ValidateVisibility(input.Visibility) {
-		return nil, apperror.New("visibility は public, summary, private のいずれかを指定してください")
-	}
-
+	existing.Type = input.Type
 	existing.Date = input.Date
 	existing.Payer = input.Payer
+	existing.ToPayer = input.ToPayer
 	existing.Category = input.Category
 	existing.Amount = input.Amount
+	existing.Items = input.Items
+	existing.TaxLines = input.TaxLines
+	existing.Currency = input.Currency
+	existing.OriginalAmount = input.OriginalAmount
+	existing.ExchangeRate = exchangeRate
 	existing.Memo = input.Memo
 	existing.Place = input.Place
 	existing.Visibility = input.Visibility
+	existing.PaidBy = input.PaidBy
+	existing.Split = input.Split
+	existing.Tags = input.Tags
+	existing.BusinessRatio = input.BusinessRatio
+	existing.Patient = input.Patient
+	existing.Provider = input.Provider
+	existing.MedicalKind = input.MedicalKind
+	existing.ReimbursedAmount = input.ReimbursedAmount
 	existing.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 
 	if err := client.PutExpense(ctx, existing); err != nil {
 		return nil, err
 	}
 
-	refreshSummaryCache(ctx, client, existing.Date)
-	if len(oldDate) >= 7 && len(existing.Date) >= 7 && oldDate[:7] != existing.Date[:7] {
-		refreshSummaryCache(ctx, client, oldDate)
-	}
+	// 月の開始日によってはカレンダー月が同じでも集計・残高の月が変わる
+	invalidateSummaryCache(ctx, client, existing.Date, oldDate)
+	markBalanceSnapshotsDirty(ctx, client, existing.Date, oldDate)
 	return existing, nil
 }
 
-// DeleteExpense は支出を削除する
-func DeleteExpense(ctx context.Context, client *dynamo.Client, id string) error {
-	// 削除前にデータ取得して yearMonth を特定
-	existing, _ := client.GetExpense(ctx, id)
+// DeleteExpense は支出を削除する（添付ファイルの本体も削除する）
+func DeleteExpense(ctx context.Context, client *dynamo.Client, store blob.Store, id string) error {
+	// 削除前にデータ取得して yearMonth・添付ファイルを特定
+	existing, err := client.GetExpense(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.RefundedAmount > 0 {
+		return apperror.Newf("返金済み（%d）の支出は削除できません。先に返金を削除してください", existing.RefundedAmount)
+	}
 
 	if err := client.DeleteExpense(ctx, id); err != nil {
 		return err
 	}
 	if existing != nil {
-		refreshSummaryCache(ctx, client, existing.Date)
+		if IsRefund(existing) {
+			releaseRefund(ctx, client, existing)
+		}
+		deleteAttachmentBlobs(ctx, store, existing)
+		invalidateSummaryCache(ctx, client, existing.Date)
+		markBalanceSnapshotsDirty(ctx, client, existing.Date)
 	}
 	return nil
 }
 
-// refreshSummaryCache は支出日付から yearMonth を抽出してキャッシュを更新する
-func refreshSummaryCache(ctx context.Context, client *dynamo.Client, date string) {
-	if len(date) < 7 {
-		return
-	}
-	if err := RefreshMonthlySummaryCache(ctx, client, date[:7]); err != nil {
-		log.Printf("monthlySummary cache refresh failed for %s: %v", date[:7], err)
-	}
-}
-
-// GetPayerBalance は支払元の月別残額を返す。
-// trackBalance=true の payer のみ有効。
-// チャージ = 現金チャージカテゴリ（isExpense=false かつ収入を除く）の合計
-// 支出 = 対象 payer の isExpense=true カテゴリ合計
-// 前月繰越 + 月内チャージ - 月内支出 = 残額
-func GetPayerBalance(ctx context.Context, client *dynamo.Client, payerName string, month string) (*model.PayerBalance, error) {
-	payers, err := GetPayers(ctx, client)
-	if err != nil {
-		return nil, err
-	}
-	var trackBalance bool
-	for _, p := range payers {
-		if p.Name == payerName && p.TrackBalance {
-			trackBalance = true
-			break
-		}
-	}
-	if !trackBalance {
-		return &model.PayerBalance{Payer: payerName}, nil
-	}
-
-	expenses, err := GetAllExpenses(ctx, client)
-	if err != nil {
-		return nil, err
-	}
-
-	catMaps, err := GetCategoryMaps(ctx, client, "")
-	if err != nil {
-		return nil, err
+// refundLinesChanged は返金が差し引くカテゴリ（カテゴリ・明細のカテゴリと金額）が変わるかどうかを返す
+func refundLinesChanged(existing *model.Expense, input *model.ExpenseInput) bool {
+	if existing.Category != input.Category || len(existing.Items) != len(input.Items) {
+		return true
 	}
-
-	// チャージ対象カテゴリを特定（isExpense=false かつ「収入」以外）
-	chargeCategories := make(map[string]bool)
-	for id, isExp := range catMaps.IsExpense {
-		if !isExp && catMaps.Name[id] != "収入" {
-			chargeCategories[id] = true
+	for i, li := range existing.Items {
+		if li.Category != input.Items[i].Category || li.Amount != input.Items[i].Amount {
+			return true
 		}
 	}
+	return false
+}
 
-	var carryover, monthCharge, monthSpent int
-	for _, e := range expenses {
-		ym := ""
-		if len(e.Date) >= 7 {
-			ym = e.Date[:7]
-		}
-
-		if chargeCategories[e.Category] {
-			// チャージ（現金チャージ等）
-			if ym < month {
-				carryover += e.Amount
-			} else if ym == month {
-				monthCharge += e.Amount
-			}
-		} else if e.Payer == payerName {
-			isExp, ok := catMaps.IsExpense[e.Category]
-			if !ok {
-				isExp = true
-			}
-			if isExp {
-				// 対象 payer の支出
-				if ym < month {
-					carryover -= e.Amount
-				} else if ym == month {
-					monthSpent += e.Amount
-				}
-			}
+// usesPersonalCategory は支出（明細を含む）に個人カテゴリが使われているかどうかを返す
+func usesPersonalCategory(input *model.ExpenseInput, catMaps *CategoryMaps) bool {
+	for _, category := range lineCategories(input) {
+		if catMaps.OwnerEmail[category] != "" {
+			return true
 		}
 	}
-
-	return &model.PayerBalance{
-		Payer:       payerName,
-		Carryover:   carryover,
-		MonthCharge: monthCharge,
-		MonthSpent:  monthSpent,
-		Balance:     carryover + monthCharge - monthSpent,
-	}, nil
+	return false
 }
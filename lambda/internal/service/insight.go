@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 異常検知の種類
+const (
+	InsightMonthToDate  = "monthToDate"  // 月初から基準日までのカテゴリ別支出
+	InsightLast30Days   = "last30Days"   // 直近 30 日のカテゴリ別支出
+	InsightLargeExpense = "largeExpense" // 1 件の高額な支出
+)
+
+const (
+	insightHistoryMonths = 12     // 比較に使う過去の月数
+	insightMinHistory    = 3      // 比較に必要な過去の支出のある月数（高額支出は件数）
+	insightSpreadFactor  = 3.0    // 中央値からばらつきの何倍を超えたら検出するか
+	insightMinSpread     = 0.1    // ばらつきの下限（中央値に対する割合）
+	insightMinDiff       = 1000   // 検出する中央値との差の下限（円）
+	madToSigma           = 1.4826 // 中央絶対偏差を標準偏差相当に換算する係数
+)
+
+// GetInsights は基準日（空=今日）時点で通常と異なる支出を検出する。
+// カテゴリごとに月初からの累計（過去 12 ヶ月の同じ経過日数までの累計と比較）と直近 30 日（過去 12 ヶ月の月額と比較）を
+// 過去の中央値・ばらつきと比べ、あわせて過去の支出と比べて高額な 1 件の支出を検出する。
+// 集計対象は summaryLines の明細。
+func GetInsights(ctx context.Context, client *dynamo.Client, date string, userEmail string) (*model.Insights, error) {
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, apperror.New("date は YYYY-MM-DD 形式で指定してください")
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return nil, err
+	}
+
+	month := monthOf(date, startDay)
+	first := month
+	for i := 0; i < insightHistoryMonths; i++ {
+		first = previousMonth(first)
+	}
+	months, err := monthsBetween(first, month)
+	if err != nil {
+		return nil, err
+	}
+	byMonth, err := queryMonthsExpenses(ctx, client, months, startDay)
+	if err != nil {
+		return nil, err
+	}
+	expenses := FilterExpensesForSummary(flattenMonths(months, byMonth), userEmail)
+	return buildInsights(expenses, date, startDay, catMaps, userEmail), nil
+}
+
+// buildInsights は基準日 date と過去 12 ヶ月の支出から異常を検出する
+func buildInsights(expenses []model.Expense, date string, startDay int, catMaps *CategoryMaps, userEmail string) *model.Insights {
+	month := monthOf(date, startDay)
+	monthStart, _ := monthDates(month, startDay)
+	last30Start := addDays(date, -29)
+	elapsed := daysBetween(monthStart, date)
+
+	// 過去の月（新しい順）と、各月の月初から同じ経過日数までの最終日
+	histIndex := make(map[string]int, insightHistoryMonths)
+	histMTDEnd := make([]string, insightHistoryMonths)
+	hm := month
+	for i := 0; i < insightHistoryMonths; i++ {
+		hm = previousMonth(hm)
+		histIndex[hm] = i
+		start, end := monthDates(hm, startDay)
+		histMTDEnd[i] = addDays(start, elapsed)
+		if histMTDEnd[i] > end {
+			histMTDEnd[i] = end
+		}
+	}
+
+	mtd := make(map[string]int)
+	last30 := make(map[string]int)
+	histFull := make(map[string][]int) // カテゴリID→過去の月額
+	histMTD := make(map[string][]int)  // カテゴリID→過去の同じ経過日数までの累計
+	histExpenses := make(map[string][]int)
+	type candidate struct {
+		e        *model.Expense
+		category string
+		amount   int
+	}
+	var candidates []candidate
+
+	for i := range expenses {
+		e := &expenses[i]
+		if e.Date > date {
+			continue
+		}
+		byCategory := make(map[string]int)
+		for _, li := range summaryLines(e, catMaps) {
+			byCategory[li.Category] += li.Amount
+		}
+		idx, inHistory := histIndex[monthOf(e.Date, startDay)]
+		for cat, amount := range byCategory {
+			if e.Date >= monthStart {
+				mtd[cat] += amount
+			}
+			if e.Date >= last30Start {
+				last30[cat] += amount
+			}
+			if inHistory {
+				if histFull[cat] == nil {
+					histFull[cat] = make([]int, insightHistoryMonths)
+					histMTD[cat] = make([]int, insightHistoryMonths)
+				}
+				histFull[cat][idx] += amount
+				if e.Date <= histMTDEnd[idx] {
+					histMTD[cat][idx] += amount
+				}
+			}
+			if IsRefund(e) || amount <= 0 {
+				continue
+			}
+			if inHistory {
+				histExpenses[cat] = append(histExpenses[cat], amount)
+			}
+			if e.Date >= monthStart || e.Date >= last30Start {
+				candidates = append(candidates, candidate{e: e, category: cat, amount: amount})
+			}
+		}
+	}
+
+	result := &model.Insights{Date: date, Month: month, MonthStart: monthStart, Flags: []model.InsightFlag{}}
+	for _, cat := range sortedKeys(mtd) {
+		if f, ok := detectAnomaly(mtd[cat], activeHistory(histMTD[cat], histFull[cat])); ok {
+			result.Flags = append(result.Flags, categoryFlag(InsightMonthToDate, cat, f, catMaps))
+		}
+	}
+	for _, cat := range sortedKeys(last30) {
+		if f, ok := detectAnomaly(last30[cat], activeHistory(histFull[cat], histFull[cat])); ok {
+			result.Flags = append(result.Flags, categoryFlag(InsightLast30Days, cat, f, catMaps))
+		}
+	}
+	for _, c := range candidates {
+		f, ok := detectAnomaly(c.amount, histExpenses[c.category])
+		if !ok {
+			continue
+		}
+		flag := categoryFlag(InsightLargeExpense, c.category, f, catMaps)
+		flag.ExpenseID, flag.Date = c.e.ID, c.e.Date
+		if c.e.CreatedBy == userEmail || EffectiveVisibility(c.e.Visibility) != VisibilitySummary {
+			flag.Place, flag.Memo = c.e.Place, c.e.Memo
+		}
+		result.Flags = append(result.Flags, flag)
+	}
+
+	// 種類ごとに中央値からの超過が大きい順
+	kindOrder := map[string]int{InsightMonthToDate: 0, InsightLast30Days: 1, InsightLargeExpense: 2}
+	sort.SliceStable(result.Flags, func(i, j int) bool {
+		a, b := result.Flags[i], result.Flags[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		return a.Amount-a.Median > b.Amount-b.Median
+	})
+	return result
+}
+
+// anomaly は検出に使った統計量
+type anomaly struct {
+	amount int
+	median int
+	spread int
+}
+
+// detectAnomaly は amount が過去の値 history の中央値からばらつきの 3 倍を超えて大きいかどうかを判定する。
+// 過去の値が少ない場合や、中央値との差が小さい場合は検出しない。
+func detectAnomaly(amount int, history []int) (anomaly, bool) {
+	if len(history) < insightMinHistory {
+		return anomaly{}, false
+	}
+	med := median(history)
+	deviations := make([]float64, len(history))
+	for i, v := range history {
+		deviations[i] = math.Abs(float64(v) - med)
+	}
+	spread := math.Max(medianFloat(deviations)*madToSigma, med*insightMinSpread)
+	a := anomaly{amount: amount, median: int(math.Round(med)), spread: int(math.Round(spread))}
+	if float64(amount)-med < insightMinDiff || float64(amount) <= med+insightSpreadFactor*spread {
+		return a, false
+	}
+	return a, true
+}
+
+// activeHistory は支出のあった月が insightMinHistory 未満の場合に nil を返す（新しいカテゴリは比較しない）。
+// 支出のあった月数は月額 full で数え、比較には values を使う。
+func activeHistory(values []int, full []int) []int {
+	active := 0
+	for _, v := range full {
+		if v != 0 {
+			active++
+		}
+	}
+	if active < insightMinHistory {
+		return nil
+	}
+	return values
+}
+
+// categoryFlag はカテゴリの検出結果を作る
+func categoryFlag(kind string, cat string, a anomaly, catMaps *CategoryMaps) model.InsightFlag {
+	name := catMaps.Name[cat]
+	if name == "" {
+		name = cat
+	}
+	flag := model.InsightFlag{Kind: kind, CategoryID: cat, Category: name, Amount: a.amount, Median: a.median, Spread: a.spread}
+	if a.median > 0 {
+		flag.Ratio = math.Round(float64(a.amount)/float64(a.median)*100) / 100
+	}
+	return flag
+}
+
+// median は整数の中央値を返す
+func median(values []int) float64 {
+	f := make([]float64, len(values))
+	for i, v := range values {
+		f[i] = float64(v)
+	}
+	return medianFloat(f)
+}
+
+// medianFloat は中央値を返す（values は並べ替えない）
+func medianFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// sortedKeys はマップのキーを昇順で返す
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// addDays は日付 "YYYY-MM-DD" に n 日を加えた日付を返す
+func addDays(date string, n int) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, n).Format("2006-01-02")
+}
+
+// daysBetween は from から to までの日数を返す
+func daysBetween(from string, to string) int {
+	f, err1 := time.Parse("2006-01-02", from)
+	t, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	return int(t.Sub(f).Hours() / 24)
+}
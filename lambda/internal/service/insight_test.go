@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestDetectAnomaly(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int
+		history []int
+		want    bool
+	}{
+		{name: "通常の範囲", amount: 9000, history: []int{8000, 8500, 7800, 9200, 8100, 8300}, want: false},
+		{name: "倍増", amount: 17000, history: []int{8000, 8500, 7800, 9200, 8100, 8300}, want: true},
+		{name: "履歴不足", amount: 17000, history: []int{8000, 8500}, want: false},
+		{name: "ばらつきが大きい", amount: 30000, history: []int{5000, 40000, 10000, 35000, 8000, 20000}, want: false},
+		{name: "差が小さい", amount: 900, history: []int{100, 100, 100, 100}, want: false},
+		{name: "ばらつき 0 でも下限を適用", amount: 1200, history: []int{1000, 1000, 1000}, want: false},
+		{name: "中央値 0", amount: 5000, history: []int{0, 0, 0, 0, 0}, want: true},
+	}
+	for _, tt := range tests {
+		if _, got := detectAnomaly(tt.amount, tt.history); got != tt.want {
+			t.Errorf("%s: detectAnomaly() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildInsights(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:      map[string]string{"electric": "電気代", "food": "食費", "new": "新カテゴリ"},
+		IsExpense: map[string]bool{"electric": true, "food": true, "new": true, "income": false},
+	}
+	var expenses []model.Expense
+	// 過去 12 ヶ月: 電気代は毎月 10 日に約 8,000 円、食費は毎月 5 日・20 日に 3,000 円
+	for m := 1; m <= 12; m++ {
+		ym := fmt.Sprintf("2025-%02d", m)
+		expenses = append(expenses,
+			model.Expense{ID: ym + "-e", Date: ym + "-10", Category: "electric", Amount: 8000 + m*50},
+			model.Expense{ID: ym + "-f1", Date: ym + "-05", Category: "food", Amount: 3000},
+			model.Expense{ID: ym + "-f2", Date: ym + "-20", Category: "food", Amount: 3000},
+		)
+	}
+	expenses = append(expenses,
+		// 当月: 電気代が倍増、食費は通常どおり、新しいカテゴリは比較しない
+		model.Expense{ID: "now-e", Date: "2026-01-10", Category: "electric", Amount: 17000, Place: "電力会社", Memo: "1月分"},
+		model.Expense{ID: "now-f", Date: "2026-01-05", Category: "food", Amount: 3000},
+		model.Expense{ID: "now-n", Date: "2026-01-06", Category: "new", Amount: 50000},
+		// 他人の「金額のみ公開」の高額支出は場所・メモを伏せる
+		model.Expense{ID: "now-f2", Date: "2026-01-12", Category: "food", Amount: 20000, Place: "百貨店", Memo: "贈答品", CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		// 基準日より後・収入は対象外
+		model.Expense{ID: "later", Date: "2026-01-20", Category: "food", Amount: 90000},
+		model.Expense{ID: "income", Date: "2026-01-10", Category: "income", Amount: 300000},
+	)
+
+	got := buildInsights(expenses, "2026-01-15", 1, catMaps, "a@example.com")
+	if got.Month != "2026-01" || got.MonthStart != "2026-01-01" {
+		t.Errorf("month = %s (%s), want 2026-01 (2026-01-01)", got.Month, got.MonthStart)
+	}
+	type key struct{ kind, category, expenseID string }
+	flags := make(map[key]model.InsightFlag)
+	for _, f := range got.Flags {
+		flags[key{f.Kind, f.CategoryID, f.ExpenseID}] = f
+	}
+	want := []key{
+		{InsightMonthToDate, "electric", ""},
+		{InsightMonthToDate, "food", ""},
+		{InsightLast30Days, "electric", ""},
+		{InsightLast30Days, "food", ""},
+		{InsightLargeExpense, "electric", "now-e"},
+		{InsightLargeExpense, "food", "now-f2"},
+	}
+	for _, k := range want {
+		if _, ok := flags[k]; !ok {
+			t.Errorf("flag %+v not found in %+v", k, got.Flags)
+		}
+	}
+	if len(got.Flags) != len(want) {
+		t.Errorf("len(Flags) = %d, want %d: %+v", len(got.Flags), len(want), got.Flags)
+	}
+	if f := flags[key{InsightLargeExpense, "electric", "now-e"}]; f.Place != "電力会社" || f.Memo != "1月分" || f.Date != "2026-01-10" {
+		t.Errorf("large electric flag = %+v", f)
+	}
+	if f := flags[key{InsightLargeExpense, "food", "now-f2"}]; f.Place != "" || f.Memo != "" {
+		t.Errorf("masked flag = %+v, want no place/memo", f)
+	}
+	if got.Flags[0].Kind != InsightMonthToDate || got.Flags[0].CategoryID != "food" {
+		t.Errorf("Flags[0] = %+v, want monthToDate food (largest excess)", got.Flags[0])
+	}
+}
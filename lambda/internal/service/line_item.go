@@ -0,0 +1,64 @@
+package service
+
+import (
+	"money-diary/internal/apperror"
+	"money-diary/internal/model"
+)
+
+// maxLineItems は 1 件の支出に登録できる明細の最大数
+const maxLineItems = 50
+
+// normalizeLineItems は明細を検証し、支出の金額・カテゴリを明細から決める。
+// 金額が 0 の場合は明細の合計を金額とし、カテゴリは最も金額の大きい明細（同額は先頭）のカテゴリとする。
+// 振替は normalizeTransferInput で明細を破棄するため検証しない。
+func normalizeLineItems(input *model.ExpenseInput) *apperror.AppError {
+	if len(input.Items) == 0 || EffectiveExpenseType(input.Type) == ExpenseTypeTransfer {
+		return nil
+	}
+	if isForeignCurrency(input.Currency) {
+		return apperror.New("外貨建ての支出は明細に分けられません")
+	}
+	if len(input.Items) > maxLineItems {
+		return apperror.Newf("明細は %d 件以内で指定してください", maxLineItems)
+	}
+
+	total := 0
+	primary := 0
+	for i, li := range input.Items {
+		if li.Category == "" || li.Amount <= 0 {
+			return apperror.Newf("明細 %d 行目: カテゴリ、金額（0より大きい値）は必須です", i+1)
+		}
+		total += li.Amount
+		if li.Amount > input.Items[primary].Amount {
+			primary = i
+		}
+	}
+	if input.Amount == 0 {
+		input.Amount = total
+	}
+	if total != input.Amount {
+		return apperror.Newf("明細の合計（%d）が金額（%d）と一致しません", total, input.Amount)
+	}
+	input.Category = input.Items[primary].Category
+	return nil
+}
+
+// expenseLines は支出をカテゴリ別の明細として返す（明細がない場合は Category に全額の 1 行）
+func expenseLines(e *model.Expense) []model.LineItem {
+	if len(e.Items) > 0 {
+		return e.Items
+	}
+	return []model.LineItem{{Category: e.Category, Amount: e.Amount}}
+}
+
+// lineCategories は入力の明細を含むすべてのカテゴリを返す
+func lineCategories(input *model.ExpenseInput) []string {
+	if len(input.Items) == 0 {
+		return []string{input.Category}
+	}
+	categories := make([]string, len(input.Items))
+	for i, li := range input.Items {
+		categories[i] = li.Category
+	}
+	return categories
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestNormalizeLineItems(t *testing.T) {
+	items := []model.LineItem{
+		{Category: "food", Amount: 2400},
+		{Category: "daily", Amount: 980, Memo: "洗剤"},
+		{Category: "alcohol", Amount: 2400},
+	}
+	tests := []struct {
+		name         string
+		input        model.ExpenseInput
+		wantAmount   int
+		wantCategory string
+		wantErr      bool
+	}{
+		{name: "明細なしはそのまま", input: model.ExpenseInput{Category: "food", Amount: 500}, wantAmount: 500, wantCategory: "food"},
+		{name: "金額 0 は明細の合計（同額は先頭のカテゴリ）", input: model.ExpenseInput{Items: items}, wantAmount: 5780, wantCategory: "food"},
+		{name: "合計一致", input: model.ExpenseInput{Category: "daily", Amount: 5780, Items: items}, wantAmount: 5780, wantCategory: "food"},
+		{name: "合計不一致", input: model.ExpenseInput{Amount: 6000, Items: items}, wantErr: true},
+		{name: "カテゴリなしの明細", input: model.ExpenseInput{Items: []model.LineItem{{Amount: 100}}}, wantErr: true},
+		{name: "外貨建て", input: model.ExpenseInput{Currency: "USD", OriginalAmount: 10, Items: items}, wantErr: true},
+		{name: "振替は対象外", input: model.ExpenseInput{Type: ExpenseTypeTransfer, Amount: 100, Items: items}, wantAmount: 100},
+	}
+	for _, tt := range tests {
+		input := tt.input
+		err := normalizeLineItems(&input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: normalizeLineItems() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if input.Amount != tt.wantAmount || input.Category != tt.wantCategory {
+			t.Errorf("%s: amount, category = %d, %q, want %d, %q", tt.name, input.Amount, input.Category, tt.wantAmount, tt.wantCategory)
+		}
+	}
+}
+
+func TestMonthDataLineItems(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:      map[string]string{"food": "食費", "daily": "日用品"},
+		Color:     map[string]string{"food": "#f00", "daily": "#0f0"},
+		SortOrder: map[string]int{"food": 1, "daily": 2},
+	}
+	expenses := []model.Expense{
+		{Date: "2025-03-01", Category: "food", Amount: 3380, Items: []model.LineItem{
+			{Category: "food", Amount: 2400},
+			{Category: "daily", Amount: 980},
+		}},
+		{Date: "2025-03-02", Category: "food", Amount: 600},
+	}
+	want := []model.CategorySummary{
+		{CategoryID: "food", Category: "食費", Amount: 3000, Color: "#f00"},
+		{CategoryID: "daily", Category: "日用品", Amount: 980, Color: "#0f0"},
+	}
+	if got := monthDataFromCache(buildSummaryCache(expenses, "2025-03", 1), "", catMaps, "").ByCategory; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByCategory = %v, want %v", got, want)
+	}
+}
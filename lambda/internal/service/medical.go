@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 医療費の区分（医療費控除の明細書の区分）
+const (
+	MedicalTreatment = "treatment" // 診療・治療
+	MedicalCare      = "care"      // 介護保険サービス
+	MedicalMedicine  = "medicine"  // 医薬品購入
+	MedicalOther     = "other"     // その他の医療費
+)
+
+// medicalKinds は明細書の列の順に並べた医療費の区分
+var medicalKinds = []string{MedicalTreatment, MedicalCare, MedicalMedicine, MedicalOther}
+
+var medicalKindLabels = map[string]string{
+	MedicalTreatment: "診療・治療",
+	MedicalCare:      "介護保険サービス",
+	MedicalMedicine:  "医薬品購入",
+	MedicalOther:     "その他の医療費",
+}
+
+const (
+	medicalThreshold    = 100000  // 足切り額（所得金額等の 5% の方が少なければそちら）
+	maxMedicalDeduction = 2000000 // 医療費控除の上限
+)
+
+// effectiveMedicalKind は空文字列を "treatment" に正規化する
+func effectiveMedicalKind(kind string) string {
+	if kind == "" {
+		return MedicalTreatment
+	}
+	return kind
+}
+
+// normalizeMedicalInput は医療費控除の項目（医療を受けた方・支払先・区分・補填額）を検証する
+func normalizeMedicalInput(input *model.ExpenseInput) *apperror.AppError {
+	input.Patient = strings.TrimSpace(input.Patient)
+	input.Provider = strings.TrimSpace(input.Provider)
+	if _, ok := medicalKindLabels[effectiveMedicalKind(input.MedicalKind)]; !ok {
+		return apperror.New("medicalKind は treatment, care, medicine, other のいずれかを指定してください")
+	}
+	if input.ReimbursedAmount < 0 || input.ReimbursedAmount > input.Amount {
+		return apperror.New("補填される金額は 0 以上、金額以下で指定してください")
+	}
+	return nil
+}
+
+// GetMedicalReport は年間（1〜12 月）の医療費控除の明細書を返す。
+// income（所得金額等の合計額）を指定すると、足切り額を 10 万円と income×5% のいずれか少ない金額にする。
+func GetMedicalReport(ctx context.Context, client *dynamo.Client, year string, income int, userEmail string) (*model.MedicalReport, error) {
+	if _, err := time.Parse("2006", year); err != nil {
+		return nil, apperror.New("year は YYYY 形式で指定してください")
+	}
+	if income < 0 {
+		return nil, apperror.New("income は 0 以上で指定してください")
+	}
+	months, err := monthRange(year+"-01", year+"-12")
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, months, 1)
+	if err != nil {
+		return nil, err
+	}
+	expenses := FilterExpensesForSummary(flattenMonths(months, byMonth), userEmail)
+	return buildMedicalReport(year, expenses, income, catMaps, userEmail), nil
+}
+
+// buildMedicalReport は医療費カテゴリの支出を医療を受けた方・支払先ごとにまとめ、控除額を計算する。
+// 他人の「金額のみ公開」の支出は医療を受けた方・支払先を伏せた行（どちらも空）にまとめる。
+func buildMedicalReport(year string, expenses []model.Expense, income int, catMaps *CategoryMaps, userEmail string) *model.MedicalReport {
+	type rowKey struct{ patient, provider string }
+	rows := make(map[rowKey]*model.MedicalRow)
+	kinds := make(map[rowKey]map[string]bool)
+	for i := range expenses {
+		e := &expenses[i]
+		amount := medicalAmount(e, catMaps)
+		if amount == 0 {
+			continue
+		}
+		key := rowKey{}
+		if e.CreatedBy == userEmail || EffectiveVisibility(e.Visibility) != VisibilitySummary {
+			key.patient, key.provider = e.Patient, e.Provider
+			if key.provider == "" {
+				key.provider = e.Place
+			}
+		}
+		r, ok := rows[key]
+		if !ok {
+			r = &model.MedicalRow{Patient: key.patient, Provider: key.provider}
+			rows[key] = r
+			kinds[key] = make(map[string]bool)
+		}
+		r.Amount += amount
+		r.Count++
+		if !IsRefund(e) {
+			r.Reimbursed += min(e.ReimbursedAmount, amount)
+		}
+		kinds[key][effectiveMedicalKind(e.MedicalKind)] = true
+	}
+
+	report := &model.MedicalReport{Year: year, Rows: []model.MedicalRow{}, ByPatient: []model.MedicalPatient{}, Income: income}
+	byPatient := make(map[string]*model.MedicalPatient)
+	for key, r := range rows {
+		for _, kind := range medicalKinds {
+			if kinds[key][kind] {
+				r.Kinds = append(r.Kinds, kind)
+			}
+		}
+		// 補填額は支払った医療費を限度とする（他の医療費からは差し引かない）
+		r.Reimbursed = max(min(r.Reimbursed, r.Amount), 0)
+		report.Rows = append(report.Rows, *r)
+		report.Total += r.Amount
+		report.Reimbursed += r.Reimbursed
+
+		p, ok := byPatient[r.Patient]
+		if !ok {
+			p = &model.MedicalPatient{Patient: r.Patient}
+			byPatient[r.Patient] = p
+		}
+		p.Amount += r.Amount
+		p.Reimbursed += r.Reimbursed
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Patient != report.Rows[j].Patient {
+			return report.Rows[i].Patient < report.Rows[j].Patient
+		}
+		return report.Rows[i].Provider < report.Rows[j].Provider
+	})
+	for _, p := range byPatient {
+		report.ByPatient = append(report.ByPatient, *p)
+	}
+	sort.Slice(report.ByPatient, func(i, j int) bool { return report.ByPatient[i].Patient < report.ByPatient[j].Patient })
+
+	report.Net = report.Total - report.Reimbursed
+	report.Threshold = medicalThreshold
+	if income > 0 {
+		report.Threshold = min(income*5/100, medicalThreshold)
+	}
+	report.Deduction = min(max(report.Net-report.Threshold, 0), maxMedicalDeduction)
+	return report
+}
+
+// medicalAmount は支出のうち医療費カテゴリ（明細を含む）の金額を返す（返金はマイナス）
+func medicalAmount(e *model.Expense, catMaps *CategoryMaps) int {
+	if !IsExpenseEntry(e) && !IsRefund(e) {
+		return 0
+	}
+	amount := 0
+	for _, li := range expenseLines(e) {
+		if catMaps.IsMedical[li.Category] {
+			amount += li.Amount
+		}
+	}
+	if IsRefund(e) {
+		return -amount
+	}
+	return amount
+}
+
+// ExportMedicalReport は医療費控除の明細書を CSV（UTF-8 BOM 付き）で返す
+func ExportMedicalReport(ctx context.Context, client *dynamo.Client, year string, income int, userEmail string) (*model.CSVExport, error) {
+	report, err := GetMedicalReport(ctx, client, year, income, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	content, err := medicalReportCSV(report)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CSVExport{FileName: "medical-" + year + ".csv", Content: content}, nil
+}
+
+// medicalReportCSV は明細書の「医療費の明細」と「控除額の計算」の欄を CSV にする
+func medicalReportCSV(report *model.MedicalReport) (string, error) {
+	header := []string{"医療を受けた方の氏名", "病院・薬局などの支払先の名称"}
+	for _, kind := range medicalKinds {
+		header = append(header, medicalKindLabels[kind])
+	}
+	header = append(header, "支払った医療費の額", "左のうち生命保険や社会保険などで補填される金額")
+	records := [][]string{header}
+	for _, r := range report.Rows {
+		record := []string{r.Patient, r.Provider}
+		for _, kind := range medicalKinds {
+			mark := ""
+			for _, k := range r.Kinds {
+				if k == kind {
+					mark = "○"
+				}
+			}
+			record = append(record, mark)
+		}
+		records = append(records, append(record, strconv.Itoa(r.Amount), strconv.Itoa(r.Reimbursed)))
+	}
+
+	income := ""
+	if report.Income > 0 {
+		income = strconv.Itoa(report.Income)
+	}
+	records = append(records,
+		[]string{},
+		[]string{"医療費の合計 (A)", strconv.Itoa(report.Total)},
+		[]string{"保険金などで補填される金額 (B)", strconv.Itoa(report.Reimbursed)},
+		[]string{"差引金額 (C) = (A) - (B)", strconv.Itoa(report.Net)},
+		[]string{"所得金額等の合計額 (D)", income},
+		[]string{"(D) × 0.05 と 10 万円のいずれか少ない金額 (F)", strconv.Itoa(report.Threshold)},
+		[]string{"医療費控除額 (C) - (F)（最高 200 万円）", strconv.Itoa(report.Deduction)},
+	)
+
+	return csvWithBOM(records)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestNormalizeMedicalInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   model.ExpenseInput
+		wantErr bool
+	}{
+		{name: "区分なし", input: model.ExpenseInput{Amount: 3000, Patient: " 太郎 "}},
+		{name: "補填額は金額まで", input: model.ExpenseInput{Amount: 3000, MedicalKind: MedicalMedicine, ReimbursedAmount: 3000}},
+		{name: "不正な区分", input: model.ExpenseInput{Amount: 3000, MedicalKind: "dental"}, wantErr: true},
+		{name: "補填額が金額を超える", input: model.ExpenseInput{Amount: 3000, ReimbursedAmount: 3001}, wantErr: true},
+		{name: "補填額がマイナス", input: model.ExpenseInput{Amount: 3000, ReimbursedAmount: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		input := tt.input
+		err := normalizeMedicalInput(&input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: normalizeMedicalInput() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if strings.TrimSpace(input.Patient) != input.Patient {
+			t.Errorf("%s: Patient = %q, want 前後の空白なし", tt.name, input.Patient)
+		}
+	}
+}
+
+func TestBuildMedicalReport(t *testing.T) {
+	catMaps := &CategoryMaps{IsMedical: map[string]bool{"medical": true}}
+	expenses := []model.Expense{
+		// 支払先が空なら場所でまとめる
+		{Date: "2025-02-01", Category: "medical", Amount: 150000, Patient: "太郎", Place: "A病院", ReimbursedAmount: 30000},
+		{Date: "2025-03-01", Category: "medical", Amount: 2000, Patient: "太郎", Provider: "A病院", MedicalKind: MedicalMedicine},
+		// 返金は差し引く
+		{Date: "2025-03-05", Type: ExpenseTypeRefund, Category: "medical", Amount: 500, Patient: "太郎", Provider: "A病院"},
+		// 明細のうち医療費カテゴリの分だけが対象
+		{Date: "2025-04-01", Category: "daily", Amount: 5000, Patient: "花子", Place: "Bドラッグ", MedicalKind: MedicalMedicine, Items: []model.LineItem{
+			{Category: "medical", Amount: 1200},
+			{Category: "daily", Amount: 3800},
+		}},
+		// 医療費以外のカテゴリ・振替は対象外
+		{Date: "2025-04-02", Category: "daily", Amount: 800},
+		{Date: "2025-04-03", Type: ExpenseTypeTransfer, Amount: 10000},
+	}
+
+	got := buildMedicalReport("2025", expenses, 0, catMaps, "")
+	wantRows := []model.MedicalRow{
+		{Patient: "太郎", Provider: "A病院", Kinds: []string{MedicalTreatment, MedicalMedicine}, Amount: 151500, Reimbursed: 30000, Count: 3},
+		{Patient: "花子", Provider: "Bドラッグ", Kinds: []string{MedicalMedicine}, Amount: 1200, Count: 1},
+	}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("Rows = %+v, want %+v", got.Rows, wantRows)
+	}
+	if got.Total != 152700 || got.Reimbursed != 30000 || got.Net != 122700 || got.Threshold != 100000 || got.Deduction != 22700 {
+		t.Errorf("A, B, C, F, 控除額 = %d, %d, %d, %d, %d, want 152700, 30000, 122700, 100000, 22700",
+			got.Total, got.Reimbursed, got.Net, got.Threshold, got.Deduction)
+	}
+
+	// 所得金額等が 200 万円未満なら足切り額は所得金額等の 5%
+	got = buildMedicalReport("2025", expenses, 1500000, catMaps, "")
+	if got.Threshold != 75000 || got.Deduction != 47700 {
+		t.Errorf("F, 控除額 = %d, %d, want 75000, 47700", got.Threshold, got.Deduction)
+	}
+
+	csv, err := medicalReportCSV(got)
+	if err != nil {
+		t.Fatalf("medicalReportCSV() error = %v", err)
+	}
+	for _, want := range []string{"太郎,A病院,○,,○,,151500,30000", "花子,Bドラッグ,,,○,,1200,0", "所得金額等の合計額 (D),1500000"} {
+		if !strings.Contains(csv, want) {
+			t.Errorf("CSV に %q が含まれていません:\n%s", want, csv)
+		}
+	}
+}
+
+func TestBuildMedicalReportMasksSummaryVisibility(t *testing.T) {
+	catMaps := &CategoryMaps{IsMedical: map[string]bool{"medical": true}}
+	expenses := []model.Expense{
+		{Date: "2025-02-01", Category: "medical", Amount: 3000, Patient: "太郎", Provider: "A病院", CreatedBy: "a@example.com", Visibility: VisibilitySummary},
+		// 他人の「金額のみ公開」は医療を受けた方・支払先（場所）を伏せて金額だけ含める
+		{Date: "2025-02-02", Category: "medical", Amount: 5000, Patient: "花子", Place: "B心療内科", CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		{Date: "2025-02-03", Category: "medical", Amount: 700, Patient: "花子", Provider: "C薬局", MedicalKind: MedicalMedicine, CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		{Date: "2025-02-04", Category: "medical", Amount: 1000, Patient: "花子", Provider: "D病院", CreatedBy: "b@example.com"},
+	}
+
+	got := buildMedicalReport("2025", expenses, 0, catMaps, "a@example.com")
+	wantRows := []model.MedicalRow{
+		{Kinds: []string{MedicalTreatment, MedicalMedicine}, Amount: 5700, Count: 2},
+		{Patient: "太郎", Provider: "A病院", Kinds: []string{MedicalTreatment}, Amount: 3000, Count: 1},
+		{Patient: "花子", Provider: "D病院", Kinds: []string{MedicalTreatment}, Amount: 1000, Count: 1},
+	}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("Rows = %+v, want %+v", got.Rows, wantRows)
+	}
+	if got.Total != 9700 {
+		t.Errorf("Total = %d, want 9700", got.Total)
+	}
+	csv, err := medicalReportCSV(got)
+	if err != nil {
+		t.Fatalf("medicalReportCSV() error = %v", err)
+	}
+	if strings.Contains(csv, "B心療内科") || strings.Contains(csv, "C薬局") {
+		t.Errorf("CSV に伏せた支払先が含まれています:\n%s", csv)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+const (
+	maskedCategoryName  = "個人出費" // 他人の「金額のみ公開」の支出をまとめるカテゴリ名
+	maskedCategoryColor = defaultCategoryColor
+)
+
+// GetMemberSummary は指定月と直近 13 ヶ月の支出を登録者（createdBy）別・カテゴリ別に集計する。
+// 他人の private は除外し、他人の「金額のみ公開」は合計に含めるがカテゴリは「個人出費」にまとめる。
+// 月の区切りは GetMonthlySummary と同じ（calendar=true はカレンダー月）。
+func GetMemberSummary(ctx context.Context, client *dynamo.Client, month string, userEmail string, calendar bool) (*model.MemberSummary, error) {
+	first := month
+	for i := 0; i < 12; i++ {
+		first = previousMonth(first)
+	}
+	months, err := monthRange(first, month)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
+	names, err := memberNames(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, months, startDay)
+	if err != nil {
+		return nil, err
+	}
+	for _, ym := range months {
+		byMonth[ym] = FilterExpensesForSummary(byMonth[ym], userEmail)
+	}
+	return buildMemberSummary(months, byMonth, names, catMaps, userEmail), nil
+}
+
+// buildMemberSummary は月別の支出（months の最後が指定月）からメンバー別集計を作る
+func buildMemberSummary(months []string, byMonth map[string][]model.Expense, names map[string]string, catMaps *CategoryMaps, userEmail string) *model.MemberSummary {
+	month := months[len(months)-1]
+	result := &model.MemberSummary{Month: month, From: months[0], Months: make([]model.MemberMonth, len(months))}
+
+	window := newMemberTotals()
+	for i, ym := range months {
+		current := newMemberTotals()
+		for j := range byMonth[ym] {
+			e := &byMonth[ym][j]
+			current.add(e, catMaps, userEmail)
+			window.add(e, catMaps, userEmail)
+		}
+		result.Months[i] = model.MemberMonth{Month: ym, Totals: current.total}
+		if ym == month {
+			result.Members = current.summaries(names, catMaps)
+		}
+	}
+	result.Window = window.summaries(names, catMaps)
+	return result
+}
+
+// memberTotals はメンバー別・カテゴリ別の集計途中の値
+type memberTotals struct {
+	total      map[string]int            // メールアドレス→合計
+	byCategory map[string]map[string]int // メールアドレス→カテゴリID→金額（内訳除外を除く）
+}
+
+func newMemberTotals() *memberTotals {
+	return &memberTotals{total: make(map[string]int), byCategory: make(map[string]map[string]int)}
+}
+
+// add は支出を登録者の合計に加える（他人の「金額のみ公開」は内訳除外のカテゴリも含めてカテゴリ "" に計上）
+func (m *memberTotals) add(e *model.Expense, catMaps *CategoryMaps, userEmail string) {
+	lines := summaryLines(e, catMaps)
+	if len(lines) == 0 {
+		return
+	}
+	masked := e.CreatedBy != userEmail && EffectiveVisibility(e.Visibility) == VisibilitySummary
+	if m.byCategory[e.CreatedBy] == nil {
+		m.byCategory[e.CreatedBy] = make(map[string]int)
+	}
+	for _, li := range lines {
+		m.total[e.CreatedBy] += li.Amount
+		// 伏せたカテゴリが内訳の有無から推測できないよう、内訳除外の判定より先に伏せる
+		cat := li.Category
+		if masked {
+			cat = ""
+		} else if catMaps.ExcludeFromBreakdown[cat] {
+			continue
+		}
+		m.byCategory[e.CreatedBy][cat] += li.Amount
+	}
+}
+
+// summaries はメンバー別の集計を返す（メールアドレス順、カテゴリはマスタの並び順で「個人出費」は最後）
+func (m *memberTotals) summaries(names map[string]string, catMaps *CategoryMaps) []model.MemberTotal {
+	result := []model.MemberTotal{}
+	for email, total := range m.total {
+		member := model.MemberTotal{Email: email, Name: displayName(email, names), Total: total, ByCategory: []model.CategorySummary{}}
+		for cat, amount := range m.byCategory[email] {
+			member.ByCategory = append(member.ByCategory, memberCategory(cat, amount, catMaps))
+		}
+		sort.Slice(member.ByCategory, func(i, j int) bool {
+			a, b := member.ByCategory[i].CategoryID, member.ByCategory[j].CategoryID
+			if (a == "") != (b == "") {
+				return b == ""
+			}
+			return categoryBefore(a, b, catMaps)
+		})
+		result = append(result, member)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Email < result[j].Email
+	})
+	return result
+}
+
+// memberCategory はカテゴリ別の金額を CategorySummary にする（"" は「個人出費」）
+func memberCategory(cat string, amount int, catMaps *CategoryMaps) model.CategorySummary {
+	if cat == "" {
+		return model.CategorySummary{Category: maskedCategoryName, Amount: amount, Color: maskedCategoryColor}
+	}
+	name, color := catMaps.Name[cat], catMaps.Color[cat]
+	if name == "" {
+		name = cat
+	}
+	if color == "" {
+		color = defaultCategoryColor
+	}
+	return model.CategorySummary{CategoryID: cat, Category: name, Amount: amount, Color: color}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestBuildMemberSummary(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:                 map[string]string{"food": "食費", "daily": "日用品", "rent": "家賃"},
+		Color:                map[string]string{"food": "#f00", "daily": "#0f0", "rent": "#00f"},
+		IsExpense:            map[string]bool{"food": true, "daily": true, "rent": true, "income": false},
+		SortOrder:            map[string]int{"food": 1, "daily": 2, "rent": 3},
+		ExcludeFromBreakdown: map[string]bool{"rent": true},
+	}
+	names := map[string]string{"a@example.com": "あき", "b@example.com": ""}
+	months := []string{"2026-02", "2026-03"}
+	byMonth := map[string][]model.Expense{
+		"2026-02": {
+			{Date: "2026-02-10", Category: "rent", Amount: 80000, CreatedBy: "a@example.com"},
+			{Date: "2026-02-11", Category: "food", Amount: 2000, CreatedBy: "b@example.com"},
+		},
+		"2026-03": {
+			{Date: "2026-03-01", Category: "daily", Amount: 500, CreatedBy: "a@example.com"},
+			{Date: "2026-03-02", Category: "food", Amount: 3000, CreatedBy: "a@example.com"},
+			{Date: "2026-03-03", Type: ExpenseTypeRefund, Category: "food", Amount: 1000, CreatedBy: "a@example.com"},
+			// 他人の「金額のみ公開」は合計に含めてカテゴリは伏せる
+			{Date: "2026-03-04", Category: "food", Amount: 1500, CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+			// 内訳除外のカテゴリでも伏せた支出は「個人出費」に入れる
+			{Date: "2026-03-04", Category: "rent", Amount: 1000, CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+			{Date: "2026-03-05", Category: "daily", Amount: 700, CreatedBy: "b@example.com"},
+			// 対象外: 収入・振替
+			{Date: "2026-03-06", Category: "income", Amount: 300000, CreatedBy: "a@example.com"},
+			{Date: "2026-03-07", Type: ExpenseTypeTransfer, Amount: 5000, CreatedBy: "b@example.com"},
+		},
+	}
+
+	got := buildMemberSummary(months, byMonth, names, catMaps, "a@example.com")
+
+	wantMembers := []model.MemberTotal{
+		{Email: "a@example.com", Name: "あき", Total: 2500, ByCategory: []model.CategorySummary{
+			{CategoryID: "food", Category: "食費", Amount: 2000, Color: "#f00"},
+			{CategoryID: "daily", Category: "日用品", Amount: 500, Color: "#0f0"},
+		}},
+		{Email: "b@example.com", Name: "b", Total: 3200, ByCategory: []model.CategorySummary{
+			{CategoryID: "daily", Category: "日用品", Amount: 700, Color: "#0f0"},
+			{Category: "個人出費", Amount: 2500, Color: "#AEB6BF"},
+		}},
+	}
+	if !reflect.DeepEqual(got.Members, wantMembers) {
+		t.Errorf("Members = %+v, want %+v", got.Members, wantMembers)
+	}
+
+	// 13 ヶ月の合計は内訳除外（家賃）を合計のみに含める
+	if got.Window[0].Total != 82500 || len(got.Window[0].ByCategory) != 2 {
+		t.Errorf("Window[a] = %+v, want total 82500 without rent breakdown", got.Window[0])
+	}
+	if got.Window[1].Total != 5200 {
+		t.Errorf("Window[b].Total = %d, want 5200", got.Window[1].Total)
+	}
+
+	wantMonths := []model.MemberMonth{
+		{Month: "2026-02", Totals: map[string]int{"a@example.com": 80000, "b@example.com": 2000}},
+		{Month: "2026-03", Totals: map[string]int{"a@example.com": 2500, "b@example.com": 3200}},
+	}
+	if !reflect.DeepEqual(got.Months, wantMonths) {
+		t.Errorf("Months = %+v, want %+v", got.Months, wantMonths)
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	names := map[string]string{"a@example.com": "あき"}
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "a@example.com", want: "あき"},
+		{email: "b@example.com", want: "b"},
+		{email: "", want: "未設定"},
+	}
+	for _, tt := range tests {
+		if got := displayName(tt.email, names); got != tt.want {
+			t.Errorf("displayName(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
@@ -61,6 +61,7 @@ func MigrateFromSheets(ctx context.Context, dynamoClient *dynamo.Client) (*Migra
 		return result, fmt.Errorf("expenses 書き込みエラー: %w", err)
 	}
 	log.Printf("[migrate] expenses: %d 件移行完了", result.Expenses)
+	invalidateAllSummaryCaches(ctx, dynamoClient)
 
 	// categories
 	log.Println("[migrate] categories 移行開始")
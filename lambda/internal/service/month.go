@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 月の区切りは世帯設定の monthStartDay（月の開始日）で決まる。
+// 開始日が N の場合、月 "YYYY-MM" は YYYY-MM-N から翌月の N-1 日までを指す（N=1 はカレンダー月）。
+// 支出はカレンダー月（yearMonth）ごとに保存されているため、開始日が 1 以外の月は
+// 当月と翌月のパーティションを参照して期間内の記録を抜き出す。
+
+const maxMonthStartDay = 28 // 2 月にも存在する日まで
+
+// effectiveMonthStartDay は未設定（0）を 1（カレンダー月）に正規化する
+func effectiveMonthStartDay(day int) int {
+	if day < 1 || day > maxMonthStartDay {
+		return 1
+	}
+	return day
+}
+
+// loadMonthStartDay は世帯設定の月の開始日を返す（calendar=true の場合は 1）
+func loadMonthStartDay(ctx context.Context, client *dynamo.Client, calendar bool) (int, error) {
+	if calendar {
+		return 1, nil
+	}
+	settings, err := client.GetHouseholdSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return effectiveMonthStartDay(settings.MonthStartDay), nil
+}
+
+// monthOf は日付 "YYYY-MM-DD" が属する月を返す（開始日より前の日は前月）
+func monthOf(date string, startDay int) string {
+	if len(date) < 7 {
+		return ""
+	}
+	ym := date[:7]
+	if startDay <= 1 || len(date) < 10 {
+		return ym
+	}
+	if d, _ := strconv.Atoi(date[8:10]); d < startDay {
+		return previousMonth(ym)
+	}
+	return ym
+}
+
+// monthDates は月の初日と末日（"YYYY-MM-DD"）を返す
+func monthDates(month string, startDay int) (string, string) {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return "", ""
+	}
+	startDay = effectiveMonthStartDay(startDay)
+	start := t.AddDate(0, 0, startDay-1)
+	end := t.AddDate(0, 1, startDay-2)
+	return start.Format("2006-01-02"), end.Format("2006-01-02")
+}
+
+// queryMonthExpenses は月（開始日基準）に属する記録を返す
+func queryMonthExpenses(ctx context.Context, client *dynamo.Client, month string, startDay int) ([]model.Expense, error) {
+	expenses, err := client.QueryExpensesByMonth(ctx, month)
+	if err != nil || startDay <= 1 {
+		return expenses, err
+	}
+	_, end := monthDates(month, startDay)
+	next, err := client.QueryExpensesByMonth(ctx, end[:7])
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Expense
+	for _, e := range append(expenses, next...) {
+		if monthOf(e.Date, startDay) == month {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// monthQueryConcurrency は複数月の GSI クエリの同時実行数の上限
+const monthQueryConcurrency = 6
+
+// queryMonthsExpenses は複数の月（開始日基準）に属する記録を月別に返す。
+// 必要なカレンダー月（開始日が 1 以外の月は翌月も）を重複なく、同時実行数を制限して並列にクエリする。
+func queryMonthsExpenses(ctx context.Context, client *dynamo.Client, months []string, startDay int) (map[string][]model.Expense, error) {
+	var calendarMonths []string
+	seen := make(map[string]bool)
+	for _, ym := range months {
+		need := []string{ym}
+		if startDay > 1 {
+			_, end := monthDates(ym, startDay)
+			need = append(need, end[:7])
+		}
+		for _, cm := range need {
+			if !seen[cm] {
+				seen[cm] = true
+				calendarMonths = append(calendarMonths, cm)
+			}
+		}
+	}
+	fetched, err := fetchMonths(ctx, calendarMonths, client.QueryExpensesByMonth)
+	if err != nil {
+		return nil, err
+	}
+	return splitByMonth(months, calendarMonths, fetched, startDay), nil
+}
+
+// fetchMonths は月ごとに fetch を並列に呼び、結果を月別に返す（同時実行数は monthQueryConcurrency まで）。
+// いずれかが失敗した場合は残りを取り消して最初のエラーを返す。
+func fetchMonths(ctx context.Context, months []string, fetch func(context.Context, string) ([]model.Expense, error)) (map[string][]model.Expense, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]model.Expense, len(months))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, monthQueryConcurrency)
+	for i, ym := range months {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			expenses, err := fetch(ctx, ym)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = expenses
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	byMonth := make(map[string][]model.Expense, len(months))
+	for i, ym := range months {
+		byMonth[ym] = results[i]
+	}
+	return byMonth, nil
+}
+
+// splitByMonth はカレンダー月ごとの記録を月（開始日基準）に振り分ける（カレンダー月の並び順を保つ）
+func splitByMonth(months []string, calendarMonths []string, fetched map[string][]model.Expense, startDay int) map[string][]model.Expense {
+	result := make(map[string][]model.Expense, len(months))
+	if startDay <= 1 {
+		for _, ym := range months {
+			result[ym] = fetched[ym]
+		}
+		return result
+	}
+	wanted := make(map[string]bool, len(months))
+	for _, ym := range months {
+		wanted[ym] = true
+	}
+	for _, cm := range calendarMonths {
+		for _, e := range fetched[cm] {
+			if ym := monthOf(e.Date, startDay); wanted[ym] {
+				result[ym] = append(result[ym], e)
+			}
+		}
+	}
+	return result
+}
+
+// flattenMonths は月別の記録を months の順に連結する
+func flattenMonths(months []string, byMonth map[string][]model.Expense) []model.Expense {
+	var result []model.Expense
+	for _, ym := range months {
+		result = append(result, byMonth[ym]...)
+	}
+	return result
+}
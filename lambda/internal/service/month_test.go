@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestMonthOf(t *testing.T) {
+	tests := []struct {
+		date     string
+		startDay int
+		want     string
+	}{
+		{"2025-03-01", 1, "2025-03"},
+		{"2025-03-31", 1, "2025-03"},
+		{"2025-03-24", 25, "2025-02"},
+		{"2025-03-25", 25, "2025-03"},
+		{"2025-01-10", 25, "2024-12"},
+		{"", 25, ""},
+	}
+	for _, tt := range tests {
+		if got := monthOf(tt.date, tt.startDay); got != tt.want {
+			t.Errorf("monthOf(%q, %d) = %q, want %q", tt.date, tt.startDay, got, tt.want)
+		}
+	}
+}
+
+func TestMonthDates(t *testing.T) {
+	tests := []struct {
+		month    string
+		startDay int
+		wantFrom string
+		wantTo   string
+	}{
+		{"2025-02", 1, "2025-02-01", "2025-02-28"},
+		{"2025-01", 25, "2025-01-25", "2025-02-24"},
+		{"2024-12", 25, "2024-12-25", "2025-01-24"},
+		{"2025-02", 28, "2025-02-28", "2025-03-27"},
+	}
+	for _, tt := range tests {
+		from, to := monthDates(tt.month, tt.startDay)
+		if from != tt.wantFrom || to != tt.wantTo {
+			t.Errorf("monthDates(%q, %d) = %q, %q, want %q, %q", tt.month, tt.startDay, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
+
+func TestMonthStartDayAggregation(t *testing.T) {
+	expenses := []model.Expense{
+		{Date: "2025-02-24", Payer: "財布", Category: "food", Amount: 100},
+		{Date: "2025-02-25", Payer: "財布", Category: "food", Amount: 200},
+		{Date: "2025-03-24", Payer: "財布", Category: "food", Amount: 400},
+		{Date: "2025-03-25", Payer: "財布", Category: "food", Amount: 800},
+	}
+	catMaps := testCategoryMaps()
+
+	// 開始日 25 の "2025-02" は 2/25〜3/24
+	if got := monthDataFromCache(buildSummaryCache(expenses, "2025-02", 25), "", catMaps, "").Total; got != 600 {
+		t.Errorf("2025-02 (startDay 25) total = %d, want 600", got)
+	}
+	if got := monthDataFromCache(buildSummaryCache(expenses, "2025-02", 1), "", catMaps, "").Total; got != 300 {
+		t.Errorf("2025-02 (startDay 1) total = %d, want 300", got)
+	}
+
+	payer := &model.Payer{Name: "財布", OpeningBalance: 10000, OpeningDate: "2025-02-10"}
+	history := computePayerBalanceHistory(expenses, payer, []string{"2025-01", "2025-02", "2025-03"}, 25, catMaps)
+	want := []struct{ carryover, spent, balance int }{
+		{10000, 100, 9900}, // 開始日 2/10 は "2025-01"（1/25〜2/24）に属する
+		{9900, 600, 9300},
+		{9300, 800, 8500},
+	}
+	for i, w := range want {
+		b := history[i]
+		if b.Carryover != w.carryover || b.MonthSpent != w.spent || b.Balance != w.balance {
+			t.Errorf("history[%d] = %+v, want carryover %d, spent %d, balance %d", i, b, w.carryover, w.spent, w.balance)
+		}
+	}
+}
+
+func TestFetchMonths(t *testing.T) {
+	months := []string{"2025-01", "2025-02", "2025-03", "2025-04", "2025-05", "2025-06", "2025-07", "2025-08", "2025-09", "2025-10", "2025-11", "2025-12", "2026-01"}
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	fetch := func(ctx context.Context, ym string) ([]model.Expense, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		return []model.Expense{{ID: ym}}, nil
+	}
+
+	got, err := fetchMonths(context.Background(), months, fetch)
+	if err != nil {
+		t.Fatalf("fetchMonths() error: %v", err)
+	}
+	for _, ym := range months {
+		if len(got[ym]) != 1 || got[ym][0].ID != ym {
+			t.Errorf("got[%s] = %+v", ym, got[ym])
+		}
+	}
+	if maxRunning > monthQueryConcurrency {
+		t.Errorf("max concurrency = %d, want <= %d", maxRunning, monthQueryConcurrency)
+	}
+
+	errQuery := errors.New("query failed")
+	_, err = fetchMonths(context.Background(), months, func(ctx context.Context, ym string) ([]model.Expense, error) {
+		if ym == "2025-03" {
+			return nil, errQuery
+		}
+		return nil, nil
+	})
+	if !errors.Is(err, errQuery) {
+		t.Errorf("fetchMonths() error = %v, want %v", err, errQuery)
+	}
+}
+
+func TestSplitByMonth(t *testing.T) {
+	fetched := map[string][]model.Expense{
+		"2025-02": {{ID: "a", Date: "2025-02-24"}, {ID: "b", Date: "2025-02-25"}},
+		"2025-03": {{ID: "c", Date: "2025-03-24"}, {ID: "d", Date: "2025-03-25"}},
+		"2025-04": {{ID: "e", Date: "2025-04-01"}},
+	}
+	ids := func(expenses []model.Expense) []string {
+		var result []string
+		for _, e := range expenses {
+			result = append(result, e.ID)
+		}
+		return result
+	}
+
+	// 開始日 25: "2025-02" は 2/25〜3/24、"2025-03" は 3/25〜4/24（"2025-01" の記録は対象外）
+	got := splitByMonth([]string{"2025-02", "2025-03"}, []string{"2025-02", "2025-03", "2025-04"}, fetched, 25)
+	if !reflect.DeepEqual(ids(got["2025-02"]), []string{"b", "c"}) || !reflect.DeepEqual(ids(got["2025-03"]), []string{"d", "e"}) {
+		t.Errorf("splitByMonth(25) = %v / %v", ids(got["2025-02"]), ids(got["2025-03"]))
+	}
+
+	got = splitByMonth([]string{"2025-02"}, []string{"2025-02"}, fetched, 1)
+	if !reflect.DeepEqual(ids(got["2025-02"]), []string{"a", "b"}) {
+		t.Errorf("splitByMonth(1) = %v", ids(got["2025-02"]))
+	}
+}
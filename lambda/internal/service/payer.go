@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -22,15 +23,28 @@ func GetAllPayers(ctx context.Context, client *dynamo.Client) ([]model.Payer, er
 
 // CreatePayer は支払元を作成する
 func CreatePayer(ctx context.Context, client *dynamo.Client, input *model.PayerInput) (*model.Payer, error) {
-	if input.Name == "" {
-		return nil, apperror.New("名前は必須です")
+	if err := validatePayerInput(input); err != nil {
+		return nil, err
+	}
+	payers, err := client.GetAllPayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePaymentSource(input, "", payers); err != nil {
+		return nil, err
 	}
 	p := &model.Payer{
-		ID:           uuid.New().String(),
-		Name:         input.Name,
-		SortOrder:    input.SortOrder,
-		IsActive:     input.IsActive,
-		TrackBalance: input.TrackBalance,
+		ID:                 uuid.New().String(),
+		Name:               input.Name,
+		SortOrder:          input.SortOrder,
+		IsActive:           input.IsActive,
+		TrackBalance:       input.TrackBalance,
+		OpeningBalance:     input.OpeningBalance,
+		OpeningDate:        input.OpeningDate,
+		ClosingDay:         input.ClosingDay,
+		PaymentDay:         input.PaymentDay,
+		PaymentMonthOffset: input.PaymentMonthOffset,
+		PaymentSource:      input.PaymentSource,
 	}
 	if err := client.PutPayer(ctx, p); err != nil {
 		return nil, err
@@ -40,23 +54,121 @@ func CreatePayer(ctx context.Context, client *dynamo.Client, input *model.PayerI
 
 // UpdatePayer は支払元を更新する
 func UpdatePayer(ctx context.Context, client *dynamo.Client, id string, input *model.PayerInput) (*model.Payer, error) {
-	if input.Name == "" {
-		return nil, apperror.New("名前は必須です")
+	if err := validatePayerInput(input); err != nil {
+		return nil, err
+	}
+	payers, err := client.GetAllPayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePaymentSource(input, id, payers); err != nil {
+		return nil, err
 	}
+	before := payerByID(payers, id)
 	p := &model.Payer{
-		ID:           id,
-		Name:         input.Name,
-		SortOrder:    input.SortOrder,
-		IsActive:     input.IsActive,
-		TrackBalance: input.TrackBalance,
+		ID:                 id,
+		Name:               input.Name,
+		SortOrder:          input.SortOrder,
+		IsActive:           input.IsActive,
+		TrackBalance:       input.TrackBalance,
+		OpeningBalance:     input.OpeningBalance,
+		OpeningDate:        input.OpeningDate,
+		ClosingDay:         input.ClosingDay,
+		PaymentDay:         input.PaymentDay,
+		PaymentMonthOffset: input.PaymentMonthOffset,
+		PaymentSource:      input.PaymentSource,
 	}
 	if err := client.PutPayer(ctx, p); err != nil {
 		return nil, err
 	}
+	rebuild, stale := payerSnapshotUpdate(before, p)
+	if stale != "" {
+		deletePayerBalanceSnapshots(ctx, client, stale)
+	}
+	if rebuild {
+		rebuildPayerBalanceSnapshots(ctx, client, p)
+	}
 	return p, nil
 }
 
-// DeletePayer は支払元を削除する
+// DeletePayer は支払元を削除する（残高スナップショットも削除する）
 func DeletePayer(ctx context.Context, client *dynamo.Client, id string) error {
-	return client.DeletePayer(ctx, id)
+	payers, err := client.GetAllPayers(ctx)
+	if err != nil {
+		return err
+	}
+	before := payerByID(payers, id)
+	if err := client.DeletePayer(ctx, id); err != nil {
+		return err
+	}
+	if before != nil {
+		deletePayerBalanceSnapshots(ctx, client, before.Name)
+	}
+	return nil
+}
+
+// payerByID は ID で支払元を探す（nil = 未登録）
+func payerByID(payers []model.Payer, id string) *model.Payer {
+	for i := range payers {
+		if payers[i].ID == id {
+			return &payers[i]
+		}
+	}
+	return nil
+}
+
+// payerSnapshotUpdate は支払元の変更（before=nil は新規）に伴う残高スナップショットの更新を返す。
+// rebuild は変更後の名前で作り直すかどうか、stale は削除すべきスナップショットの支払元名（名前の変更・追跡対象外になった場合）。
+func payerSnapshotUpdate(before *model.Payer, after *model.Payer) (rebuild bool, stale string) {
+	tracked := after.TrackBalance && after.IsActive
+	if before == nil {
+		return tracked, ""
+	}
+	if before.Name != after.Name || !tracked {
+		stale = before.Name
+	}
+	wasTracked := before.TrackBalance && before.IsActive
+	rebuild = tracked && (!wasTracked || stale != "" ||
+		before.OpeningDate != after.OpeningDate || before.OpeningBalance != after.OpeningBalance)
+	return rebuild, stale
+}
+
+// validatePayerInput は支払元の登録内容を検証する
+func validatePayerInput(input *model.PayerInput) error {
+	if input.Name == "" {
+		return apperror.New("名前は必須です")
+	}
+	if input.OpeningDate != "" {
+		if _, err := time.Parse("2006-01-02", input.OpeningDate); err != nil {
+			return apperror.New("残高管理開始日は YYYY-MM-DD 形式で指定してください")
+		}
+	}
+	if input.ClosingDay < 0 || input.ClosingDay > 31 {
+		return apperror.New("締め日は 1-31 で指定してください")
+	}
+	if input.ClosingDay > 0 {
+		if input.PaymentDay < 1 || input.PaymentDay > 31 {
+			return apperror.New("締め日を指定する場合、支払日（1-31）は必須です")
+		}
+		if input.PaymentMonthOffset < 0 || input.PaymentMonthOffset > 3 {
+			return apperror.New("支払月は締め月の 1-3 ヶ月後で指定してください（0 は翌月）")
+		}
+		if input.PaymentSource == input.Name {
+			return apperror.New("引き落とし元に自分自身は指定できません")
+		}
+	}
+	return nil
+}
+
+// validatePaymentSource は引き落とし元が登録済みの支払元（更新中の支払元 id 自身を除く）かどうかを検証する
+func validatePaymentSource(input *model.PayerInput, id string, payers []model.Payer) error {
+	if input.PaymentSource == "" {
+		return nil
+	}
+	for _, p := range payers {
+		if p.ID != id && p.Name == input.PaymentSource {
+			return nil
+		}
+	}
+	return apperror.Newf("引き落とし元の支払元「%s」が見つかりません", input.PaymentSource)
 }
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// ピボット集計の次元
+const (
+	PivotCategory = "category"
+	PivotPayer    = "payer"
+	PivotPlace    = "place"
+	PivotCreator  = "creator"
+	PivotTag      = "tag"
+	PivotWeek     = "week"  // 週初日（世帯設定の開始曜日）
+	PivotMonth    = "month" // 月（開始日基準）
+)
+
+var pivotDimensions = map[string]bool{
+	PivotCategory: true, PivotPayer: true, PivotPlace: true, PivotCreator: true,
+	PivotTag: true, PivotWeek: true, PivotMonth: true,
+}
+
+// GetPivot は期間（from〜to の月、両端を含む）の支出を groupBy（1〜2 次元）で集計する。
+// filters は次元ごとの値のいずれかに一致する支出（明細）に絞り込む（タグはいずれかのタグが一致）。
+// 集計対象は summaryLines の明細で、excludeFromBreakdown のカテゴリは合計にのみ含める。他人の「金額のみ公開」の支出は場所・タグを伏せる。
+func GetPivot(ctx context.Context, client *dynamo.Client, from string, to string, groupBy []string, filters map[string][]string, userEmail string, calendar bool) (*model.PivotSummary, error) {
+	if err := validatePivot(groupBy, filters); err != nil {
+		return nil, err
+	}
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
+	weekStart, err := loadWeekStart(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, months, startDay)
+	if err != nil {
+		return nil, err
+	}
+	expenses := FilterExpensesForSummary(flattenMonths(months, byMonth), userEmail)
+	result := buildPivot(expenses, groupBy, filters, catMaps, startDay, weekStart, userEmail)
+	result.From, result.To = from, to
+	return result, nil
+}
+
+// validatePivot は groupBy（1〜2 個、重複なし）と filters の次元を検証する
+func validatePivot(groupBy []string, filters map[string][]string) *apperror.AppError {
+	if len(groupBy) == 0 || len(groupBy) > 2 {
+		return apperror.New("groupBy は 1〜2 個指定してください")
+	}
+	if len(groupBy) == 2 && groupBy[0] == groupBy[1] {
+		return apperror.New("groupBy に同じ次元は指定できません")
+	}
+	for _, dim := range groupBy {
+		if !pivotDimensions[dim] {
+			return apperror.Newf("groupBy の値が不正です: %s", dim)
+		}
+	}
+	for dim := range filters {
+		if !pivotDimensions[dim] {
+			return apperror.Newf("filters の次元が不正です: %s", dim)
+		}
+	}
+	return nil
+}
+
+// buildPivot は支出を明細ごとに次元の値へ振り分けて集計する。
+// タグは 1 件の支出を各タグに計上するため、タグの次元を含む集計の合計は Total と一致しないことがある。
+func buildPivot(expenses []model.Expense, groupBy []string, filters map[string][]string, catMaps *CategoryMaps, startDay int, weekStart string, userEmail string) *model.PivotSummary {
+	result := &model.PivotSummary{GroupBy: groupBy, Rows: []model.PivotCell{}, Cells: []model.PivotCell{}}
+	rows := make(map[string]*model.PivotCell)
+	cells := make(map[string]*model.PivotCell)
+
+	for i := range expenses {
+		e := &expenses[i]
+		dims := expenseDimensions(e, startDay, weekStart, userEmail)
+		touched := make(map[*model.PivotCell]bool)
+		for _, li := range summaryLines(e, catMaps) {
+			dims[PivotCategory] = []string{li.Category}
+			if !matchesPivotFilters(dims, filters) {
+				continue
+			}
+			result.Total += li.Amount
+			if catMaps.ExcludeFromBreakdown[li.Category] {
+				continue
+			}
+			for _, k1 := range dims[groupBy[0]] {
+				row := pivotCell(rows, []string{k1}, groupBy, catMaps)
+				row.Amount += li.Amount
+				touched[row] = true
+				if len(groupBy) == 1 {
+					continue
+				}
+				for _, k2 := range dims[groupBy[1]] {
+					cell := pivotCell(cells, []string{k1, k2}, groupBy, catMaps)
+					cell.Amount += li.Amount
+					touched[cell] = true
+				}
+			}
+		}
+		if !IsRefund(e) {
+			for c := range touched {
+				c.Count++
+			}
+		}
+	}
+
+	for _, r := range rows {
+		result.Rows = append(result.Rows, *r)
+	}
+	sort.Slice(result.Rows, func(i, j int) bool {
+		return pivotLess(groupBy[0], &result.Rows[i], &result.Rows[j], 0, catMaps)
+	})
+	if len(groupBy) == 1 {
+		result.Cells = append(result.Cells, result.Rows...)
+		return result
+	}
+
+	rank := make(map[string]int, len(result.Rows))
+	for i, r := range result.Rows {
+		rank[r.Keys[0]] = i
+	}
+	for _, c := range cells {
+		result.Cells = append(result.Cells, *c)
+	}
+	sort.Slice(result.Cells, func(i, j int) bool {
+		a, b := &result.Cells[i], &result.Cells[j]
+		if a.Keys[0] != b.Keys[0] {
+			return rank[a.Keys[0]] < rank[b.Keys[0]]
+		}
+		return pivotLess(groupBy[1], a, b, 1, catMaps)
+	})
+	return result
+}
+
+// expenseDimensions は支出のカテゴリ以外の次元の値を返す（タグなしは空文字列 1 件）
+func expenseDimensions(e *model.Expense, startDay int, weekStart string, userEmail string) map[string][]string {
+	place, tags := e.Place, e.Tags
+	if e.CreatedBy != userEmail && EffectiveVisibility(e.Visibility) == VisibilitySummary {
+		place, tags = "", nil
+	}
+	if len(tags) == 0 {
+		tags = []string{""}
+	}
+	return map[string][]string{
+		PivotPayer:   {e.Payer},
+		PivotPlace:   {place},
+		PivotCreator: {e.CreatedBy},
+		PivotTag:     tags,
+		PivotWeek:    {weekOf(e.Date, weekStart)},
+		PivotMonth:   {monthOf(e.Date, startDay)},
+	}
+}
+
+// matchesPivotFilters はすべての絞り込み条件について、次元の値のいずれかが指定値に含まれるかどうかを返す
+func matchesPivotFilters(dims map[string][]string, filters map[string][]string) bool {
+	for dim, values := range filters {
+		if len(values) == 0 {
+			continue
+		}
+		matched := false
+		for _, v := range dims[dim] {
+			for _, want := range values {
+				if v == want {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// pivotCell はキーに対応するセルを返す（なければ作成する）
+func pivotCell(cells map[string]*model.PivotCell, keys []string, groupBy []string, catMaps *CategoryMaps) *model.PivotCell {
+	id := strings.Join(keys, "\x00")
+	if c, ok := cells[id]; ok {
+		return c
+	}
+	c := &model.PivotCell{Keys: keys, Labels: make([]string, len(keys))}
+	for i, k := range keys {
+		c.Labels[i] = pivotLabel(groupBy[i], k, catMaps)
+	}
+	cells[id] = c
+	return c
+}
+
+// pivotLabel は次元の値の表示名を返す
+func pivotLabel(dim string, key string, catMaps *CategoryMaps) string {
+	switch {
+	case dim == PivotCategory && catMaps.Name[key] != "":
+		return catMaps.Name[key]
+	case key != "":
+		return key
+	case dim == PivotTag:
+		return "タグなし"
+	default:
+		return "未設定"
+	}
+}
+
+// pivotLess は次元の並び順で比較する（週・月は古い順、カテゴリはマスタの並び順、それ以外は金額の大きい順）
+func pivotLess(dim string, a, b *model.PivotCell, idx int, catMaps *CategoryMaps) bool {
+	ka, kb := a.Keys[idx], b.Keys[idx]
+	switch dim {
+	case PivotWeek, PivotMonth:
+		return ka < kb
+	case PivotCategory:
+		if oa, ob := catMaps.SortOrder[ka], catMaps.SortOrder[kb]; oa != ob {
+			return oa < ob
+		}
+		return ka < kb
+	}
+	if a.Amount != b.Amount {
+		return a.Amount > b.Amount
+	}
+	return ka < kb
+}
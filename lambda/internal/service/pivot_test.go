@@ -0,0 +1,127 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestValidatePivot(t *testing.T) {
+	tests := []struct {
+		name    string
+		groupBy []string
+		filters map[string][]string
+		wantErr bool
+	}{
+		{name: "1 次元", groupBy: []string{"category"}},
+		{name: "2 次元と絞り込み", groupBy: []string{"category", "place"}, filters: map[string][]string{"tag": {"旅行"}}},
+		{name: "未指定", groupBy: nil, wantErr: true},
+		{name: "3 次元", groupBy: []string{"category", "place", "payer"}, wantErr: true},
+		{name: "重複", groupBy: []string{"place", "place"}, wantErr: true},
+		{name: "不明な次元", groupBy: []string{"shop"}, wantErr: true},
+		{name: "不明な絞り込み", groupBy: []string{"category"}, filters: map[string][]string{"shop": {"A"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validatePivot(tt.groupBy, tt.filters)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validatePivot() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBuildPivot(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:                 map[string]string{"food": "食費", "daily": "日用品", "rent": "家賃"},
+		IsExpense:            map[string]bool{"food": true, "daily": true, "rent": true, "income": false},
+		SortOrder:            map[string]int{"food": 1, "daily": 2, "rent": 3},
+		ExcludeFromBreakdown: map[string]bool{"rent": true},
+	}
+	expenses := []model.Expense{
+		{ID: "1", Date: "2026-03-02", Category: "food", Amount: 3000, Place: "スーパー", Payer: "現金", CreatedBy: "a@example.com", Tags: []string{"旅行"}},
+		{ID: "2", Date: "2026-03-05", Category: "food", Amount: 1000, Place: "コンビニ", Payer: "カード", CreatedBy: "a@example.com"},
+		{ID: "3", Date: "2026-03-10", Amount: 2500, Place: "スーパー", Payer: "カード", CreatedBy: "a@example.com",
+			Items: []model.LineItem{{Category: "food", Amount: 2000}, {Category: "daily", Amount: 500}}},
+		// 他人の「金額のみ公開」は場所を伏せる
+		{ID: "4", Date: "2026-03-12", Category: "daily", Amount: 800, Place: "薬局", Payer: "カード", CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		// 返金はマイナス、件数に含めない
+		{ID: "5", Date: "2026-03-15", Type: ExpenseTypeRefund, Category: "food", Amount: 500, Place: "スーパー", Payer: "現金", CreatedBy: "a@example.com"},
+		// 内訳除外は合計のみ、収入は対象外
+		{ID: "6", Date: "2026-03-25", Category: "rent", Amount: 80000, Payer: "口座", CreatedBy: "a@example.com"},
+		{ID: "7", Date: "2026-03-25", Category: "income", Amount: 300000, Payer: "口座", CreatedBy: "a@example.com"},
+	}
+
+	tests := []struct {
+		name      string
+		groupBy   []string
+		filters   map[string][]string
+		wantTotal int
+		wantRows  []model.PivotCell
+		wantCells []model.PivotCell
+	}{
+		{
+			name:      "カテゴリ",
+			groupBy:   []string{"category"},
+			wantTotal: 86800,
+			wantRows: []model.PivotCell{
+				{Keys: []string{"food"}, Labels: []string{"食費"}, Amount: 5500, Count: 3},
+				{Keys: []string{"daily"}, Labels: []string{"日用品"}, Amount: 1300, Count: 2},
+			},
+		},
+		{
+			name:      "カテゴリ×場所",
+			groupBy:   []string{"category", "place"},
+			wantTotal: 86800,
+			wantRows: []model.PivotCell{
+				{Keys: []string{"food"}, Labels: []string{"食費"}, Amount: 5500, Count: 3},
+				{Keys: []string{"daily"}, Labels: []string{"日用品"}, Amount: 1300, Count: 2},
+			},
+			wantCells: []model.PivotCell{
+				{Keys: []string{"food", "スーパー"}, Labels: []string{"食費", "スーパー"}, Amount: 4500, Count: 2},
+				{Keys: []string{"food", "コンビニ"}, Labels: []string{"食費", "コンビニ"}, Amount: 1000, Count: 1},
+				{Keys: []string{"daily", ""}, Labels: []string{"日用品", "未設定"}, Amount: 800, Count: 1},
+				{Keys: []string{"daily", "スーパー"}, Labels: []string{"日用品", "スーパー"}, Amount: 500, Count: 1},
+			},
+		},
+		{
+			name:      "週×支払元（支払元で絞り込み）",
+			groupBy:   []string{"week", "payer"},
+			filters:   map[string][]string{"payer": {"カード"}},
+			wantTotal: 4300,
+			wantRows: []model.PivotCell{
+				{Keys: []string{"2026-03-02"}, Labels: []string{"2026-03-02"}, Amount: 1000, Count: 1},
+				{Keys: []string{"2026-03-09"}, Labels: []string{"2026-03-09"}, Amount: 3300, Count: 2},
+			},
+			wantCells: []model.PivotCell{
+				{Keys: []string{"2026-03-02", "カード"}, Labels: []string{"2026-03-02", "カード"}, Amount: 1000, Count: 1},
+				{Keys: []string{"2026-03-09", "カード"}, Labels: []string{"2026-03-09", "カード"}, Amount: 3300, Count: 2},
+			},
+		},
+		{
+			name:      "タグ（カテゴリで絞り込み）",
+			groupBy:   []string{"tag"},
+			filters:   map[string][]string{"category": {"food"}},
+			wantTotal: 5500,
+			wantRows: []model.PivotCell{
+				{Keys: []string{"旅行"}, Labels: []string{"旅行"}, Amount: 3000, Count: 1},
+				{Keys: []string{""}, Labels: []string{"タグなし"}, Amount: 2500, Count: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := buildPivot(expenses, tt.groupBy, tt.filters, catMaps, 1, WeekStartMonday, "a@example.com")
+		if got.Total != tt.wantTotal {
+			t.Errorf("%s: Total = %d, want %d", tt.name, got.Total, tt.wantTotal)
+		}
+		if !reflect.DeepEqual(got.Rows, tt.wantRows) {
+			t.Errorf("%s: Rows = %v, want %v", tt.name, got.Rows, tt.wantRows)
+		}
+		wantCells := tt.wantCells
+		if wantCells == nil {
+			wantCells = tt.wantRows
+		}
+		if !reflect.DeepEqual(got.Cells, wantCells) {
+			t.Errorf("%s: Cells = %v, want %v", tt.name, got.Cells, wantCells)
+		}
+	}
+}
@@ -21,7 +21,10 @@ func GetRecurringExpenses(ctx context.Context, client *dynamo.Client) ([]model.R
 
 // CreateRecurringExpense は定期支出テンプレートを作成する
 func CreateRecurringExpense(ctx context.Context, client *dynamo.Client, input *model.RecurringExpenseInput) (*model.RecurringExpense, error) {
-	if input.Category == "" || input.Amount <= 0 || input.DayOfMonth < 1 || input.DayOfMonth > 31 {
+	if err := normalizeRecurringCurrency(input); err != nil {
+		return nil, err
+	}
+	if input.Category == "" || (input.Amount <= 0 && input.OriginalAmount <= 0) || input.DayOfMonth < 1 || input.DayOfMonth > 31 {
 		return nil, apperror.New("カテゴリ、金額（正の数）、日（1-31）は必須です")
 	}
 	if input.Frequency != "monthly" && input.Frequency != "bimonthly" && input.Frequency != "yearly" {
@@ -34,24 +37,36 @@ func CreateRecurringExpense(ctx context.Context, client *dynamo.Client, input *m
 	if !ValidateVisibility(input.Visibility) {
 		return nil, apperror.New("visibility は public, summary, private のいずれかを指定してください")
 	}
+	if err := validateRecurringSplit(ctx, client, input); err != nil {
+		return nil, err
+	}
+	tags, appErr := normalizeTags(input.Tags)
+	if appErr != nil {
+		return nil, appErr
+	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	r := &model.RecurringExpense{
-		ID:          uuid.New().String(),
-		Category:    input.Category,
-		Amount:      input.Amount,
-		Payer:       input.Payer,
-		Place:       input.Place,
-		Memo:        input.Memo,
-		Visibility:  input.Visibility,
-		Frequency:   input.Frequency,
-		DayOfMonth:  input.DayOfMonth,
-		RepeatMonth: input.RepeatMonth,
-		StartMonth:  input.StartMonth,
-		EndMonth:    input.EndMonth,
-		IsActive:    input.IsActive,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:             uuid.New().String(),
+		Category:       input.Category,
+		Amount:         input.Amount,
+		Currency:       input.Currency,
+		OriginalAmount: input.OriginalAmount,
+		Payer:          input.Payer,
+		Place:          input.Place,
+		Memo:           input.Memo,
+		Visibility:     input.Visibility,
+		PaidBy:         input.PaidBy,
+		Split:          input.Split,
+		Tags:           tags,
+		Frequency:      input.Frequency,
+		DayOfMonth:     input.DayOfMonth,
+		RepeatMonth:    input.RepeatMonth,
+		StartMonth:     input.StartMonth,
+		EndMonth:       input.EndMonth,
+		IsActive:       input.IsActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
 	if err := client.PutRecurringExpense(ctx, r); err != nil {
@@ -62,7 +77,10 @@ func CreateRecurringExpense(ctx context.Context, client *dynamo.Client, input *m
 
 // UpdateRecurringExpense は定期支出テンプレートを更新する
 func UpdateRecurringExpense(ctx context.Context, client *dynamo.Client, id string, input *model.RecurringExpenseInput) (*model.RecurringExpense, error) {
-	if input.Category == "" || input.Amount <= 0 || input.DayOfMonth < 1 || input.DayOfMonth > 31 {
+	if err := normalizeRecurringCurrency(input); err != nil {
+		return nil, err
+	}
+	if input.Category == "" || (input.Amount <= 0 && input.OriginalAmount <= 0) || input.DayOfMonth < 1 || input.DayOfMonth > 31 {
 		return nil, apperror.New("カテゴリ、金額（正の数）、日（1-31）は必須です")
 	}
 	if input.Frequency != "monthly" && input.Frequency != "bimonthly" && input.Frequency != "yearly" {
@@ -87,14 +105,26 @@ func UpdateRecurringExpense(ctx context.Context, client *dynamo.Client, id strin
 	if !ValidateVisibility(input.Visibility) {
 		return nil, apperror.New("visibility は public, summary, private のいずれかを指定してください")
 	}
+	if err := validateRecurringSplit(ctx, client, input); err != nil {
+		return nil, err
+	}
+	tags, appErr := normalizeTags(input.Tags)
+	if appErr != nil {
+		return nil, appErr
+	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
 	found.Category = input.Category
 	found.Amount = input.Amount
+	found.Currency = input.Currency
+	found.OriginalAmount = input.OriginalAmount
 	found.Payer = input.Payer
 	found.Place = input.Place
 	found.Memo = input.Memo
 	found.Visibility = input.Visibility
+	found.PaidBy = input.PaidBy
+	found.Split = input.Split
+	found.Tags = tags
 	found.Frequency = input.Frequency
 	found.DayOfMonth = input.DayOfMonth
 	found.RepeatMonth = input.RepeatMonth
@@ -109,6 +139,48 @@ func UpdateRecurringExpense(ctx context.Context, client *dynamo.Client, id strin
 	return found, nil
 }
 
+// normalizeRecurringCurrency は外貨建てテンプレートの通貨を検証する。
+// 外貨建ての場合は金額を登録時のレートで換算するため amount を 0 にする。
+func normalizeRecurringCurrency(input *model.RecurringExpenseInput) *apperror.AppError {
+	input.Currency = normalizeCurrency(input.Currency)
+	if !isForeignCurrency(input.Currency) {
+		input.Currency = ""
+		input.OriginalAmount = 0
+		return nil
+	}
+	if !isCurrencyCode(input.Currency) {
+		return apperror.New("通貨は3文字の通貨コード（例: USD）で指定してください")
+	}
+	if input.OriginalAmount <= 0 {
+		return apperror.New("外貨建ての場合は元の金額（0より大きい値）は必須です")
+	}
+	input.Amount = 0
+	return nil
+}
+
+// validateRecurringSplit は負担割合を検証する（normalizeRecurringCurrency の後に呼ぶ）。
+// 自動登録ではログインユーザーがいないため、負担割合を指定する場合は立替者を必須とする。
+// 外貨建ては円換算額が自動登録時まで決まらないため、金額（fixed）での指定はできない。
+func validateRecurringSplit(ctx context.Context, client *dynamo.Client, input *model.RecurringExpenseInput) error {
+	if input.Split == nil {
+		return nil
+	}
+	if input.Currency != "" && input.Split.Method == SplitFixed {
+		return apperror.New("外貨建ての定期支出は円換算額が登録時に決まるため、負担割合を金額では指定できません。均等または比率を指定してください")
+	}
+	if input.PaidBy == "" {
+		return apperror.New("負担割合を指定する場合は立替者は必須です")
+	}
+	members, err := GetMembers(ctx, client)
+	if err != nil {
+		return err
+	}
+	if err := validateSplit(input.Split, input.PaidBy, input.Amount, toMemberSet(members)); err != nil {
+		return err
+	}
+	return nil
+}
+
 // DeleteRecurringExpense は定期支出テンプレートを削除する
 func DeleteRecurringExpense(ctx context.Context, client *dynamo.Client, id string) error {
 	return client.DeleteRecurringExpense(ctx, id)
@@ -156,13 +228,18 @@ func ProcessRecurringExpenses(ctx context.Context, client *dynamo.Client, userEm
 
 		// 支出を作成（CreateExpense 内でバックアップも実行される）
 		_, err := CreateExpense(ctx, client, &model.ExpenseInput{
-			Date:       date,
-			Payer:      t.Payer,
-			Category:   t.Category,
-			Amount:     t.Amount,
-			Memo:       t.Memo,
-			Place:      t.Place,
-			Visibility: t.Visibility,
+			Date:           date,
+			Payer:          t.Payer,
+			Category:       t.Category,
+			Amount:         t.Amount,
+			Currency:       t.Currency,
+			OriginalAmount: t.OriginalAmount,
+			Memo:           t.Memo,
+			Place:          t.Place,
+			Visibility:     t.Visibility,
+			PaidBy:         t.PaidBy,
+			Split:          t.Split,
+			Tags:           t.Tags,
 		}, userEmail)
 		if err != nil {
 			return created, fmt.Errorf("定期支出 %s の作成に失敗: %w", t.ID, err)
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestValidateRecurringSplitForeignCurrency(t *testing.T) {
+	input := &model.RecurringExpenseInput{
+		Category:       "subscription",
+		Currency:       "usd",
+		OriginalAmount: 10,
+		DayOfMonth:     1,
+		PaidBy:         "a@example.com",
+		Split: &model.Split{Method: SplitFixed, Shares: []model.SplitShare{
+			{Email: "a@example.com", Value: 800},
+			{Email: "b@example.com", Value: 700},
+		}},
+	}
+	if err := normalizeRecurringCurrency(input); err != nil {
+		t.Fatalf("normalizeRecurringCurrency() error = %v", err)
+	}
+	// 外貨建ての金額指定は合計不一致ではなく、外貨建てでは指定できない旨のエラーにする
+	err := validateRecurringSplit(context.Background(), nil, input)
+	if err == nil || !strings.Contains(err.Error(), "外貨建て") {
+		t.Errorf("validateRecurringSplit() error = %v, want foreign currency error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 返金（type=refund）は返金元の支出に紐づく記録で、Amount は返金額（正の値）。
+// 集計では返金元と同じカテゴリから差し引き、支払元の残額では支出の取り消しとして扱う。
+// 返金元の refundedAmount に返金済みの合計、refundedByCategory にカテゴリ別の内訳を持ち、
+// 返金元（明細がある場合は明細のカテゴリ）の未返金額を超える返金は登録できない。
+
+// IsRefund は返金の記録かどうかを返す
+func IsRefund(e *model.Expense) bool {
+	return EffectiveExpenseType(e.Type) == ExpenseTypeRefund
+}
+
+// CreateRefund は支出の返金（返品・一部返金）を登録する
+func CreateRefund(ctx context.Context, client *dynamo.Client, input *model.RefundInput, userEmail string) (*model.Expense, error) {
+	if input.ExpenseID == "" || input.Date == "" || input.Amount <= 0 {
+		return nil, apperror.New("返金元の支出、日付、金額（0より大きい値）は必須です")
+	}
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		return nil, apperror.New("日付は YYYY-MM-DD 形式で指定してください")
+	}
+
+	original, err := client.GetExpense(ctx, input.ExpenseID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || !canAccessExpense(original, userEmail) {
+		return nil, apperror.WithStatus(404, "返金元の支出が見つかりません")
+	}
+	category, appErr := validateRefund(original, input)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	payer := input.Payer
+	if payer == "" {
+		payer = original.Payer
+	}
+	memo := input.Memo
+	if memo == "" {
+		memo = "返金"
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	refund := model.Expense{
+		ID:            uuid.New().String(),
+		Type:          ExpenseTypeRefund,
+		Date:          input.Date,
+		Payer:         payer,
+		Category:      category,
+		Amount:        input.Amount,
+		TaxLines:      refundTaxLines(original, category, input.Amount),
+		Memo:          memo,
+		Place:         original.Place,
+		Visibility:    original.Visibility,
+		PaidBy:        original.PaidBy,
+		Split:         original.Split,
+		Tags:          original.Tags,
+		BusinessRatio: original.BusinessRatio,
+		Patient:       original.Patient,
+		Provider:      original.Provider,
+		MedicalKind:   original.MedicalKind,
+		RefundOf:      original.ID,
+		CreatedBy:     userEmail,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if refund.Split != nil && refund.PaidBy == "" {
+		// 立替者は返金元の登録者（精算で返金分を差し引くため明示する）
+		refund.PaidBy = original.CreatedBy
+	}
+	// 返金元の返金済み額が読み込み時から変わっていなければ、返金と返金済み額をまとめて保存する
+	refundedByCategory := make(map[string]int, len(original.RefundedByCategory)+1)
+	for cat, amount := range original.RefundedByCategory {
+		refundedByCategory[cat] = amount
+	}
+	refundedByCategory[category] += refund.Amount
+	saved, err := client.PutRefund(ctx, &refund, original.RefundedAmount, refundedByCategory)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, apperror.WithStatus(409, "返金元の支出が同時に更新されました。もう一度お試しください")
+	}
+
+	invalidateSummaryCache(ctx, client, refund.Date)
+	markBalanceSnapshotsDirty(ctx, client, refund.Date)
+	return &refund, nil
+}
+
+// validateRefund は返金の内容を検証し、差し引くカテゴリを返す
+func validateRefund(original *model.Expense, input *model.RefundInput) (string, *apperror.AppError) {
+	if !IsExpenseEntry(original) {
+		return "", apperror.New("返金できるのは支出のみです")
+	}
+	if input.Date < original.Date {
+		return "", apperror.New("返金日は返金元の支出日以降を指定してください")
+	}
+	if remaining := original.Amount - original.RefundedAmount; input.Amount > remaining {
+		return "", apperror.Newf("返金額は返金元の未返金額（%d）以下で指定してください", remaining)
+	}
+
+	if input.Category == "" {
+		return original.Category, nil
+	}
+	lineAmount, found := 0, false
+	for _, li := range expenseLines(original) {
+		if li.Category == input.Category {
+			lineAmount += li.Amount
+			found = true
+		}
+	}
+	if !found {
+		return "", apperror.New("カテゴリは返金元の支出（明細）のカテゴリを指定してください")
+	}
+	if len(original.Items) > 0 {
+		if remaining := lineAmount - refundedFromCategory(original, input.Category); input.Amount > remaining {
+			return "", apperror.Newf("返金額は明細の未返金額（%d）以下で指定してください", remaining)
+		}
+	}
+	return input.Category, nil
+}
+
+// refundedFromCategory は返金元のカテゴリの返金済み額を返す。
+// カテゴリ別の内訳に無い返金済み額（内訳の導入前の返金）はどのカテゴリの分か分からないため、すべてのカテゴリに含める。
+func refundedFromCategory(original *model.Expense, category string) int {
+	unattributed := original.RefundedAmount
+	for _, amount := range original.RefundedByCategory {
+		unattributed -= amount
+	}
+	if unattributed < 0 {
+		unattributed = 0
+	}
+	return original.RefundedByCategory[category] + unattributed
+}
+
+// releaseRefund は削除した返金の分だけ返金元の返金済み額を戻す（失敗はログのみ）
+func releaseRefund(ctx context.Context, client *dynamo.Client, refund *model.Expense) {
+	if err := client.ReleaseRefund(ctx, refund.RefundOf, refund.Category, refund.Amount, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		log.Printf("refund original update failed for %s: %v", refund.RefundOf, err)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestValidateRefund(t *testing.T) {
+	original := &model.Expense{ID: "e1", Date: "2025-03-01", Category: "food", Amount: 3380, RefundedAmount: 380, RefundedByCategory: map[string]int{"food": 380}, Items: []model.LineItem{
+		{Category: "food", Amount: 2400},
+		{Category: "daily", Amount: 980},
+	}}
+	tests := []struct {
+		name         string
+		original     *model.Expense
+		input        model.RefundInput
+		wantCategory string
+		wantErr      bool
+	}{
+		{name: "カテゴリ省略は返金元のカテゴリ", original: original, input: model.RefundInput{Date: "2025-03-05", Amount: 500}, wantCategory: "food"},
+		{name: "明細のカテゴリ", original: original, input: model.RefundInput{Date: "2025-03-05", Category: "daily", Amount: 980}, wantCategory: "daily"},
+		{name: "明細の金額超過", original: original, input: model.RefundInput{Date: "2025-03-05", Category: "daily", Amount: 1000}, wantErr: true},
+		{name: "明細にないカテゴリ", original: original, input: model.RefundInput{Date: "2025-03-05", Category: "hobby", Amount: 100}, wantErr: true},
+		{name: "未返金額の超過", original: original, input: model.RefundInput{Date: "2025-03-05", Amount: 3001}, wantErr: true},
+		{name: "返金元より前の日付", original: original, input: model.RefundInput{Date: "2025-02-28", Amount: 100}, wantErr: true},
+		{name: "振替は返金できない", original: &model.Expense{Type: ExpenseTypeTransfer, Date: "2025-03-01", Amount: 1000}, input: model.RefundInput{Date: "2025-03-05", Amount: 100}, wantErr: true},
+	}
+	// 同じ明細への 2 回目の返金は明細の未返金額まで
+	refunded := &model.Expense{ID: "e2", Date: "2025-03-01", Category: "food", Amount: 3000, RefundedAmount: 1000,
+		RefundedByCategory: map[string]int{"daily": 1000}, Items: []model.LineItem{
+			{Category: "food", Amount: 2000},
+			{Category: "daily", Amount: 1000},
+		}}
+	// 内訳の無い返金済み額はどの明細の分か分からないため、すべての明細から差し引く
+	legacy := &model.Expense{ID: "e3", Date: "2025-03-01", Category: "food", Amount: 3000, RefundedAmount: 500, Items: []model.LineItem{
+		{Category: "food", Amount: 2000},
+		{Category: "daily", Amount: 1000},
+	}}
+	tests = append(tests, []struct {
+		name         string
+		original     *model.Expense
+		input        model.RefundInput
+		wantCategory string
+		wantErr      bool
+	}{
+		{name: "同じ明細への 2 回目の返金", original: refunded, input: model.RefundInput{Date: "2025-03-05", Category: "daily", Amount: 1000}, wantErr: true},
+		{name: "別の明細への返金", original: refunded, input: model.RefundInput{Date: "2025-03-05", Category: "food", Amount: 2000}, wantCategory: "food"},
+		{name: "内訳の無い返金済み額", original: legacy, input: model.RefundInput{Date: "2025-03-05", Category: "daily", Amount: 501}, wantErr: true},
+		{name: "内訳の無い返金済み額の残り", original: legacy, input: model.RefundInput{Date: "2025-03-05", Category: "daily", Amount: 500}, wantCategory: "daily"},
+	}...)
+	for _, tt := range tests {
+		got, err := validateRefund(tt.original, &tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateRefund() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.wantCategory {
+			t.Errorf("%s: category = %q, want %q", tt.name, got, tt.wantCategory)
+		}
+	}
+}
+
+func TestRefundNetting(t *testing.T) {
+	catMaps := testCategoryMaps()
+	catMaps.Color = map[string]string{"food": "#f00"}
+	expenses := []model.Expense{
+		{Date: "2025-03-01", Payer: "現金", Category: "food", Amount: 5000},
+		{Date: "2025-03-10", Type: ExpenseTypeRefund, Payer: "現金", Category: "food", Amount: 1200, RefundOf: "e1"},
+		// チャージの返金はチャージの取り消し
+		{Date: "2025-03-11", Type: ExpenseTypeRefund, Payer: "銀行", Category: "charge", Amount: 1000, RefundOf: "e2"},
+	}
+
+	want := []model.CategorySummary{{CategoryID: "food", Category: "食費", Amount: 3800, Color: "#f00"}}
+	if got := monthDataFromCache(buildSummaryCache(expenses, "2025-03", 1), "現金", catMaps, "").ByCategory; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByCategory = %v, want %v", got, want)
+	}
+
+	chargeCategories := chargeCategoryIDs(catMaps)
+	spent, charge := 0, 0
+	for i := range expenses {
+		f := classifyPayerFlow(&expenses[i], "現金", chargeCategories, catMaps)
+		spent += f.spent
+		charge += f.charge
+	}
+	if spent != 3800 || charge != -1000 {
+		t.Errorf("spent, charge = %d, %d, want 3800, -1000", spent, charge)
+	}
+}
+
+func TestRefundLinesChanged(t *testing.T) {
+	existing := &model.Expense{Category: "food", Amount: 5000, RefundedAmount: 1000, Items: []model.LineItem{
+		{Category: "food", Amount: 3000},
+		{Category: "daily", Amount: 2000, Memo: "洗剤"},
+	}}
+	items := func(foodAmount int, dailyCategory string) []model.LineItem {
+		return []model.LineItem{{Category: "food", Amount: foodAmount}, {Category: dailyCategory, Amount: 2000}}
+	}
+	tests := []struct {
+		name  string
+		input model.ExpenseInput
+		want  bool
+	}{
+		{name: "メモ・日付の変更", input: model.ExpenseInput{Category: "food", Date: "2025-03-02", Memo: "変更", Items: items(3000, "daily")}},
+		{name: "カテゴリの変更", input: model.ExpenseInput{Category: "daily", Items: items(3000, "daily")}, want: true},
+		{name: "明細のカテゴリの変更", input: model.ExpenseInput{Category: "food", Items: items(3000, "hobby")}, want: true},
+		{name: "明細の金額の変更", input: model.ExpenseInput{Category: "food", Items: items(3500, "daily")}, want: true},
+		{name: "明細の削除", input: model.ExpenseInput{Category: "food"}, want: true},
+	}
+	for _, tt := range tests {
+		if got := refundLinesChanged(existing, &tt.input); got != tt.want {
+			t.Errorf("%s: refundLinesChanged() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
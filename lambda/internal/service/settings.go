@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// GetHouseholdSettings は世帯設定を返す（未設定の項目は既定値）
+func GetHouseholdSettings(ctx context.Context, client *dynamo.Client) (*model.HouseholdSettings, error) {
+	settings, err := client.GetHouseholdSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	settings.MonthStartDay = effectiveMonthStartDay(settings.MonthStartDay)
+	settings.WeekStart = effectiveWeekStart(settings.WeekStart)
+	settings.FiscalYearStartMonth = effectiveFiscalYearStartMonth(settings.FiscalYearStartMonth)
+	return settings, nil
+}
+
+// UpdateHouseholdSettings は世帯設定を保存する。
+// 月の開始日を変更した場合は、月の区切りが変わるため集計キャッシュを破棄して残高スナップショットを作り直す。
+func UpdateHouseholdSettings(ctx context.Context, client *dynamo.Client, input *model.HouseholdSettings) (*model.HouseholdSettings, error) {
+	if input.MonthStartDay < 1 || input.MonthStartDay > maxMonthStartDay {
+		return nil, apperror.Newf("月の開始日は 1〜%d で指定してください", maxMonthStartDay)
+	}
+	if input.WeekStart != "" && input.WeekStart != WeekStartMonday && input.WeekStart != WeekStartSunday {
+		return nil, apperror.New("週の開始曜日は monday または sunday で指定してください")
+	}
+	if input.FiscalYearStartMonth < 0 || input.FiscalYearStartMonth > 12 {
+		return nil, apperror.New("年度の開始月は 1〜12 で指定してください")
+	}
+	current, err := GetHouseholdSettings(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &model.HouseholdSettings{
+		MonthStartDay:        input.MonthStartDay,
+		WeekStart:            effectiveWeekStart(input.WeekStart),
+		FiscalYearStartMonth: effectiveFiscalYearStartMonth(input.FiscalYearStartMonth),
+		UpdatedAt:            time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := client.PutHouseholdSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	if settings.MonthStartDay != current.MonthStartDay {
+		invalidateAllSummaryCaches(ctx, client)
+		rebuildBalanceSnapshotsAfter(ctx, client, "monthStartDay change")
+	}
+	return settings, nil
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+const (
+	SplitEqual = "equal"
+	SplitRatio = "ratio"
+	SplitFixed = "fixed"
+)
+
+// 立替・精算は split が指定された支出のみを対象とする。
+// 立て替えたメンバー（paidBy、空=createdBy）が全額を支払い、各メンバーが split に応じた額を負担する。
+// 精算（type=settlement）は paidBy から paidTo への支払いとして差し引く。
+// split 付きの支出はメンバー間で共有する前提のため、visibility に関わらず精算額に含める。
+
+// GetMembers は世帯メンバー（登録ユーザー）のメールアドレス一覧を返す
+func GetMembers(ctx context.Context, client *dynamo.Client) ([]string, error) {
+	users, err := client.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, len(users))
+	for i, u := range users {
+		members[i] = u.Email
+	}
+	return members, nil
+}
+
+// GetSettlement は期間（from〜to、両端を含む）の立替・負担を集計し、未精算額を解消する支払いを返す
+func GetSettlement(ctx context.Context, client *dynamo.Client, from string, to string) (*model.Settlement, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	members, err := GetMembers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, months, 1)
+	if err != nil {
+		return nil, err
+	}
+	expenses := flattenMonths(months, byMonth)
+
+	result := computeSettlement(expenses, members)
+	result.From = from
+	result.To = to
+	return result, nil
+}
+
+// RecordSettlement はメンバー間の精算（支払い）を記録する
+func RecordSettlement(ctx context.Context, client *dynamo.Client, input *model.SettlementInput, userEmail string) (*model.Expense, error) {
+	if input.Date == "" || input.From == "" || input.To == "" || input.Amount <= 0 {
+		return nil, apperror.New("日付、支払者、受取者、金額（0より大きい値）は必須です")
+	}
+	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
+		return nil, apperror.New("日付は YYYY-MM-DD 形式で指定してください")
+	}
+	if input.From == input.To {
+		return nil, apperror.New("支払者と受取者に同じメンバーは指定できません")
+	}
+	members, err := GetMembers(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	memberSet := toMemberSet(members)
+	if !memberSet[input.From] || !memberSet[input.To] {
+		return nil, apperror.New("支払者・受取者は登録ユーザーを指定してください")
+	}
+
+	memo := input.Memo
+	if memo == "" {
+		memo = "精算"
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	settlement := model.Expense{
+		ID:        uuid.New().String(),
+		Type:      ExpenseTypeSettlement,
+		Date:      input.Date,
+		Amount:    input.Amount,
+		Memo:      memo,
+		PaidBy:    input.From,
+		PaidTo:    input.To,
+		CreatedBy: userEmail,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := client.PutExpense(ctx, &settlement); err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// loadMembersFor は split 指定の入力がある場合のみメンバー一覧を取得する
+func loadMembersFor(ctx context.Context, client *dynamo.Client, inputs ...*model.ExpenseInput) (map[string]bool, error) {
+	for _, input := range inputs {
+		if input.Split != nil {
+			members, err := GetMembers(ctx, client)
+			if err != nil {
+				return nil, err
+			}
+			return toMemberSet(members), nil
+		}
+	}
+	return nil, nil
+}
+
+func toMemberSet(members []string) map[string]bool {
+	set := make(map[string]bool, len(members))
+	for _, m := range members {
+		set[m] = true
+	}
+	return set
+}
+
+// validateSplit は負担割合を検証する。fixed は合計が金額と一致する必要がある。
+func validateSplit(split *model.Split, paidBy string, amount int, members map[string]bool) *apperror.AppError {
+	if split == nil {
+		return nil
+	}
+	if paidBy != "" && !members[paidBy] {
+		return apperror.New("立替者は登録ユーザーを指定してください")
+	}
+	seen := make(map[string]bool, len(split.Shares))
+	for _, sh := range split.Shares {
+		if !members[sh.Email] {
+			return apperror.Newf("負担者 %s は登録ユーザーではありません", sh.Email)
+		}
+		if seen[sh.Email] {
+			return apperror.Newf("負担者 %s が重複しています", sh.Email)
+		}
+		seen[sh.Email] = true
+	}
+
+	switch split.Method {
+	case SplitEqual:
+		return nil
+	case SplitRatio:
+		if len(split.Shares) == 0 {
+			return apperror.New("比率で分ける場合は負担者を指定してください")
+		}
+		for _, sh := range split.Shares {
+			if sh.Value <= 0 {
+				return apperror.New("比率は0より大きい値で指定してください")
+			}
+		}
+		return nil
+	case SplitFixed:
+		if len(split.Shares) == 0 {
+			return apperror.New("金額で分ける場合は負担者を指定してください")
+		}
+		total := 0
+		for _, sh := range split.Shares {
+			total += sh.Value
+		}
+		if total != amount {
+			return apperror.Newf("負担額の合計（%d）が金額（%d）と一致しません", total, amount)
+		}
+		return nil
+	default:
+		return apperror.New("分け方は equal, ratio, fixed のいずれかを指定してください")
+	}
+}
+
+// computeSplitShares はメンバーごとの負担額を返す。
+// 割り切れない端数は 1 円ずつ負担者の先頭から順に割り当てる。
+func computeSplitShares(amount int, split *model.Split, members []string) map[string]int {
+	var emails []string
+	var weights []int
+	switch split.Method {
+	case SplitFixed:
+		shares := make(map[string]int, len(split.Shares))
+		fixedTotal := 0
+		for _, sh := range split.Shares {
+			shares[sh.Email] += sh.Value
+			fixedTotal += sh.Value
+		}
+		if fixedTotal == amount {
+			return shares
+		}
+		// 一部返金等で金額が異なる場合は指定金額の比率で按分する
+		for _, sh := range split.Shares {
+			emails = append(emails, sh.Email)
+			weights = append(weights, sh.Value)
+		}
+	case SplitRatio:
+		for _, sh := range split.Shares {
+			emails = append(emails, sh.Email)
+			weights = append(weights, sh.Value)
+		}
+	default:
+		for _, sh := range split.Shares {
+			emails = append(emails, sh.Email)
+		}
+		if len(emails) == 0 {
+			emails = members
+		}
+		for range emails {
+			weights = append(weights, 1)
+		}
+	}
+
+	totalWeight := 0
+	for _, w := range weights {
+		totalWeight += w
+	}
+	shares := make(map[string]int, len(emails))
+	if totalWeight == 0 {
+		return shares
+	}
+	allocated := 0
+	for i, email := range emails {
+		share := amount * weights[i] / totalWeight
+		shares[email] += share
+		allocated += share
+	}
+	remainder := amount - allocated
+	step := 1
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(emails) {
+		shares[emails[i]] += step
+		remainder -= step
+	}
+	return shares
+}
+
+// computeSettlement は記録からメンバーごとの立替・負担・精算額と、未精算額を解消する支払いを計算する
+func computeSettlement(expenses []model.Expense, members []string) *model.Settlement {
+	byEmail := make(map[string]*model.SettlementMember)
+	member := func(email string) *model.SettlementMember {
+		m, ok := byEmail[email]
+		if !ok {
+			m = &model.SettlementMember{Email: email}
+			byEmail[email] = m
+		}
+		return m
+	}
+	for _, email := range members {
+		member(email)
+	}
+
+	result := &model.Settlement{Payments: []model.Expense{}}
+	for i := range expenses {
+		e := &expenses[i]
+		switch {
+		case EffectiveExpenseType(e.Type) == ExpenseTypeSettlement:
+			member(e.PaidBy).SettledOut += e.Amount
+			member(e.PaidTo).SettledIn += e.Amount
+			result.Payments = append(result.Payments, *e)
+		case IsExpenseEntry(e) && e.Split != nil:
+			paidBy := e.PaidBy
+			if paidBy == "" {
+				paidBy = e.CreatedBy
+			}
+			member(paidBy).Paid += e.Amount
+			for email, share := range computeSplitShares(e.Amount, e.Split, members) {
+				member(email).Share += share
+			}
+		case IsRefund(e) && e.Split != nil:
+			// 返金は立替額・負担額を同じ割合で減らす
+			member(e.PaidBy).Paid -= e.Amount
+			for email, share := range computeSplitShares(e.Amount, e.Split, members) {
+				member(email).Share -= share
+			}
+		}
+	}
+	sort.Slice(result.Payments, func(i, j int) bool {
+		return result.Payments[i].Date < result.Payments[j].Date
+	})
+
+	result.Members = make([]model.SettlementMember, 0, len(byEmail))
+	for _, m := range byEmail {
+		m.Net = m.Paid - m.Share + m.SettledOut - m.SettledIn
+		result.Members = append(result.Members, *m)
+	}
+	sort.Slice(result.Members, func(i, j int) bool {
+		return result.Members[i].Email < result.Members[j].Email
+	})
+	result.Transfers = settlementTransfers(result.Members)
+	return result
+}
+
+// settlementTransfers は受け取る側（net > 0）と支払う側（net < 0）を金額の大きい順に突き合わせる
+func settlementTransfers(members []model.SettlementMember) []model.SettlementTransfer {
+	type balance struct {
+		email  string
+		amount int
+	}
+	var creditors, debtors []balance
+	for _, m := range members {
+		if m.Net > 0 {
+			creditors = append(creditors, balance{m.Email, m.Net})
+		} else if m.Net < 0 {
+			debtors = append(debtors, balance{m.Email, -m.Net})
+		}
+	}
+	byAmount := func(list []balance) {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].amount > list[j].amount })
+	}
+	byAmount(creditors)
+	byAmount(debtors)
+
+	transfers := []model.SettlementTransfer{}
+	for ci, di := 0, 0; ci < len(creditors) && di < len(debtors); {
+		amount := min(creditors[ci].amount, debtors[di].amount)
+		transfers = append(transfers, model.SettlementTransfer{From: debtors[di].email, To: creditors[ci].email, Amount: amount})
+		creditors[ci].amount -= amount
+		debtors[di].amount -= amount
+		if creditors[ci].amount == 0 {
+			ci++
+		}
+		if debtors[di].amount == 0 {
+			di++
+		}
+	}
+	return transfers
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestComputeSplitShares(t *testing.T) {
+	members := []string{"a@example.com", "b@example.com", "c@example.com"}
+	tests := []struct {
+		name   string
+		amount int
+		split  model.Split
+		want   map[string]int
+	}{
+		{
+			name:   "均等（全員・端数は先頭から）",
+			amount: 1000,
+			split:  model.Split{Method: SplitEqual},
+			want:   map[string]int{"a@example.com": 334, "b@example.com": 333, "c@example.com": 333},
+		},
+		{
+			name:   "均等（指定メンバー）",
+			amount: 1001,
+			split:  model.Split{Method: SplitEqual, Shares: []model.SplitShare{{Email: "b@example.com"}, {Email: "c@example.com"}}},
+			want:   map[string]int{"b@example.com": 501, "c@example.com": 500},
+		},
+		{
+			name:   "比率 60/40",
+			amount: 12345,
+			split:  model.Split{Method: SplitRatio, Shares: []model.SplitShare{{Email: "a@example.com", Value: 60}, {Email: "b@example.com", Value: 40}}},
+			want:   map[string]int{"a@example.com": 7407, "b@example.com": 4938},
+		},
+		{
+			name:   "金額指定",
+			amount: 5000,
+			split:  model.Split{Method: SplitFixed, Shares: []model.SplitShare{{Email: "a@example.com", Value: 3500}, {Email: "b@example.com", Value: 1500}}},
+			want:   map[string]int{"a@example.com": 3500, "b@example.com": 1500},
+		},
+		{
+			name:   "金額指定の一部返金は比率で按分",
+			amount: 1000,
+			split:  model.Split{Method: SplitFixed, Shares: []model.SplitShare{{Email: "a@example.com", Value: 3500}, {Email: "b@example.com", Value: 1500}}},
+			want:   map[string]int{"a@example.com": 700, "b@example.com": 300},
+		},
+	}
+	for _, tt := range tests {
+		got := computeSplitShares(tt.amount, &tt.split, members)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: computeSplitShares() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeSettlement(t *testing.T) {
+	members := []string{"a@example.com", "b@example.com"}
+	ratio := &model.Split{Method: SplitRatio, Shares: []model.SplitShare{{Email: "a@example.com", Value: 60}, {Email: "b@example.com", Value: 40}}}
+	expenses := []model.Expense{
+		// 家賃を a が立替（b の負担 40,000）
+		{Date: "2025-01-25", Amount: 100000, Split: ratio, CreatedBy: "a@example.com"},
+		// 食費を b が立替（a の負担 6,000）
+		{Date: "2025-01-10", Amount: 10000, Split: ratio, PaidBy: "b@example.com", CreatedBy: "a@example.com"},
+		// split なしは対象外
+		{Date: "2025-01-11", Amount: 3000, CreatedBy: "b@example.com"},
+		// 精算済み 10,000（b → a）
+		{Date: "2025-01-31", Type: ExpenseTypeSettlement, Amount: 10000, PaidBy: "b@example.com", PaidTo: "a@example.com"},
+	}
+
+	got := computeSettlement(expenses, members)
+	wantMembers := []model.SettlementMember{
+		{Email: "a@example.com", Paid: 100000, Share: 66000, SettledIn: 10000, Net: 24000},
+		{Email: "b@example.com", Paid: 10000, Share: 44000, SettledOut: 10000, Net: -24000},
+	}
+	if !reflect.DeepEqual(got.Members, wantMembers) {
+		t.Errorf("Members = %+v, want %+v", got.Members, wantMembers)
+	}
+	wantTransfers := []model.SettlementTransfer{{From: "b@example.com", To: "a@example.com", Amount: 24000}}
+	if !reflect.DeepEqual(got.Transfers, wantTransfers) {
+		t.Errorf("Transfers = %+v, want %+v", got.Transfers, wantTransfers)
+	}
+	if len(got.Payments) != 1 {
+		t.Errorf("Payments = %d件, want 1件", len(got.Payments))
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 変更履歴の操作
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// 集計キャッシュ・残高スナップショットに影響する項目（これ以外だけの変更は変更履歴のみ記録する）
+var aggregateFields = map[string]bool{
+	"type":       true,
+	"date":       true,
+	"payer":      true,
+	"toPayer":    true,
+	"category":   true,
+	"amount":     true,
+	"items":      true,
+	"visibility": true,
+}
+
+// ProcessExpenseChanges は expenses テーブルの変更（DynamoDB Streams）を変更履歴・集計キャッシュ・残高スナップショットに反映する。
+// API 以外（移行・CSV インポート・DynamoDB の直接操作）の書き込みも含め、すべての変更がここを通る。
+// バッチ内の月はまとめて 1 回ずつ処理する。失敗時はエラーを返して Lambda の再試行に任せる（どの処理も再実行してよい）。
+func ProcessExpenseChanges(ctx context.Context, client *dynamo.Client, changes []model.ExpenseChange) error {
+	entries := make([]model.AuditEntry, 0, len(changes))
+	var dates []string
+	for i := range changes {
+		if entry := auditEntryFor(&changes[i]); entry != nil {
+			entries = append(entries, *entry)
+		}
+		dates = append(dates, aggregateDates(&changes[i])...)
+	}
+	if err := client.BatchPutAuditEntries(ctx, entries); err != nil {
+		return err
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return err
+	}
+	for _, k := range summaryCacheKeys(dates, startDay) {
+		if err := client.InvalidateSummaryCache(ctx, k.month, k.startDay); err != nil {
+			return err
+		}
+	}
+	// API 以外の書き込みも含めて再計算待ちにし、API が残した分と合わせて再計算する
+	if err := markBalanceMonthsDirty(ctx, client, dates, startDay); err != nil {
+		return err
+	}
+	return refreshDirtyBalanceSnapshots(ctx, client, startDay)
+}
+
+// GetAuditLog は変更時刻（UTC）が指定月の変更履歴を新しい順に返す（他人の private の記録は除外し、「金額のみ公開」はカテゴリを伏せる）
+func GetAuditLog(ctx context.Context, client *dynamo.Client, month string, userEmail string) ([]model.AuditEntry, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, apperror.New("month は YYYY-MM 形式で指定してください")
+	}
+	entries, err := client.QueryAuditEntries(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	return filterAuditEntries(entries, userEmail), nil
+}
+
+// filterAuditEntries は閲覧者に見せる変更履歴を返す（他人の private は除外、他人の「金額のみ公開」はカテゴリを空にする）
+func filterAuditEntries(entries []model.AuditEntry, userEmail string) []model.AuditEntry {
+	result := make([]model.AuditEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.CreatedBy != userEmail {
+			switch EffectiveVisibility(e.Visibility) {
+			case VisibilityPrivate:
+				continue
+			case VisibilitySummary:
+				e.Category = ""
+			}
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// auditEntryFor は変更の履歴を作る（項目が変わっていない更新は nil）
+func auditEntryFor(c *model.ExpenseChange) *model.AuditEntry {
+	e, action := c.New, AuditUpdate
+	switch {
+	case c.Old == nil:
+		action = AuditCreate
+	case c.New == nil:
+		e, action = c.Old, AuditDelete
+	}
+	if e == nil {
+		return nil
+	}
+	entry := &model.AuditEntry{
+		ID:         dynamo.AuditID(c.Time, c.EventID),
+		Time:       c.Time,
+		Action:     action,
+		ExpenseID:  e.ID,
+		Date:       e.Date,
+		Payer:      e.Payer,
+		Category:   e.Category,
+		Amount:     e.Amount,
+		Visibility: e.Visibility,
+		CreatedBy:  e.CreatedBy,
+	}
+	if action == AuditUpdate {
+		entry.Changes = changedFields(c.Old, c.New)
+		if len(entry.Changes) == 0 {
+			return nil
+		}
+		if c.Old.Date != e.Date {
+			entry.OldDate = c.Old.Date
+		}
+		if c.Old.Amount != e.Amount {
+			oldAmount := c.Old.Amount
+			entry.OldAmount = &oldAmount
+		}
+	}
+	return entry
+}
+
+// aggregateDates は集計キャッシュ・残高スナップショットの更新が必要な日付を返す（変更前後の日付）
+func aggregateDates(c *model.ExpenseChange) []string {
+	if c.Old != nil && c.New != nil {
+		affected := false
+		for _, field := range changedFields(c.Old, c.New) {
+			affected = affected || aggregateFields[field]
+		}
+		if !affected {
+			return nil
+		}
+	}
+	var dates []string
+	for _, e := range []*model.Expense{c.Old, c.New} {
+		if e != nil {
+			dates = append(dates, e.Date)
+		}
+	}
+	return dates
+}
+
+// changedFields は変更前後で値が変わった項目名を返す（更新日時・作成者などの管理項目は除く）
+func changedFields(before, after *model.Expense) []string {
+	fields := []struct {
+		name          string
+		before, after any
+	}{
+		{"type", EffectiveExpenseType(before.Type), EffectiveExpenseType(after.Type)},
+		{"date", before.Date, after.Date},
+		{"payer", before.Payer, after.Payer},
+		{"toPayer", before.ToPayer, after.ToPayer},
+		{"category", before.Category, after.Category},
+		{"amount", before.Amount, after.Amount},
+		{"items", before.Items, after.Items},
+		{"taxLines", before.TaxLines, after.TaxLines},
+		{"currency", before.Currency, after.Currency},
+		{"originalAmount", before.OriginalAmount, after.OriginalAmount},
+		{"memo", before.Memo, after.Memo},
+		{"place", before.Place, after.Place},
+		{"visibility", EffectiveVisibility(before.Visibility), EffectiveVisibility(after.Visibility)},
+		{"paidBy", before.PaidBy, after.PaidBy},
+		{"paidTo", before.PaidTo, after.PaidTo},
+		{"split", before.Split, after.Split},
+		{"tags", before.Tags, after.Tags},
+		{"attachments", before.Attachments, after.Attachments},
+		{"refundedAmount", before.RefundedAmount, after.RefundedAmount},
+		{"businessRatio", before.BusinessRatio, after.BusinessRatio},
+		{"patient", before.Patient, after.Patient},
+		{"provider", before.Provider, after.Provider},
+		{"medicalKind", before.MedicalKind, after.MedicalKind},
+		{"reimbursedAmount", before.ReimbursedAmount, after.ReimbursedAmount},
+	}
+	var changed []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.before, f.after) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
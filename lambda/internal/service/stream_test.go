@@ -0,0 +1,101 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestAuditEntryFor(t *testing.T) {
+	before := &model.Expense{ID: "e1", Date: "2025-03-31", Payer: "財布", Category: "food", Amount: 1000, Memo: "昼", CreatedBy: "a@example.com"}
+
+	created := auditEntryFor(&model.ExpenseChange{EventID: "ev1", Time: "2025-04-01T00:00:00Z", New: before})
+	if created == nil || created.Action != AuditCreate || created.ID != "2025-04-01T00:00:00Z#ev1" || created.Amount != 1000 || created.Changes != nil {
+		t.Errorf("create entry = %+v", created)
+	}
+	deleted := auditEntryFor(&model.ExpenseChange{EventID: "ev2", Time: "2025-04-02T00:00:00Z", Old: before})
+	if deleted == nil || deleted.Action != AuditDelete || deleted.ExpenseID != "e1" || deleted.Date != "2025-03-31" {
+		t.Errorf("delete entry = %+v", deleted)
+	}
+
+	after := *before
+	after.Date = "2025-04-01"
+	after.Amount = 1200
+	after.Memo = "夕"
+	after.UpdatedAt = "2025-04-03T00:00:00Z"
+	updated := auditEntryFor(&model.ExpenseChange{EventID: "ev3", Time: "2025-04-03T00:00:00Z", Old: before, New: &after})
+	if updated == nil || updated.Action != AuditUpdate || updated.Date != "2025-04-01" || updated.OldDate != "2025-03-31" ||
+		updated.OldAmount == nil || *updated.OldAmount != 1000 || !reflect.DeepEqual(updated.Changes, []string{"date", "amount", "memo"}) {
+		t.Errorf("update entry = %+v", updated)
+	}
+
+	// 更新日時だけの変更は記録しない
+	touched := *before
+	touched.UpdatedAt = "2025-04-04T00:00:00Z"
+	if got := auditEntryFor(&model.ExpenseChange{EventID: "ev4", Old: before, New: &touched}); got != nil {
+		t.Errorf("touch entry = %+v, want nil", got)
+	}
+}
+
+func TestFilterAuditEntries(t *testing.T) {
+	entries := []model.AuditEntry{
+		{ID: "1", Category: "food", CreatedBy: "a@example.com", Visibility: VisibilitySummary},
+		{ID: "2", Category: "food", CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		{ID: "3", Category: "food", CreatedBy: "b@example.com", Visibility: VisibilityPrivate},
+		{ID: "4", Category: "food", CreatedBy: "b@example.com"},
+	}
+	want := []model.AuditEntry{
+		{ID: "1", Category: "food", CreatedBy: "a@example.com", Visibility: VisibilitySummary},
+		// 他人の「金額のみ公開」はカテゴリを伏せる
+		{ID: "2", CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		{ID: "4", Category: "food", CreatedBy: "b@example.com"},
+	}
+	if got := filterAuditEntries(entries, "a@example.com"); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterAuditEntries() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAggregateDates(t *testing.T) {
+	before := &model.Expense{ID: "e1", Date: "2025-03-31", Payer: "財布", Category: "food", Amount: 1000}
+
+	if got := aggregateDates(&model.ExpenseChange{New: before}); !reflect.DeepEqual(got, []string{"2025-03-31"}) {
+		t.Errorf("create dates = %v", got)
+	}
+	moved := *before
+	moved.Date = "2025-04-01"
+	if got := aggregateDates(&model.ExpenseChange{Old: before, New: &moved}); !reflect.DeepEqual(got, []string{"2025-03-31", "2025-04-01"}) {
+		t.Errorf("move dates = %v", got)
+	}
+
+	// メモ・添付ファイル・返金済み額だけの変更は集計に影響しない
+	annotated := *before
+	annotated.Memo = "メモ"
+	annotated.Attachments = []model.Attachment{{ID: "a1"}}
+	annotated.RefundedAmount = 300
+	if got := aggregateDates(&model.ExpenseChange{Old: before, New: &annotated}); got != nil {
+		t.Errorf("annotate dates = %v, want nil", got)
+	}
+	// visibility の空と public は同じ
+	public := *before
+	public.Visibility = VisibilityPublic
+	if got := aggregateDates(&model.ExpenseChange{Old: before, New: &public}); got != nil {
+		t.Errorf("public dates = %v, want nil", got)
+	}
+	private := *before
+	private.Visibility = VisibilityPrivate
+	if got := aggregateDates(&model.ExpenseChange{Old: before, New: &private}); len(got) != 2 {
+		t.Errorf("private dates = %v", got)
+	}
+}
+
+func TestSummaryCacheKeys(t *testing.T) {
+	got := summaryCacheKeys([]string{"2025-03-24", "2025-03-25", "2025-03-10", ""}, 25)
+	want := []summaryCacheKey{{"2025-03", 1}, {"2025-02", 25}, {"2025-03", 25}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summaryCacheKeys() = %+v, want %+v", got, want)
+	}
+	if got := summaryCacheKeys([]string{"2025-03-24"}, 1); len(got) != 1 {
+		t.Errorf("calendar month keys = %+v", got)
+	}
+}
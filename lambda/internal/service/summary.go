@@ -8,41 +8,27 @@ import (
 	"strings"
 	"time"
 
+	"money-diary/internal/apperror"
 	"money-diary/internal/dynamo"
 	"money-diary/internal/model"
 )
 
-// RefreshMonthlySummaryCache は指定月の集計キャッシュを再計算して保存する（共有カテゴリのみ）
-func RefreshMonthlySummaryCache(ctx context.Context, client *dynamo.Client, yearMonth string) error {
-	expenses, err := client.QueryExpensesByMonth(ctx, yearMonth)
-	if err != nil {
-		return err
-	}
-	catMaps, err := GetCategoryMaps(ctx, client, "")
-	if err != nil {
-		return err
-	}
-	allCategories := filterExpenseCategories(aggregateByCategory(expenses, yearMonth, "", catMaps), catMaps.IsExpense)
-	summaryCategories := filterSummaryCategories(allCategories, catMaps.ExcludeFromSummary)
-	total := sumCategories(summaryCategories)
-	byCategory := filterBreakdownCategories(summaryCategories, catMaps.ExcludeFromBreakdown)
-	return client.PutMonthlySummaryCache(ctx, &model.MonthData{
-		Month: yearMonth, Total: total, ByCategory: byCategory,
-	})
-}
-
 // GetMonthlySummary は指定月の集計データを返す（payer指定時はフィルタ）。
-// userEmail に応じて visibility フィルタを適用する。
-func GetMonthlySummary(ctx context.Context, client *dynamo.Client, month string, payer string, userEmail string) (*model.MonthlySummary, error) {
+// userEmail に応じて visibility フィルタを適用する。月の区切りは世帯設定の開始日に従う（calendar=true はカレンダー月）。
+func GetMonthlySummary(ctx context.Context, client *dynamo.Client, month string, payer string, userEmail string, calendar bool) (*model.MonthlySummary, error) {
 	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
 	if err != nil {
 		return nil, err
 	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
 
 	prevMonth := previousMonth(month)
 	prevYearMonth := previousYearMonth(month)
 
-	dataMap, err := getMonthDataMap(ctx, client, []string{month, prevMonth, prevYearMonth}, payer, catMaps, userEmail)
+	dataMap, err := getMonthDataMap(ctx, client, []string{month, prevMonth, prevYearMonth}, payer, catMaps, userEmail, startDay)
 	if err != nil {
 		return nil, err
 	}
@@ -67,8 +53,8 @@ func GetMonthlySummary(ctx context.Context, client *dynamo.Client, month string,
 }
 
 // GetYearlySummary は指定月を最新とした直近13ヶ月分の集計データを返す（payer指定時はフィルタ）。
-// userEmail に応じて visibility フィルタを適用する。
-func GetYearlySummary(ctx context.Context, client *dynamo.Client, month string, payer string, userEmail string) (*model.YearlySummary, error) {
+// userEmail に応じて visibility フィルタを適用する。月の区切りは GetMonthlySummary と同じ。
+func GetYearlySummary(ctx context.Context, client *dynamo.Client, month string, payer string, userEmail string, calendar bool) (*model.YearlySummary, error) {
 	parts := strings.Split(month, "-")
 	if len(parts) != 2 {
 		return nil, fmt.Errorf("month の形式が不正です: %s", month)
@@ -87,8 +73,12 @@ func GetYearlySummary(ctx context.Context, client *dynamo.Client, month string,
 	if err != nil {
 		return nil, err
 	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
 
-	dataMap, err := getMonthDataMap(ctx, client, months, payer, catMaps, userEmail)
+	dataMap, err := getMonthDataMap(ctx, client, months, payer, catMaps, userEmail, startDay)
 	if err != nil {
 		return nil, err
 	}
@@ -105,61 +95,59 @@ func GetYearlySummary(ctx context.Context, client *dynamo.Client, month string,
 }
 
 // getMonthDataMap は複数月の集計データを取得する。
-// visibility フィルタがユーザー依存のため、常に月別クエリで計算する。
-func getMonthDataMap(ctx context.Context, client *dynamo.Client, months []string, payer string, catMaps *CategoryMaps, userEmail string) (map[string]*model.MonthData, error) {
-	result := make(map[string]*model.MonthData)
-
-	for _, ym := range months {
-		data, err := computeMonthData(ctx, client, ym, payer, catMaps, userEmail)
-		if err != nil {
-			return nil, err
-		}
-		result[ym] = data
+// 月別集計キャッシュ（SummaryCache）から閲覧者の visibility・カテゴリ設定で集計し、
+// キャッシュのない月だけ月別クエリ（queryMonthsExpenses で並列に取得）から計算してキャッシュに保存する。
+func getMonthDataMap(ctx context.Context, client *dynamo.Client, months []string, payer string, catMaps *CategoryMaps, userEmail string, startDay int) (map[string]*model.MonthData, error) {
+	caches, err := loadSummaryCaches(ctx, client, months, startDay)
+	if err != nil {
+		return nil, err
 	}
-
-	// データがない月は空の MonthData で埋める
+	result := make(map[string]*model.MonthData, len(months))
 	for _, ym := range months {
-		if _, ok := result[ym]; !ok {
-			result[ym] = &model.MonthData{Month: ym}
-		}
+		result[ym] = monthDataFromCache(caches[ym], payer, catMaps, userEmail)
 	}
-
 	return result, nil
 }
 
-// computeMonthData は月別 GSI クエリから集計を計算する。
-// 他人の private 支出は除外する。
-// total は excludeFromBreakdown を含む全支出カテゴリの合計、byCategory は除外後の内訳。
-func computeMonthData(ctx context.Context, client *dynamo.Client, yearMonth string, payer string, catMaps *CategoryMaps, userEmail string) (*model.MonthData, error) {
-	expenses, err := client.QueryExpensesByMonth(ctx, yearMonth)
-	if err != nil {
-		return nil, err
-	}
-	filtered := FilterExpensesForSummary(expenses, userEmail)
-	allCategories := filterExpenseCategories(aggregateByCategory(filtered, yearMonth, payer, catMaps), catMaps.IsExpense)
-	summaryCategories := filterSummaryCategories(allCategories, catMaps.ExcludeFromSummary)
-	total := sumCategories(summaryCategories)
-	byCategory := filterBreakdownCategories(summaryCategories, catMaps.ExcludeFromBreakdown)
-	return &model.MonthData{Month: yearMonth, Total: total, ByCategory: byCategory}, nil
-}
-
-// aggregateByCategory は指定月(+支払元)の支出をカテゴリID別に集計する（カテゴリマスタの sortOrder 順）
-func aggregateByCategory(expenses []model.Expense, month string, payer string, catMaps *CategoryMaps) []model.CategorySummary {
+// categorySummaries は支出(+支払元)をカテゴリID別に集計する（カテゴリマスタの sortOrder 順）
+func categorySummaries(expenses []model.Expense, payer string, catMaps *CategoryMaps) []model.CategorySummary {
 	totals := make(map[string]int)
 	for _, e := range expenses {
-		if len(e.Date) >= 7 && e.Date[:7] == month {
-			if payer != "" && e.Payer != payer {
-				continue
-			}
-			totals[e.Category] += e.Amount
+		if payer != "" && e.Payer != payer {
+			continue
 		}
+		addCategoryAmounts(totals, &e)
+	}
+	return categorySummariesFromTotals(totals, catMaps)
+}
+
+// addCategoryAmounts は支出をカテゴリID別の金額に加える（返金はマイナス、振替・残高調整は対象外）
+func addCategoryAmounts(totals map[string]int, e *model.Expense) {
+	if !IsExpenseEntry(e) && !IsRefund(e) {
+		// 振替・残高調整は支出集計の対象外
+		return
 	}
+	if IsRefund(e) {
+		// 返金は返金元と同じカテゴリから差し引く
+		totals[e.Category] -= e.Amount
+		return
+	}
+	// 明細がある場合は明細ごとのカテゴリに計上
+	for _, li := range expenseLines(e) {
+		totals[li.Category] += li.Amount
+	}
+}
+
+// defaultCategoryColor はカテゴリマスタに色がないときの表示色
+const defaultCategoryColor = "#AEB6BF"
 
+// categorySummariesFromTotals はカテゴリID別の金額を CategorySummary にする（カテゴリマスタの sortOrder 順）
+func categorySummariesFromTotals(totals map[string]int, catMaps *CategoryMaps) []model.CategorySummary {
 	var result []model.CategorySummary
 	for catID, amount := range totals {
 		color := catMaps.Color[catID]
 		if color == "" {
-			color = "#AEB6BF"
+			color = defaultCategoryColor
 		}
 		name := catMaps.Name[catID]
 		if name == "" {
@@ -180,6 +168,30 @@ func aggregateByCategory(expenses []model.Expense, month string, payer string, c
 	return result
 }
 
+// summaryLines は支出の集計対象となる明細を返す（支出カテゴリかつ excludeFromSummary でない明細。返金はマイナス）。
+// 振替・残高調整・精算は空を返す。月次集計と各種レポートはこの明細を、FilterExpensesForSummary で
+// 他人の private を除いた支出について集計する。
+func summaryLines(e *model.Expense, catMaps *CategoryMaps) []model.LineItem {
+	if !IsExpenseEntry(e) && !IsRefund(e) {
+		return nil
+	}
+	lines := expenseLines(e)
+	if IsRefund(e) {
+		lines = []model.LineItem{{Category: e.Category, Amount: -e.Amount}}
+	}
+	var result []model.LineItem
+	for _, li := range lines {
+		if isExp, ok := catMaps.IsExpense[li.Category]; ok && !isExp {
+			continue
+		}
+		if catMaps.ExcludeFromSummary[li.Category] {
+			continue
+		}
+		result = append(result, li)
+	}
+	return result
+}
+
 // filterExpenseCategories は isExpense=true のカテゴリのみ返す（キーはカテゴリID）
 func filterExpenseCategories(categories []model.CategorySummary, isExpenseMap map[string]bool) []model.CategorySummary {
 	var result []model.CategorySummary
@@ -224,18 +236,51 @@ func sumCategories(categories []model.CategorySummary) int {
 
 func makeComparison(current, previous int) *model.MonthComparison {
 	diff := current - previous
-	var diffPercent float64
-	if previous > 0 {
-		diffPercent = float64(diff) / float64(previous) * 100
-		diffPercent = float64(int(diffPercent*100)) / 100
-	}
 	return &model.MonthComparison{
 		Total:       previous,
 		Diff:        diff,
-		DiffPercent: diffPercent,
+		DiffPercent: diffPercent(diff, previous),
 	}
 }
 
+// makeCategoryComparison は makeComparison にカテゴリ別の比較を加える
+// （今回・比較対象のどちらかにあるカテゴリ、今回の並び順の後に比較対象のみのカテゴリ）
+func makeCategoryComparison(current, previous int, currentCategories, previousCategories []model.CategorySummary) *model.MonthComparison {
+	comparison := makeComparison(current, previous)
+	prevAmounts := make(map[string]int, len(previousCategories))
+	for _, c := range previousCategories {
+		prevAmounts[c.CategoryID] = c.Amount
+	}
+	seen := make(map[string]bool, len(currentCategories))
+	for _, c := range currentCategories {
+		seen[c.CategoryID] = true
+		diff := c.Amount - prevAmounts[c.CategoryID]
+		comparison.ByCategory = append(comparison.ByCategory, model.CategoryComparison{
+			CategoryID: c.CategoryID, Category: c.Category, Amount: prevAmounts[c.CategoryID],
+			Diff: diff, DiffPercent: diffPercent(diff, prevAmounts[c.CategoryID]),
+		})
+	}
+	for _, c := range previousCategories {
+		if seen[c.CategoryID] {
+			continue
+		}
+		comparison.ByCategory = append(comparison.ByCategory, model.CategoryComparison{
+			CategoryID: c.CategoryID, Category: c.Category, Amount: c.Amount,
+			Diff: -c.Amount, DiffPercent: diffPercent(-c.Amount, c.Amount),
+		})
+	}
+	return comparison
+}
+
+// diffPercent は比較対象に対する増減率（%、小数第 2 位で切り捨て）を返す（比較対象が 0 以下なら 0）
+func diffPercent(diff, previous int) float64 {
+	if previous <= 0 {
+		return 0
+	}
+	percent := float64(diff) / float64(previous) * 100
+	return float64(int(percent*100)) / 100
+}
+
 // previousMonth は "YYYY-MM" の前月を返す
 func previousMonth(month string) string {
 	parts := strings.Split(month, "-")
@@ -261,3 +306,28 @@ func previousYearMonth(month string) string {
 	t := time.Date(year, time.Month(m), 1, 0, 0, 0, 0, time.UTC).AddDate(-1, 0, 0)
 	return fmt.Sprintf("%04d-%02d", t.Year(), t.Month())
 }
+
+// maxMonthRange は期間指定 API で一度に扱える最大月数
+const maxMonthRange = 120
+
+// monthRange は "YYYY-MM" の from〜to（両端を含む）の月一覧を昇順で返す
+func monthRange(from string, to string) ([]string, error) {
+	if _, err := time.Parse("2006-01", from); err != nil {
+		return nil, apperror.Newf("from の形式が不正です: %s", from)
+	}
+	if _, err := time.Parse("2006-01", to); err != nil {
+		return nil, apperror.Newf("to の形式が不正です: %s", to)
+	}
+	if to < from {
+		return nil, apperror.New("to は from 以降の月を指定してください")
+	}
+
+	months, err := monthsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(months) > maxMonthRange {
+		return nil, apperror.Newf("期間は %d ヶ月以内で指定してください", maxMonthRange)
+	}
+	return months, nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 月別集計キャッシュは月と開始日ごとに、private 以外の記録（Shared）と登録者ごとの private の記録（Private）を
+// 支払元別・カテゴリID別の金額で保持する。カテゴリ名・集計除外などのカテゴリ設定と visibility は読み出し時に
+// 閲覧者ごとに適用するため、同じキャッシュを全員で共有できる。
+// 記録の変更時は影響する月を無効化し（版を進める）、次の読み出しで再計算する。
+
+// loadSummaryCaches は複数月の集計キャッシュを返す。無効・未作成の月は記録から計算して保存する。
+// キャッシュの取得・保存に失敗した場合は記録からの計算で続行する。
+func loadSummaryCaches(ctx context.Context, client *dynamo.Client, months []string, startDay int) (map[string]*model.SummaryCache, error) {
+	caches, err := client.BatchGetSummaryCaches(ctx, months, startDay)
+	if err != nil {
+		log.Printf("monthlySummary cache read failed: %v", err)
+		caches = make(map[string]*model.SummaryCache, len(months))
+	}
+	var missing []string
+	for _, ym := range months {
+		if c := caches[ym]; c == nil || !c.Valid {
+			missing = append(missing, ym)
+		}
+	}
+	if len(missing) == 0 {
+		return caches, nil
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, missing, startDay)
+	if err != nil {
+		return nil, err
+	}
+	var wg sync.WaitGroup
+	for _, ym := range missing {
+		cache := buildSummaryCache(byMonth[ym], ym, startDay)
+		if old := caches[ym]; old != nil {
+			cache.Version = old.Version
+		}
+		caches[ym] = cache
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.PutSummaryCache(ctx, cache); err != nil {
+				log.Printf("monthlySummary cache write failed for %s: %v", cache.Month, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return caches, nil
+}
+
+// buildSummaryCache は月（開始日基準）の記録から集計キャッシュを作る（Version は 0）
+func buildSummaryCache(expenses []model.Expense, month string, startDay int) *model.SummaryCache {
+	cache := &model.SummaryCache{
+		Month:    month,
+		StartDay: startDay,
+		Shared:   model.SummaryAmounts{},
+		Private:  make(map[string]model.SummaryAmounts),
+		Valid:    true,
+	}
+	for i := range expenses {
+		e := &expenses[i]
+		if monthOf(e.Date, startDay) != month {
+			continue
+		}
+		amounts := cache.Shared
+		if EffectiveVisibility(e.Visibility) == VisibilityPrivate {
+			if cache.Private[e.CreatedBy] == nil {
+				cache.Private[e.CreatedBy] = model.SummaryAmounts{}
+			}
+			amounts = cache.Private[e.CreatedBy]
+		}
+		if amounts[e.Payer] == nil {
+			amounts[e.Payer] = make(map[string]int)
+		}
+		addCategoryAmounts(amounts[e.Payer], e)
+	}
+	return cache
+}
+
+// monthDataFromCache は集計キャッシュから閲覧者の月別集計を作る（payer 指定時はその支払元のみ）。
+// 自分の private は含め、他人の private は除外する。
+// total は excludeFromBreakdown を含む全支出カテゴリの合計、byCategory は除外後の内訳。
+func monthDataFromCache(cache *model.SummaryCache, payer string, catMaps *CategoryMaps, userEmail string) *model.MonthData {
+	totals := make(map[string]int)
+	add := func(amounts model.SummaryAmounts) {
+		for p, byCategory := range amounts {
+			if payer != "" && p != payer {
+				continue
+			}
+			for cat, amount := range byCategory {
+				totals[cat] += amount
+			}
+		}
+	}
+	add(cache.Shared)
+	add(cache.Private[userEmail])
+
+	allCategories := filterExpenseCategories(categorySummariesFromTotals(totals, catMaps), catMaps.IsExpense)
+	summaryCategories := filterSummaryCategories(allCategories, catMaps.ExcludeFromSummary)
+	return &model.MonthData{
+		Month:      cache.Month,
+		Total:      sumCategories(summaryCategories),
+		ByCategory: filterBreakdownCategories(summaryCategories, catMaps.ExcludeFromBreakdown),
+	}
+}
+
+// invalidateSummaryCache は日付の記録を含む月の集計キャッシュを無効化する（失敗はログのみ）。
+// カレンダー月（開始日 1）と世帯設定の開始日の月の両方を対象にする。
+// DynamoDB Streams（ProcessExpenseChanges）でも無効化するが、操作直後の集計に古いキャッシュを返さないよう
+// 同期的にも行う（残高スナップショットも同様に markBalanceSnapshotsDirty で再計算待ちにする）。
+func invalidateSummaryCache(ctx context.Context, client *dynamo.Client, dates ...string) {
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		log.Printf("monthlySummary cache invalidation failed: %v", err)
+		return
+	}
+	for _, k := range summaryCacheKeys(dates, startDay) {
+		if err := client.InvalidateSummaryCache(ctx, k.month, k.startDay); err != nil {
+			log.Printf("monthlySummary cache invalidation failed for %s#%d: %v", k.month, k.startDay, err)
+		}
+	}
+}
+
+// summaryCacheKey は集計キャッシュのキー
+type summaryCacheKey struct {
+	month    string
+	startDay int
+}
+
+// summaryCacheKeys は日付の記録を含む集計キャッシュのキーを返す（カレンダー月と開始日 startDay の月。重複なし）
+func summaryCacheKeys(dates []string, startDay int) []summaryCacheKey {
+	var keys []summaryCacheKey
+	seen := make(map[summaryCacheKey]bool)
+	for _, date := range dates {
+		if len(date) < 7 {
+			continue
+		}
+		for _, d := range []int{1, startDay} {
+			k := summaryCacheKey{monthOf(date, d), d}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// invalidateAllSummaryCaches は集計キャッシュをすべて無効化する（月の開始日の変更・移行時。失敗はログのみ）。
+// カテゴリ設定は読み出し時に適用するため、カテゴリの変更では無効化しない。
+func invalidateAllSummaryCaches(ctx context.Context, client *dynamo.Client) {
+	if _, err := client.InvalidateAllSummaryCaches(ctx); err != nil {
+		log.Printf("monthlySummary cache invalidation failed: %v", err)
+	}
+}
+
+// 集計キャッシュの照合結果
+const (
+	SummaryCacheOK       = "ok"       // 記録からの集計と一致
+	SummaryCacheMissing  = "missing"  // 未作成
+	SummaryCacheInvalid  = "invalid"  // 無効化済み（次の読み出しで再計算される）
+	SummaryCacheMismatch = "mismatch" // 記録からの集計と食い違う
+)
+
+// RebuildSummaryCaches は from〜to の月別集計キャッシュを記録から作り直す（カレンダー月と世帯設定の開始日の月）。
+// verify=true の場合は保存せず、キャッシュと記録からの集計の差異だけを報告する。
+func RebuildSummaryCaches(ctx context.Context, client *dynamo.Client, from, to string, verify bool) (*model.SummaryCacheReport, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, false)
+	if err != nil {
+		return nil, err
+	}
+	startDays := []int{1}
+	if startDay != 1 {
+		startDays = append(startDays, startDay)
+	}
+
+	report := &model.SummaryCacheReport{From: from, To: to, Verify: verify, Months: []model.SummaryCacheMonth{}}
+	for _, d := range startDays {
+		caches, err := client.BatchGetSummaryCaches(ctx, months, d)
+		if err != nil {
+			return nil, err
+		}
+		byMonth, err := queryMonthsExpenses(ctx, client, months, d)
+		if err != nil {
+			return nil, err
+		}
+		for _, ym := range months {
+			actual := buildSummaryCache(byMonth[ym], ym, d)
+			result := compareSummaryCache(caches[ym], actual)
+			if result.Status == SummaryCacheMismatch {
+				report.Mismatches++
+			}
+			if !verify {
+				if old := caches[ym]; old != nil {
+					actual.Version = old.Version
+				}
+				saved, err := client.PutSummaryCache(ctx, actual)
+				if err != nil {
+					return nil, err
+				}
+				if saved {
+					result.Rebuilt = true
+					report.Rebuilt++
+				}
+			}
+			report.Months = append(report.Months, result)
+		}
+	}
+	return report, nil
+}
+
+// compareSummaryCache はキャッシュを記録からの集計 actual と照合する（private は食い違う登録者の数のみ）
+func compareSummaryCache(cached *model.SummaryCache, actual *model.SummaryCache) model.SummaryCacheMonth {
+	result := model.SummaryCacheMonth{Month: actual.Month, StartDay: actual.StartDay, Status: SummaryCacheOK}
+	switch {
+	case cached == nil:
+		result.Status = SummaryCacheMissing
+		return result
+	case !cached.Valid:
+		result.Status = SummaryCacheInvalid
+		return result
+	}
+	result.Diffs = diffSummaryAmounts(cached.Shared, actual.Shared)
+	owners := make(map[string]bool)
+	for email := range cached.Private {
+		owners[email] = true
+	}
+	for email := range actual.Private {
+		owners[email] = true
+	}
+	for email := range owners {
+		if len(diffSummaryAmounts(cached.Private[email], actual.Private[email])) > 0 {
+			result.PrivateMismatches++
+		}
+	}
+	if len(result.Diffs) > 0 || result.PrivateMismatches > 0 {
+		result.Status = SummaryCacheMismatch
+	}
+	return result
+}
+
+// diffSummaryAmounts は支払元・カテゴリごとの金額の差異を返す（支払元・カテゴリID順。0 と未集計は同じとみなす）
+func diffSummaryAmounts(cached, actual model.SummaryAmounts) []model.SummaryCacheDiff {
+	var diffs []model.SummaryCacheDiff
+	seen := make(map[[2]string]bool)
+	check := func(payer, cat string) {
+		key := [2]string{payer, cat}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if c, a := cached[payer][cat], actual[payer][cat]; c != a {
+			diffs = append(diffs, model.SummaryCacheDiff{Payer: payer, CategoryID: cat, Cached: c, Actual: a})
+		}
+	}
+	for payer, byCategory := range cached {
+		for cat := range byCategory {
+			check(payer, cat)
+		}
+	}
+	for payer, byCategory := range actual {
+		for cat := range byCategory {
+			check(payer, cat)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Payer != diffs[j].Payer {
+			return diffs[i].Payer < diffs[j].Payer
+		}
+		return diffs[i].CategoryID < diffs[j].CategoryID
+	})
+	return diffs
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestSummaryCacheVisibility(t *testing.T) {
+	expenses := []model.Expense{
+		{Date: "2025-03-01", Payer: "財布", Category: "food", Amount: 1000, CreatedBy: "a@example.com"},
+		{Date: "2025-03-02", Payer: "カード", Category: "daily", Amount: 500, CreatedBy: "b@example.com", Visibility: VisibilitySummary},
+		{Date: "2025-03-03", Payer: "カード", Category: "food", Amount: 3000, CreatedBy: "a@example.com", Visibility: VisibilityPrivate},
+		{Date: "2025-03-04", Payer: "財布", Category: "daily", Amount: 700, CreatedBy: "b@example.com", Visibility: VisibilityPrivate},
+		{Date: "2025-03-05", Payer: "財布", Type: ExpenseTypeRefund, Category: "food", Amount: 200, CreatedBy: "a@example.com"},
+		{Date: "2025-03-06", Payer: "財布", Items: []model.LineItem{{Category: "food", Amount: 400}, {Category: "daily", Amount: 100}}, Amount: 500, CreatedBy: "b@example.com"},
+		{Date: "2025-03-07", Payer: "財布", Category: "rent", Amount: 80000, CreatedBy: "a@example.com"},
+		{Date: "2025-03-08", Payer: "財布", Category: "mine", Amount: 900, CreatedBy: "b@example.com"},
+		{Date: "2025-03-09", Payer: "銀行", Type: ExpenseTypeTransfer, ToPayer: "財布", Amount: 10000, CreatedBy: "a@example.com"},
+		{Date: "2025-04-01", Payer: "財布", Category: "food", Amount: 99999, CreatedBy: "a@example.com"},
+	}
+	shared := &CategoryMaps{
+		Name:                 map[string]string{"food": "食費", "daily": "日用品", "rent": "家賃"},
+		IsExpense:            map[string]bool{"food": true, "daily": true, "rent": true},
+		SortOrder:            map[string]int{"food": 1, "daily": 2, "rent": 3},
+		ExcludeFromBreakdown: map[string]bool{"rent": true},
+	}
+	// b の個人カテゴリ "mine" は b のカテゴリ設定にのみ含まれる
+	withMine := &CategoryMaps{
+		Name:                 map[string]string{"food": "食費", "daily": "日用品", "rent": "家賃", "mine": "趣味"},
+		IsExpense:            map[string]bool{"food": true, "daily": true, "rent": true, "mine": true},
+		SortOrder:            map[string]int{"food": 1, "daily": 2, "rent": 3, "mine": 4},
+		ExcludeFromBreakdown: map[string]bool{"rent": true},
+	}
+
+	cache := buildSummaryCache(expenses, "2025-03", 1)
+	if len(cache.Private) != 2 || cache.Private["a@example.com"]["カード"]["food"] != 3000 {
+		t.Errorf("Private = %+v", cache.Private)
+	}
+
+	tests := []struct {
+		name    string
+		user    string
+		payer   string
+		catMaps *CategoryMaps
+	}{
+		{name: "a 全体", user: "a@example.com", catMaps: shared},
+		{name: "b 全体（個人カテゴリあり）", user: "b@example.com", catMaps: withMine},
+		{name: "a 財布", user: "a@example.com", payer: "財布", catMaps: shared},
+		{name: "b カード", user: "b@example.com", payer: "カード", catMaps: withMine},
+		{name: "c 全体", user: "c@example.com", catMaps: shared},
+	}
+	for _, tt := range tests {
+		// キャッシュからの集計は記録から直接集計した結果と一致する
+		var filtered []model.Expense
+		for _, e := range FilterExpensesForSummary(expenses, tt.user) {
+			if monthOf(e.Date, 1) == "2025-03" {
+				filtered = append(filtered, e)
+			}
+		}
+		allCategories := filterExpenseCategories(categorySummaries(filtered, tt.payer, tt.catMaps), tt.catMaps.IsExpense)
+		summaryCategories := filterSummaryCategories(allCategories, tt.catMaps.ExcludeFromSummary)
+		want := &model.MonthData{
+			Month:      "2025-03",
+			Total:      sumCategories(summaryCategories),
+			ByCategory: filterBreakdownCategories(summaryCategories, tt.catMaps.ExcludeFromBreakdown),
+		}
+		got := monthDataFromCache(cache, tt.payer, tt.catMaps, tt.user)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: monthDataFromCache() = %+v, want %+v", tt.name, got, want)
+		}
+	}
+
+	// a: 食費 1000+3000-200+400、日用品 500+100、家賃は合計のみ、マスタにないカテゴリは ID のまま
+	got := monthDataFromCache(cache, "", shared, "a@example.com")
+	if got.Total != 85700 || len(got.ByCategory) != 3 {
+		t.Fatalf("a summary = %+v", got)
+	}
+	if got.ByCategory[1].CategoryID != "food" || got.ByCategory[1].Amount != 4200 || got.ByCategory[2].Amount != 600 {
+		t.Errorf("a byCategory = %+v", got.ByCategory)
+	}
+	// c: 他人の private は含まない
+	if got := monthDataFromCache(cache, "", shared, "c@example.com"); got.Total != 82700 {
+		t.Errorf("c total = %d, want 82700", got.Total)
+	}
+}
+
+func TestBuildSummaryCacheStartDay(t *testing.T) {
+	expenses := []model.Expense{
+		{Date: "2025-02-24", Payer: "財布", Category: "food", Amount: 100},
+		{Date: "2025-02-25", Payer: "財布", Category: "food", Amount: 200},
+		{Date: "2025-03-24", Payer: "財布", Category: "food", Amount: 400},
+		{Date: "2025-03-25", Payer: "財布", Category: "food", Amount: 800},
+	}
+	cache := buildSummaryCache(expenses, "2025-02", 25)
+	if cache.Month != "2025-02" || cache.StartDay != 25 || !cache.Valid || cache.Shared["財布"]["food"] != 600 {
+		t.Errorf("cache = %+v", cache)
+	}
+}
+
+func TestCompareSummaryCache(t *testing.T) {
+	actual := buildSummaryCache([]model.Expense{
+		{Date: "2025-03-01", Payer: "財布", Category: "food", Amount: 1000, CreatedBy: "a@example.com"},
+		{Date: "2025-03-02", Payer: "カード", Category: "daily", Amount: 500, CreatedBy: "a@example.com"},
+		{Date: "2025-03-03", Payer: "財布", Category: "food", Amount: 300, CreatedBy: "b@example.com", Visibility: VisibilityPrivate},
+	}, "2025-03", 1)
+
+	if got := compareSummaryCache(nil, actual); got.Status != SummaryCacheMissing {
+		t.Errorf("nil cache status = %s", got.Status)
+	}
+	if got := compareSummaryCache(&model.SummaryCache{Month: "2025-03", StartDay: 1}, actual); got.Status != SummaryCacheInvalid {
+		t.Errorf("invalid cache status = %s", got.Status)
+	}
+	same := buildSummaryCache(nil, "2025-03", 1)
+	same.Shared = model.SummaryAmounts{"財布": {"food": 1000, "rent": 0}, "カード": {"daily": 500}}
+	same.Private = map[string]model.SummaryAmounts{"b@example.com": {"財布": {"food": 300}}}
+	if got := compareSummaryCache(same, actual); got.Status != SummaryCacheOK || got.Diffs != nil {
+		t.Errorf("same cache = %+v", got)
+	}
+
+	// 金額違い・記録にないカテゴリ・private の欠落
+	stale := buildSummaryCache(nil, "2025-03", 1)
+	stale.Shared = model.SummaryAmounts{"財布": {"food": 800, "misc": 50}, "カード": {"daily": 500}}
+	got := compareSummaryCache(stale, actual)
+	wantDiffs := []model.SummaryCacheDiff{
+		{Payer: "財布", CategoryID: "food", Cached: 800, Actual: 1000},
+		{Payer: "財布", CategoryID: "misc", Cached: 50, Actual: 0},
+	}
+	if got.Status != SummaryCacheMismatch || got.PrivateMismatches != 1 || !reflect.DeepEqual(got.Diffs, wantDiffs) {
+		t.Errorf("stale cache = %+v, want diffs %+v", got, wantDiffs)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+const (
+	maxTagsPerExpense = 10
+	maxTagLength      = 30
+)
+
+// normalizeTags はタグの前後の空白と先頭の "#" を取り除き、重複を除いて昇順に並べる。
+// タグは DynamoDB の string set として保存するため、空のタグは除外する。
+func normalizeTags(tags []string) ([]string, *apperror.AppError) {
+	seen := make(map[string]bool, len(tags))
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, apperror.Newf("タグは %d 文字以内で指定してください: %s", maxTagLength, tag)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxTagsPerExpense {
+		return nil, apperror.Newf("タグは %d 個以内で指定してください", maxTagsPerExpense)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// hasAllTags は支出が指定タグをすべて含むかどうかを返す
+func hasAllTags(e *model.Expense, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range e.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterExpensesByTags は指定タグをすべて含む支出のみ返す。
+// 他人の summary 支出はタグがマスクされるため、visibility フィルタ適用後に呼ぶこと。
+func filterExpensesByTags(expenses []model.Expense, tags []string) []model.Expense {
+	if len(tags) == 0 {
+		return expenses
+	}
+	result := make([]model.Expense, 0, len(expenses))
+	for _, e := range expenses {
+		if hasAllTags(&e, tags) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// SearchExpenses は期間（from〜to、両端を含む）の支出をキーワード・タグで検索する（新しい日付順）。
+// キーワードはメモ・場所・カテゴリ名の部分一致、タグはすべて含むものに一致する。
+func SearchExpenses(ctx context.Context, client *dynamo.Client, from string, to string, query string, tags []string, userEmail string) ([]model.Expense, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	tags, appErr := normalizeTags(tags)
+	if appErr != nil {
+		return nil, appErr
+	}
+	query = strings.TrimSpace(query)
+	if query == "" && len(tags) == 0 {
+		return nil, apperror.New("query または tags は必須です")
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := fetchMonths(ctx, months, func(ctx context.Context, ym string) ([]model.Expense, error) {
+		return client.QueryExpensesByMonthWithTags(ctx, ym, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := []model.Expense{}
+	for i := len(months) - 1; i >= 0; i-- {
+		for _, e := range filterExpensesByTags(FilterExpensesForUser(byMonth[months[i]], userEmail), tags) {
+			if query == "" || matchesQuery(&e, query, catMaps) {
+				result = append(result, e)
+			}
+		}
+	}
+	return result, nil
+}
+
+// matchesQuery はメモ・場所・カテゴリ名（明細を含む）のいずれかにキーワードを含むかどうかを返す（大文字小文字を区別しない）
+func matchesQuery(e *model.Expense, query string, catMaps *CategoryMaps) bool {
+	q := strings.ToLower(query)
+	if e.Category != "" {
+		for _, li := range expenseLines(e) {
+			if strings.Contains(strings.ToLower(catMaps.Name[li.Category]), q) || strings.Contains(strings.ToLower(li.Memo), q) {
+				return true
+			}
+		}
+	}
+	return strings.Contains(strings.ToLower(e.Memo), q) || strings.Contains(strings.ToLower(e.Place), q)
+}
+
+// GetTagSummary は期間（from〜to の月、両端を含む。月の区切りは GetMonthlySummary と同じ）の支出をタグ別に集計する。
+// 集計対象は summaryLines の明細。
+func GetTagSummary(ctx context.Context, client *dynamo.Client, from string, to string, userEmail string, calendar bool) (*model.TagSummary, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth, err := queryMonthsExpenses(ctx, client, months, startDay)
+	if err != nil {
+		return nil, err
+	}
+	expenses := FilterExpensesForSummary(flattenMonths(months, byMonth), userEmail)
+
+	return &model.TagSummary{
+		From:  from,
+		To:    to,
+		ByTag: aggregateByTag(expenses, catMaps),
+	}, nil
+}
+
+// aggregateByTag は支出をタグ別に集計する（金額の大きい順、同額はタグ名順）
+func aggregateByTag(expenses []model.Expense, catMaps *CategoryMaps) []model.TagAmount {
+	byTag := make(map[string]*model.TagAmount)
+	for i := range expenses {
+		e := &expenses[i]
+		if len(e.Tags) == 0 {
+			continue
+		}
+		amount := 0
+		for _, li := range summaryLines(e, catMaps) {
+			amount += li.Amount
+		}
+		if amount == 0 {
+			continue
+		}
+		for _, tag := range e.Tags {
+			t, ok := byTag[tag]
+			if !ok {
+				t = &model.TagAmount{Tag: tag}
+				byTag[tag] = t
+			}
+			t.Amount += amount
+			if !IsRefund(e) {
+				t.Count++
+			}
+		}
+	}
+
+	result := make([]model.TagAmount, 0, len(byTag))
+	for _, t := range byTag {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Amount != result[j].Amount {
+			return result[i].Amount > result[j].Amount
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
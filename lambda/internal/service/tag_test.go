@@ -0,0 +1,56 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "空", tags: nil, want: nil},
+		{name: "空白・#・重複を除去して昇順", tags: []string{" 立替 ", "#学校", "", "学校", "立替"}, want: []string{"学校", "立替"}},
+		{name: "長すぎるタグ", tags: []string{"あいうえおかきくけこさしすせそたちつてとなにぬねのはひふへほま"}, wantErr: true},
+		{name: "個数超過", tags: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeTags(tt.tags)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: normalizeTags() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: normalizeTags() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAggregateByTag(t *testing.T) {
+	catMaps := &CategoryMaps{
+		IsExpense:          map[string]bool{"food": true, "school": true, "income": false, "savings": true},
+		ExcludeFromSummary: map[string]bool{"savings": true},
+	}
+	expenses := []model.Expense{
+		{Category: "school", Amount: 5000, Tags: []string{"学校", "立替"}},
+		{Category: "food", Amount: 1200, Tags: []string{"学校"}},
+		{Category: "food", Amount: 800, Tags: []string{"立替"}},
+		{Category: "food", Amount: 3000},
+		// 対象外: 収入カテゴリ・集計除外カテゴリ・振替
+		{Category: "income", Amount: 9000, Tags: []string{"学校"}},
+		{Category: "savings", Amount: 9000, Tags: []string{"学校"}},
+		{Type: ExpenseTypeTransfer, Amount: 9000, Tags: []string{"学校"}},
+	}
+	want := []model.TagAmount{
+		{Tag: "学校", Amount: 6200, Count: 2},
+		{Tag: "立替", Amount: 5800, Count: 2},
+	}
+	if got := aggregateByTag(expenses, catMaps); !reflect.DeepEqual(got, want) {
+		t.Errorf("aggregateByTag() = %v, want %v", got, want)
+	}
+}
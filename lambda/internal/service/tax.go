@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// taxRates は登録できる消費税率（%）: 標準税率・軽減税率・非課税
+var taxRates = map[int]bool{10: true, 8: true, 0: true}
+
+// taxOf は金額に対する消費税額を返す（1 円未満切り捨て）。
+// excluded=true の場合 amount は税抜金額、false の場合は税込金額として計算する。
+func taxOf(amount int, rate int, excluded bool) int {
+	if excluded {
+		return amount * rate / 100
+	}
+	return amount * rate / (100 + rate)
+}
+
+// normalizeTaxLines は税率別内訳を検証し、税込金額に揃える（税率の高い順）。
+// 消費税額が 0 の場合は税率から計算し、レシート記載の税額を指定した場合はそれを使う。
+// 金額が 0 の場合は税込金額の合計を金額とする。振替は normalizeTransferInput で内訳を破棄するため検証しない。
+func normalizeTaxLines(input *model.ExpenseInput) *apperror.AppError {
+	if len(input.TaxLines) == 0 || EffectiveExpenseType(input.Type) == ExpenseTypeTransfer {
+		input.TaxExcluded = false
+		return nil
+	}
+	if isForeignCurrency(input.Currency) {
+		return apperror.New("外貨建ての支出には消費税の内訳を指定できません")
+	}
+
+	seen := make(map[int]bool, len(input.TaxLines))
+	total := 0
+	lines := make([]model.TaxLine, len(input.TaxLines))
+	for i, t := range input.TaxLines {
+		if !taxRates[t.Rate] {
+			return apperror.Newf("税率は 10, 8, 0 のいずれかを指定してください: %d", t.Rate)
+		}
+		if seen[t.Rate] {
+			return apperror.Newf("税率 %d%% の内訳が重複しています", t.Rate)
+		}
+		seen[t.Rate] = true
+		if t.Amount <= 0 || t.Tax < 0 {
+			return apperror.Newf("税率 %d%%: 金額は 0 より大きい値、税額は 0 以上で指定してください", t.Rate)
+		}
+		if t.Tax == 0 {
+			t.Tax = taxOf(t.Amount, t.Rate, input.TaxExcluded)
+		}
+		if input.TaxExcluded {
+			t.Amount += t.Tax
+		}
+		if t.Tax >= t.Amount {
+			return apperror.Newf("税率 %d%%: 税額が金額を超えています", t.Rate)
+		}
+		total += t.Amount
+		lines[i] = t
+	}
+	if input.Amount == 0 {
+		input.Amount = total
+	}
+	if total != input.Amount {
+		return apperror.Newf("消費税の内訳の税込合計（%d）が金額（%d）と一致しません", total, input.Amount)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Rate > lines[j].Rate })
+	input.TaxLines = lines
+	input.TaxExcluded = false
+	return nil
+}
+
+// prorate は value を weights の比率で按分する（端数は最も重みの大きい要素、同じ重みは先頭に寄せる）
+func prorate(value int, weights []int) []int {
+	result := make([]int, len(weights))
+	total, largest := 0, 0
+	for i, w := range weights {
+		total += w
+		if w > weights[largest] {
+			largest = i
+		}
+	}
+	if total == 0 {
+		return result
+	}
+	allocated := 0
+	for i, w := range weights {
+		result[i] = value * w / total
+		allocated += result[i]
+	}
+	result[largest] += value - allocated
+	return result
+}
+
+// maxTaxMatchLines は明細の税率を推定する明細数の上限（組み合わせの探索を抑える）
+const maxTaxMatchLines = 12
+
+// lineTaxRates は明細ごとの税率を返す。税率別内訳の税込金額を明細の金額の組み合わせで
+// ちょうど 1 通りに分けられる場合のみ推定でき、それ以外は nil を返す。
+func lineTaxRates(lines []model.LineItem, taxLines []model.TaxLine) []int {
+	if len(lines) == 0 || len(taxLines) == 0 || len(lines) > maxTaxMatchLines {
+		return nil
+	}
+	rates := make([]int, len(lines))
+	assigned := make([]bool, len(lines))
+	var found []int
+	solutions := 0
+
+	var assign func(t int) bool
+	var pick func(t, i, remaining int) bool
+	// assign は t 番目以降の税率に明細を割り当てる（解が 2 通り見つかったら打ち切る）
+	assign = func(t int) bool {
+		if t == len(taxLines) {
+			for _, a := range assigned {
+				if !a {
+					return false
+				}
+			}
+			solutions++
+			found = append([]int(nil), rates...)
+			return solutions > 1
+		}
+		return pick(t, 0, taxLines[t].Amount)
+	}
+	pick = func(t, i, remaining int) bool {
+		if remaining == 0 {
+			return assign(t + 1)
+		}
+		if i == len(lines) {
+			return false
+		}
+		if !assigned[i] && lines[i].Amount > 0 && lines[i].Amount <= remaining {
+			assigned[i], rates[i] = true, taxLines[t].Rate
+			stop := pick(t, i+1, remaining-lines[i].Amount)
+			assigned[i] = false
+			if stop {
+				return true
+			}
+		}
+		return pick(t, i+1, remaining)
+	}
+	assign(0)
+	if solutions != 1 {
+		return nil
+	}
+	return found
+}
+
+// refundTaxLines は返金の税率別内訳を返す（返金元に内訳がない場合は nil）。
+// 明細ごとの税率を推定できる場合は返金するカテゴリの明細の税率で、できない場合は返金元の内訳全体で按分する。
+func refundTaxLines(original *model.Expense, category string, amount int) []model.TaxLine {
+	if len(original.TaxLines) == 0 {
+		return nil
+	}
+	weights := make([]int, len(original.TaxLines))
+	lines := expenseLines(original)
+	if rates := lineTaxRates(lines, original.TaxLines); rates != nil {
+		for i, t := range original.TaxLines {
+			for j, li := range lines {
+				if li.Category == category && rates[j] == t.Rate {
+					weights[i] += li.Amount
+				}
+			}
+		}
+	} else {
+		for i, t := range original.TaxLines {
+			weights[i] = t.Amount
+		}
+	}
+	var result []model.TaxLine
+	for i, a := range prorate(amount, weights) {
+		if a == 0 {
+			continue
+		}
+		t := original.TaxLines[i]
+		result = append(result, model.TaxLine{Rate: t.Rate, Amount: a, Tax: t.Tax * a / t.Amount})
+	}
+	return result
+}
+
+// categoryTax はカテゴリ・税率ごとに按分した税込金額・消費税額
+type categoryTax struct {
+	category string
+	rate     int
+	amount   int
+	tax      int
+}
+
+// expenseCategoryTaxes は税率別内訳を明細のカテゴリごとに按分して返す（集計対象外のカテゴリは除く、返金はマイナス）。
+// 明細ごとの税率を推定できる場合は各税率をその税率の明細だけで按分する。
+func expenseCategoryTaxes(e *model.Expense, catMaps *CategoryMaps) []categoryTax {
+	if len(e.TaxLines) == 0 {
+		return nil
+	}
+	lines := expenseLines(e)
+	rates := lineTaxRates(lines, e.TaxLines)
+	included := make(map[string]bool)
+	for _, li := range summaryLines(e, catMaps) {
+		included[li.Category] = true
+	}
+	sign := 1
+	if IsRefund(e) {
+		sign = -1
+	}
+
+	var result []categoryTax
+	for _, t := range e.TaxLines {
+		weights := make([]int, len(lines))
+		for i, li := range lines {
+			if rates == nil || rates[i] == t.Rate {
+				weights[i] = li.Amount
+			}
+		}
+		amounts := prorate(t.Amount, weights)
+		taxes := prorate(t.Tax, weights)
+		for i, li := range lines {
+			if !included[li.Category] {
+				continue
+			}
+			result = append(result, categoryTax{category: li.Category, rate: t.Rate, amount: sign * amounts[i], tax: sign * taxes[i]})
+		}
+	}
+	return result
+}
+
+// GetTaxSummary は期間（from〜to の月、両端を含む。月の区切りは GetMonthlySummary と同じ）の消費税を月別・税率別・カテゴリ別に集計する。
+// 集計対象は summaryLines の明細で、税率別内訳のない支出は含まない。
+func GetTaxSummary(ctx context.Context, client *dynamo.Client, from string, to string, userEmail string, calendar bool) (*model.TaxSummary, error) {
+	months, err := monthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	startDay, err := loadMonthStartDay(ctx, client, calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.TaxSummary{From: from, To: to, Months: make([]model.TaxMonth, 0, len(months))}
+	var all []model.Expense
+	byMonth, err := queryMonthsExpenses(ctx, client, months, startDay)
+	if err != nil {
+		return nil, err
+	}
+	for _, ym := range months {
+		filtered := FilterExpensesForSummary(byMonth[ym], userEmail)
+		month := aggregateTax(filtered, catMaps)
+		month.Month = ym
+		result.Months = append(result.Months, month)
+		all = append(all, filtered...)
+	}
+	total := aggregateTax(all, catMaps)
+	result.Taxable, result.Tax, result.ByRate = total.Taxable, total.Tax, total.ByRate
+	return result, nil
+}
+
+// aggregateTax は支出の消費税を税率別・カテゴリ別に集計する
+func aggregateTax(expenses []model.Expense, catMaps *CategoryMaps) model.TaxMonth {
+	byRate := make(map[int]*model.TaxRateAmount)
+	byCategory := make(map[string]*model.TaxCategoryAmount)
+	result := model.TaxMonth{ByRate: []model.TaxRateAmount{}, ByCategory: []model.TaxCategoryAmount{}}
+	for i := range expenses {
+		for _, ct := range expenseCategoryTaxes(&expenses[i], catMaps) {
+			taxable := ct.amount - ct.tax
+			result.Taxable += taxable
+			result.Tax += ct.tax
+
+			r, ok := byRate[ct.rate]
+			if !ok {
+				r = &model.TaxRateAmount{Rate: ct.rate}
+				byRate[ct.rate] = r
+			}
+			r.Taxable += taxable
+			r.Tax += ct.tax
+
+			c, ok := byCategory[ct.category]
+			if !ok {
+				c = &model.TaxCategoryAmount{CategoryID: ct.category, Category: catMaps.Name[ct.category], Color: catMaps.Color[ct.category]}
+				if c.Category == "" {
+					c.Category = ct.category
+				}
+				if c.Color == "" {
+					c.Color = defaultCategoryColor
+				}
+				byCategory[ct.category] = c
+			}
+			c.Taxable += taxable
+			c.Tax += ct.tax
+		}
+	}
+
+	for _, r := range byRate {
+		result.ByRate = append(result.ByRate, *r)
+	}
+	sort.Slice(result.ByRate, func(i, j int) bool { return result.ByRate[i].Rate > result.ByRate[j].Rate })
+	for _, c := range byCategory {
+		result.ByCategory = append(result.ByCategory, *c)
+	}
+	sort.Slice(result.ByCategory, func(i, j int) bool {
+		return catMaps.SortOrder[result.ByCategory[i].CategoryID] < catMaps.SortOrder[result.ByCategory[j].CategoryID]
+	})
+	return result
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestNormalizeTaxLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      model.ExpenseInput
+		wantAmount int
+		want       []model.TaxLine
+		wantErr    bool
+	}{
+		{
+			name:       "税込（税額は税率から計算、税率の高い順）",
+			input:      model.ExpenseInput{TaxLines: []model.TaxLine{{Rate: 8, Amount: 1080}, {Rate: 10, Amount: 550}}},
+			wantAmount: 1630,
+			want:       []model.TaxLine{{Rate: 10, Amount: 550, Tax: 50}, {Rate: 8, Amount: 1080, Tax: 80}},
+		},
+		{
+			name:       "税抜は税込に変換（1 円未満切り捨て）",
+			input:      model.ExpenseInput{Amount: 1186, TaxExcluded: true, TaxLines: []model.TaxLine{{Rate: 8, Amount: 999}, {Rate: 0, Amount: 108}}},
+			wantAmount: 1186,
+			want:       []model.TaxLine{{Rate: 8, Amount: 1078, Tax: 79}, {Rate: 0, Amount: 108, Tax: 0}},
+		},
+		{
+			name:       "レシート記載の税額を優先",
+			input:      model.ExpenseInput{Amount: 1000, TaxLines: []model.TaxLine{{Rate: 10, Amount: 1000, Tax: 91}}},
+			wantAmount: 1000,
+			want:       []model.TaxLine{{Rate: 10, Amount: 1000, Tax: 91}},
+		},
+		{name: "合計不一致", input: model.ExpenseInput{Amount: 2000, TaxLines: []model.TaxLine{{Rate: 10, Amount: 1000}}}, wantErr: true},
+		{name: "不正な税率", input: model.ExpenseInput{TaxLines: []model.TaxLine{{Rate: 5, Amount: 1000}}}, wantErr: true},
+		{name: "税率の重複", input: model.ExpenseInput{TaxLines: []model.TaxLine{{Rate: 8, Amount: 100}, {Rate: 8, Amount: 200}}}, wantErr: true},
+		{name: "外貨建て", input: model.ExpenseInput{Currency: "USD", OriginalAmount: 10, TaxLines: []model.TaxLine{{Rate: 10, Amount: 1000}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		input := tt.input
+		err := normalizeTaxLines(&input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: normalizeTaxLines() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if input.Amount != tt.wantAmount || !reflect.DeepEqual(input.TaxLines, tt.want) {
+			t.Errorf("%s: amount, taxLines = %d, %v, want %d, %v", tt.name, input.Amount, input.TaxLines, tt.wantAmount, tt.want)
+		}
+	}
+}
+
+func TestAggregateTax(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:      map[string]string{"food": "食費", "daily": "日用品"},
+		Color:     map[string]string{"food": "#f00", "daily": "#0f0"},
+		SortOrder: map[string]int{"food": 1, "daily": 2},
+	}
+	expenses := []model.Expense{
+		// 食品（8%）と日用品（10%）のレシートを明細に分けたもの（税額は明細の金額で按分）
+		{Date: "2025-03-01", Category: "food", Amount: 3000, Items: []model.LineItem{
+			{Category: "food", Amount: 2000},
+			{Category: "daily", Amount: 1000},
+		}, TaxLines: []model.TaxLine{{Rate: 10, Amount: 1100, Tax: 100}, {Rate: 8, Amount: 1900, Tax: 140}}},
+		// 返金は差し引く
+		{Date: "2025-03-05", Type: ExpenseTypeRefund, Category: "food", Amount: 540, TaxLines: []model.TaxLine{{Rate: 8, Amount: 540, Tax: 40}}},
+		// 内訳なしは対象外
+		{Date: "2025-03-06", Category: "food", Amount: 800},
+	}
+
+	got := aggregateTax(expenses, catMaps)
+	want := model.TaxMonth{
+		Taxable: 2260,
+		Tax:     200,
+		ByRate: []model.TaxRateAmount{
+			{Rate: 10, Taxable: 1000, Tax: 100},
+			{Rate: 8, Taxable: 1260, Tax: 100},
+		},
+		ByCategory: []model.TaxCategoryAmount{
+			{CategoryID: "food", Category: "食費", Color: "#f00", Taxable: 1340, Tax: 121},
+			{CategoryID: "daily", Category: "日用品", Color: "#0f0", Taxable: 920, Tax: 79},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("aggregateTax() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRefundTaxLines(t *testing.T) {
+	// 食品 1,080 円（8%）と日用品 1,100 円（10%）のレシート
+	mixed := &model.Expense{Category: "daily", Amount: 2180, Items: []model.LineItem{
+		{Category: "food", Amount: 1080},
+		{Category: "daily", Amount: 1100},
+	}, TaxLines: []model.TaxLine{{Rate: 10, Amount: 1100, Tax: 100}, {Rate: 8, Amount: 1080, Tax: 80}}}
+
+	if got, want := lineTaxRates(expenseLines(mixed), mixed.TaxLines), []int{8, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lineTaxRates() = %v, want %v", got, want)
+	}
+	// 10% の明細だけの返金は全額 10%
+	if got, want := refundTaxLines(mixed, "daily", 1100), []model.TaxLine{{Rate: 10, Amount: 1100, Tax: 100}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("refundTaxLines(daily) = %+v, want %+v", got, want)
+	}
+	if got, want := refundTaxLines(mixed, "food", 540), []model.TaxLine{{Rate: 8, Amount: 540, Tax: 40}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("refundTaxLines(food) = %+v, want %+v", got, want)
+	}
+	// 返金元のカテゴリ別の消費税も明細の税率で按分する
+	var foodTax, dailyTax []categoryTax
+	for _, ct := range expenseCategoryTaxes(mixed, &CategoryMaps{}) {
+		if ct.amount == 0 {
+			continue
+		}
+		if ct.category == "food" {
+			foodTax = append(foodTax, ct)
+		} else {
+			dailyTax = append(dailyTax, ct)
+		}
+	}
+	if !reflect.DeepEqual(foodTax, []categoryTax{{category: "food", rate: 8, amount: 1080, tax: 80}}) ||
+		!reflect.DeepEqual(dailyTax, []categoryTax{{category: "daily", rate: 10, amount: 1100, tax: 100}}) {
+		t.Errorf("expenseCategoryTaxes() = %+v / %+v", foodTax, dailyTax)
+	}
+
+	// 明細の税率を 1 通りに決められない場合は内訳全体で按分する
+	ambiguous := &model.Expense{Category: "food", Amount: 2000, Items: []model.LineItem{
+		{Category: "food", Amount: 1000},
+		{Category: "daily", Amount: 1000},
+	}, TaxLines: []model.TaxLine{{Rate: 10, Amount: 1000, Tax: 90}, {Rate: 8, Amount: 1000, Tax: 74}}}
+	if got := lineTaxRates(expenseLines(ambiguous), ambiguous.TaxLines); got != nil {
+		t.Errorf("lineTaxRates(ambiguous) = %v, want nil", got)
+	}
+	want := []model.TaxLine{{Rate: 10, Amount: 500, Tax: 45}, {Rate: 8, Amount: 500, Tax: 37}}
+	if got := refundTaxLines(ambiguous, "daily", 1000); !reflect.DeepEqual(got, want) {
+		t.Errorf("refundTaxLines(ambiguous) = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"money-diary/internal/apperror"
+	"money-diary/internal/model"
+)
+
+const (
+	ExpenseTypeExpense    = "expense"
+	ExpenseTypeTransfer   = "transfer"
+	ExpenseTypeAdjustment = "adjustment" // 残高照合による調整（ReconcilePayer でのみ作成）
+	ExpenseTypeSettlement = "settlement" // メンバー間の精算（RecordSettlement でのみ作成）
+	ExpenseTypeRefund     = "refund"     // 返品・一部返金（CreateRefund でのみ作成）
+)
+
+// EffectiveExpenseType は空文字列を "expense" に正規化する
+func EffectiveExpenseType(t string) string {
+	if t == "" {
+		return ExpenseTypeExpense
+	}
+	return t
+}
+
+// IsExpenseEntry は支出集計の対象となる通常の記録かどうかを返す（振替・残高調整・精算は対象外）
+func IsExpenseEntry(e *model.Expense) bool {
+	return EffectiveExpenseType(e.Type) == ExpenseTypeExpense
+}
+
+// ValidateExpenseType は登録リクエストの type の値を検証する
+func ValidateExpenseType(t string) bool {
+	return t == "" || t == ExpenseTypeExpense || t == ExpenseTypeTransfer
+}
+
+// validateExpenseInput は支出・振替の登録内容を検証する。
+// 振替は振替元・振替先が必須（カテゴリは不要）、支出はカテゴリが必須。
+func validateExpenseInput(input *model.ExpenseInput) *apperror.AppError {
+	if !ValidateExpenseType(input.Type) {
+		return apperror.New("type は expense, transfer のいずれかを指定してください")
+	}
+	if EffectiveExpenseType(input.Type) == ExpenseTypeTransfer {
+		if input.Date == "" || input.Payer == "" || input.ToPayer == "" || input.Amount <= 0 {
+			return apperror.New("日付、振替元、振替先、金額（0より大きい値）は必須です")
+		}
+		if input.Payer == input.ToPayer {
+			return apperror.New("振替元と振替先に同じ支払元は指定できません")
+		}
+	} else if input.Date == "" || input.Category == "" || input.Amount <= 0 {
+		return apperror.New("日付、カテゴリ、金額（0より大きい値）は必須です")
+	}
+	if !ValidateVisibility(input.Visibility) {
+		return apperror.New("visibility は public, summary, private のいずれかを指定してください")
+	}
+	if r := input.BusinessRatio; r != nil && (*r < 0 || *r > 100) {
+		return apperror.New("事業割合は 0〜100 で指定してください")
+	}
+	return nil
+}
+
+// normalizeTransferInput は振替のカテゴリ・明細・消費税の内訳・事業割合・医療費控除の項目・場所・負担割合を空にし、支出の振替先を空にする
+func normalizeTransferInput(input *model.ExpenseInput) {
+	if EffectiveExpenseType(input.Type) == ExpenseTypeTransfer {
+		input.Category = ""
+		input.Items = nil
+		input.TaxLines = nil
+		input.BusinessRatio = nil
+		input.Patient = ""
+		input.Provider = ""
+		input.MedicalKind = ""
+		input.ReimbursedAmount = 0
+		input.Place = ""
+		input.PaidBy = ""
+		input.Split = nil
+	} else {
+		input.ToPayer = ""
+	}
+}
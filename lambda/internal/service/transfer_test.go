@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestValidateExpenseInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input model.ExpenseInput
+		ok    bool
+	}{
+		{"expense", model.ExpenseInput{Date: "2025-01-01", Category: "food", Amount: 100}, true},
+		{"expense without category", model.ExpenseInput{Date: "2025-01-01", Amount: 100}, false},
+		{"transfer", model.ExpenseInput{Type: "transfer", Date: "2025-01-01", Payer: "銀行", ToPayer: "財布", Amount: 100}, true},
+		{"transfer without destination", model.ExpenseInput{Type: "transfer", Date: "2025-01-01", Payer: "銀行", Amount: 100}, false},
+		{"transfer to same payer", model.ExpenseInput{Type: "transfer", Date: "2025-01-01", Payer: "財布", ToPayer: "財布", Amount: 100}, false},
+		{"unknown type", model.ExpenseInput{Type: "refund", Date: "2025-01-01", Category: "food", Amount: 100}, false},
+	}
+	for _, tt := range tests {
+		err := validateExpenseInput(&tt.input)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: validateExpenseInput() error = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
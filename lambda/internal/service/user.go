@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"strings"
+	"unicode/utf8"
 
+	"money-diary/internal/apperror"
 	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
 )
 
 // IsUserRegistered はユーザーが登録されているか確認する
@@ -26,3 +30,63 @@ func GetUserRole(ctx context.Context, client *dynamo.Client, email string) (stri
 	}
 	return user.Role, nil
 }
+
+// RequireAdmin は管理者以外のユーザーに 403 を返す
+func RequireAdmin(ctx context.Context, client *dynamo.Client, email string) error {
+	role, err := GetUserRole(ctx, client, email)
+	if err != nil {
+		return err
+	}
+	if role != "admin" {
+		return apperror.WithStatus(403, "この操作は管理者のみ実行できます")
+	}
+	return nil
+}
+
+const maxDisplayNameLength = 20
+
+// UpdateDisplayName はユーザー自身の表示名を更新する（空文字列で既定の表示名に戻す）
+func UpdateDisplayName(ctx context.Context, client *dynamo.Client, email string, name string) (*model.User, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return nil, apperror.Newf("表示名は %d 文字以内で入力してください", maxDisplayNameLength)
+	}
+	if err := client.UpdateUserName(ctx, email, name); err != nil {
+		return nil, err
+	}
+	user, err := client.GetUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.New("ユーザーが見つかりません")
+	}
+	return user, nil
+}
+
+// memberNames は登録ユーザーのメールアドレス→表示名を返す
+func memberNames(ctx context.Context, client *dynamo.Client) (map[string]string, error) {
+	users, err := client.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[u.Email] = u.Name
+	}
+	return names, nil
+}
+
+// displayName は表示名を返す（未設定はメールアドレスの @ より前、登録者なしは「未設定」）
+func displayName(email string, names map[string]string) string {
+	if name := names[email]; name != "" {
+		return name
+	}
+	if email == "" {
+		return "未設定"
+	}
+	if i := strings.Index(email, "@"); i > 0 {
+		return email[:i]
+	}
+	return email
+}
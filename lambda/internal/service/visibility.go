@@ -34,8 +34,10 @@ func FilterExpensesForUser(expenses []model.Expense, userEmail string) []model.E
 		case VisibilitySummary:
 			result = append(result, model.Expense{
 				ID:         e.ID,
+				Type:       e.Type,
 				Date:       e.Date,
 				Payer:      e.Payer,
+				ToPayer:    e.ToPayer,
 				Category:   "",
 				Amount:     e.Amount,
 				Visibility: e.Visibility,
@@ -66,3 +68,9 @@ func FilterExpensesForSummary(expenses []model.Expense, userEmail string) []mode
 func ValidateVisibility(v string) bool {
 	return v == "" || v == VisibilityPublic || v == VisibilitySummary || v == VisibilityPrivate
 }
+
+// canAccessExpense は支出の内容（添付ファイル・返金元など）を参照・変更できるかどうかを返す。
+// 他人の summary / private 支出は内容を公開しないため対象外とする。
+func canAccessExpense(e *model.Expense, userEmail string) bool {
+	return e.CreatedBy == userEmail || EffectiveVisibility(e.Visibility) == VisibilityPublic
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"money-diary/internal/apperror"
+	"money-diary/internal/dynamo"
+	"money-diary/internal/model"
+)
+
+// 週の開始曜日（世帯設定の weekStart）
+const (
+	WeekStartMonday = "monday"
+	WeekStartSunday = "sunday"
+)
+
+const (
+	defaultAverageWeeks = 4  // 平均比較の既定の週数
+	maxAverageWeeks     = 12 // 平均比較の最大週数
+)
+
+// effectiveWeekStart は未設定（空文字列）を "monday" に正規化する
+func effectiveWeekStart(s string) string {
+	if s == WeekStartSunday {
+		return WeekStartSunday
+	}
+	return WeekStartMonday
+}
+
+// loadWeekStart は世帯設定の週の開始曜日を返す
+func loadWeekStart(ctx context.Context, client *dynamo.Client) (string, error) {
+	settings, err := client.GetHouseholdSettings(ctx)
+	if err != nil {
+		return "", err
+	}
+	return effectiveWeekStart(settings.WeekStart), nil
+}
+
+// weekOf は日付 "YYYY-MM-DD" を含む週の初日を返す
+func weekOf(date string, weekStart string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ""
+	}
+	offset := int(t.Weekday()) // 日曜日始まり
+	if weekStart != WeekStartSunday {
+		offset = (offset + 6) % 7
+	}
+	return t.AddDate(0, 0, -offset).Format("2006-01-02")
+}
+
+// addWeeks は週初日 "YYYY-MM-DD" に n 週を加えた日付を返す
+func addWeeks(week string, n int) string {
+	t, err := time.Parse("2006-01-02", week)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, 7*n).Format("2006-01-02")
+}
+
+// weekEndOf は週初日 "YYYY-MM-DD" の週の最終日を返す
+func weekEndOf(week string) string {
+	t, err := time.Parse("2006-01-02", week)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, 6).Format("2006-01-02")
+}
+
+// GetWeeklySummary は date を含む週の集計を返す（payer指定時はフィルタ）。
+// 前週との比較と、前週までの直近 weeks 週（0=4 週）の平均との比較を含む。
+// 集計対象は summaryLines の明細。
+func GetWeeklySummary(ctx context.Context, client *dynamo.Client, date string, weeks int, payer string, userEmail string) (*model.WeeklySummary, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, apperror.New("date は YYYY-MM-DD 形式で指定してください")
+	}
+	if weeks == 0 {
+		weeks = defaultAverageWeeks
+	}
+	if weeks < 1 || weeks > maxAverageWeeks {
+		return nil, apperror.Newf("weeks は 1〜%d で指定してください", maxAverageWeeks)
+	}
+	weekStart, err := loadWeekStart(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	catMaps, err := GetCategoryMaps(ctx, client, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	week := weekOf(date, weekStart)
+	months, err := monthRange(addWeeks(week, -weeks)[:7], addWeeks(week, 1)[:7])
+	if err != nil {
+		return nil, err
+	}
+	byMonth, err := queryMonthsExpenses(ctx, client, months, 1)
+	if err != nil {
+		return nil, err
+	}
+	expenses := FilterExpensesForSummary(flattenMonths(months, byMonth), userEmail)
+	return buildWeeklySummary(expenses, week, weeks, weekStart, payer, catMaps), nil
+}
+
+// buildWeeklySummary は週初日 week の集計と、前週・直近 weeks 週の平均との比較を作る
+func buildWeeklySummary(expenses []model.Expense, week string, weeks int, weekStart string, payer string, catMaps *CategoryMaps) *model.WeeklySummary {
+	byWeek := make(map[string][]model.Expense)
+	for _, e := range expenses {
+		w := weekOf(e.Date, weekStart)
+		byWeek[w] = append(byWeek[w], e)
+	}
+	data := make([]model.WeekData, weeks+1)
+	for i := range data {
+		w := addWeeks(week, i-weeks)
+		data[i] = computeWeekData(byWeek[w], w, payer, catMaps)
+	}
+
+	current, prev := data[weeks], data[weeks-1]
+	average := averageWeekData(data[:weeks])
+	summary := &model.WeeklySummary{
+		Week:         week,
+		WeekEnd:      weekEndOf(week),
+		WeekStart:    weekStart,
+		Total:        current.Total,
+		ByCategory:   current.ByCategory,
+		AverageWeeks: weeks,
+		Weeks:        data,
+	}
+	if prev.Total > 0 || current.Total > 0 {
+		summary.PreviousWeek = makeCategoryComparison(current.Total, prev.Total, current.ByCategory, prev.ByCategory)
+	}
+	if average.Total > 0 || current.Total > 0 {
+		summary.RecentAverage = makeCategoryComparison(current.Total, average.Total, current.ByCategory, average.ByCategory)
+	}
+	return summary
+}
+
+// computeWeekData は週の支出を月別集計（monthDataFromCache）と同じ規則で集計する。
+// total は excludeFromBreakdown を含む全支出カテゴリの合計、byCategory は除外後の内訳。
+func computeWeekData(expenses []model.Expense, week string, payer string, catMaps *CategoryMaps) model.WeekData {
+	allCategories := filterExpenseCategories(categorySummaries(expenses, payer, catMaps), catMaps.IsExpense)
+	summaryCategories := filterSummaryCategories(allCategories, catMaps.ExcludeFromSummary)
+	return model.WeekData{
+		Week:       week,
+		Total:      sumCategories(summaryCategories),
+		ByCategory: filterBreakdownCategories(summaryCategories, catMaps.ExcludeFromBreakdown),
+	}
+}
+
+// averageWeekData は複数週の合計・カテゴリ別金額の平均（円未満四捨五入）を返す
+func averageWeekData(weeks []model.WeekData) model.WeekData {
+	var avg model.WeekData
+	if len(weeks) == 0 {
+		return avg
+	}
+	n := len(weeks)
+	sums := make(map[string]int)
+	var order []model.CategorySummary
+	total := 0
+	for _, w := range weeks {
+		total += w.Total
+		for _, c := range w.ByCategory {
+			if _, ok := sums[c.CategoryID]; !ok {
+				order = append(order, c)
+			}
+			sums[c.CategoryID] += c.Amount
+		}
+	}
+	avg.Total = roundDiv(total, n)
+	for _, c := range order {
+		c.Amount = roundDiv(sums[c.CategoryID], n)
+		avg.ByCategory = append(avg.ByCategory, c)
+	}
+	return avg
+}
+
+// roundDiv は a / n を四捨五入した整数を返す
+func roundDiv(a, n int) int {
+	if a < 0 {
+		return -roundDiv(-a, n)
+	}
+	return (a + n/2) / n
+}
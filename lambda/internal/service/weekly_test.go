@@ -0,0 +1,90 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"money-diary/internal/model"
+)
+
+func TestWeekOf(t *testing.T) {
+	tests := []struct {
+		date      string
+		weekStart string
+		want      string
+	}{
+		{date: "2026-03-02", weekStart: WeekStartMonday, want: "2026-03-02"}, // 月曜日
+		{date: "2026-03-08", weekStart: WeekStartMonday, want: "2026-03-02"}, // 日曜日
+		{date: "2026-03-01", weekStart: WeekStartMonday, want: "2026-02-23"},
+		{date: "2026-03-08", weekStart: WeekStartSunday, want: "2026-03-08"},
+		{date: "2026-03-07", weekStart: WeekStartSunday, want: "2026-03-01"}, // 土曜日
+		{date: "invalid", weekStart: WeekStartMonday, want: ""},
+	}
+	for _, tt := range tests {
+		if got := weekOf(tt.date, tt.weekStart); got != tt.want {
+			t.Errorf("weekOf(%q, %q) = %q, want %q", tt.date, tt.weekStart, got, tt.want)
+		}
+	}
+}
+
+func TestBuildWeeklySummary(t *testing.T) {
+	catMaps := &CategoryMaps{
+		Name:                 map[string]string{"food": "食費", "daily": "日用品", "rent": "家賃"},
+		Color:                map[string]string{"food": "#f00", "daily": "#0f0", "rent": "#00f"},
+		IsExpense:            map[string]bool{"food": true, "daily": true, "rent": true, "income": false},
+		SortOrder:            map[string]int{"food": 1, "daily": 2, "rent": 3},
+		ExcludeFromBreakdown: map[string]bool{"rent": true},
+	}
+	expenses := []model.Expense{
+		// 2 週前（2026-02-23〜03-01）
+		{Date: "2026-02-24", Category: "food", Amount: 5000, Payer: "現金"},
+		{Date: "2026-03-01", Category: "daily", Amount: 1000, Payer: "現金"},
+		// 前週（2026-03-02〜03-08）
+		{Date: "2026-03-03", Category: "food", Amount: 4000, Payer: "現金"},
+		{Date: "2026-03-08", Category: "rent", Amount: 80000, Payer: "口座"},
+		// 当週（2026-03-09〜03-15）
+		{Date: "2026-03-09", Category: "food", Amount: 6000, Payer: "現金"},
+		{Date: "2026-03-15", Type: ExpenseTypeRefund, Category: "food", Amount: 500, Payer: "現金"},
+		{Date: "2026-03-12", Category: "income", Amount: 300000, Payer: "口座"},
+		// 範囲外
+		{Date: "2026-03-16", Category: "food", Amount: 9999, Payer: "現金"},
+	}
+
+	got := buildWeeklySummary(expenses, "2026-03-09", 2, WeekStartMonday, "", catMaps)
+	if got.Week != "2026-03-09" || got.WeekEnd != "2026-03-15" || got.Total != 5500 {
+		t.Errorf("week = %s〜%s total = %d, want 2026-03-09〜2026-03-15 5500", got.Week, got.WeekEnd, got.Total)
+	}
+	wantWeeks := []int{6000, 84000, 5500}
+	for i, w := range got.Weeks {
+		if w.Total != wantWeeks[i] {
+			t.Errorf("Weeks[%d] (%s) total = %d, want %d", i, w.Week, w.Total, wantWeeks[i])
+		}
+	}
+
+	wantPrev := &model.MonthComparison{
+		Total: 84000, Diff: -78500, DiffPercent: -93.45,
+		ByCategory: []model.CategoryComparison{
+			{CategoryID: "food", Category: "食費", Amount: 4000, Diff: 1500, DiffPercent: 37.5},
+		},
+	}
+	if !reflect.DeepEqual(got.PreviousWeek, wantPrev) {
+		t.Errorf("PreviousWeek = %+v, want %+v", got.PreviousWeek, wantPrev)
+	}
+	// 平均: 合計 (6000+84000)/2、食費 (5000+4000)/2、日用品 1000/2
+	wantAvg := &model.MonthComparison{
+		Total: 45000, Diff: -39500, DiffPercent: -87.77,
+		ByCategory: []model.CategoryComparison{
+			{CategoryID: "food", Category: "食費", Amount: 4500, Diff: 1000, DiffPercent: 22.22},
+			{CategoryID: "daily", Category: "日用品", Amount: 500, Diff: -500, DiffPercent: -100},
+		},
+	}
+	if !reflect.DeepEqual(got.RecentAverage, wantAvg) {
+		t.Errorf("RecentAverage = %+v, want %+v", got.RecentAverage, wantAvg)
+	}
+
+	// 支払元フィルタ・日曜日始まり
+	got = buildWeeklySummary(expenses, "2026-03-08", 1, WeekStartSunday, "口座", catMaps)
+	if got.Total != 80000 || got.PreviousWeek == nil || got.PreviousWeek.Total != 0 {
+		t.Errorf("sunday/payer total = %d previous = %+v, want 80000 and 0", got.Total, got.PreviousWeek)
+	}
+}